@@ -0,0 +1,189 @@
+package alerts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bookaroo/bookaroo-platform-be/filters"
+	"github.com/bookaroo/bookaroo-platform-be/geo"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/notifications"
+	"github.com/bookaroo/bookaroo-platform-be/search"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxNamed is how many new properties a notification names before summing up the rest
+const maxNamed = 3
+
+// Matcher runs saved searches and tells guests about new matches and price drops
+type Matcher struct {
+	DB       *gorm.DB
+	Geocoder geo.Geocoder
+	Search   search.Backend
+	Notifier notifications.Notifier
+}
+
+func NewMatcher(db *gorm.DB, geocoder geo.Geocoder, backend search.Backend, notifier notifications.Notifier) *Matcher {
+	return &Matcher{DB: db, Geocoder: geocoder, Search: backend, Notifier: notifier}
+}
+
+// Criteria parses the search parameters of a saved search run by guestID
+func (m *Matcher) Criteria(ctx context.Context, query string, guestID uint) (*filters.Criteria, error) {
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return filters.Parse(ctx, params, guestID, m.Geocoder, m.Search)
+}
+
+// CheckAll checks every saved search with alerts enabled
+func (m *Matcher) CheckAll(ctx context.Context) error {
+	var searches []models.SavedSearch
+	if err := m.DB.WithContext(ctx).Where("alerts_enabled = ?", true).Order("id").Find(&searches).Error; err != nil {
+		return err
+	}
+
+	failed := 0
+	for i := range searches {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := m.Check(ctx, &searches[i]); err != nil {
+			log.Printf("Failed to check saved search %d: %v", searches[i].ID, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d saved searches failed to check", failed, len(searches))
+	}
+	return nil
+}
+
+// Check compares the current matches of a saved search with those seen at the
+// previous check and notifies the guest of properties that newly match and of
+// matches whose price dropped. The first check only records the matches.
+// Searches that can no longer run, such as those for stay dates that have
+// passed, have their alerts turned off.
+func (m *Matcher) Check(ctx context.Context, saved *models.SavedSearch) error {
+	db := m.DB.WithContext(ctx)
+
+	criteria, err := m.Criteria(ctx, saved.Query, saved.UserID)
+	var invalid *filters.InvalidError
+	if errors.As(err, &invalid) {
+		log.Printf("Turning off alerts for saved search %d: %v", saved.ID, err)
+		saved.AlertsEnabled = false
+		return db.Model(saved).Update("alerts_enabled", false).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	var current []models.Property
+	if err := criteria.Apply(db.Model(&models.Property{})).
+		Scopes(models.ListedScope).
+		Select("properties.id, properties.name, properties.price").Order("properties.id").
+		Find(&current).Error; err != nil {
+		return err
+	}
+
+	var seen []models.SavedSearchMatch
+	if err := db.Where("saved_search_id = ?", saved.ID).Find(&seen).Error; err != nil {
+		return err
+	}
+	previous := make(map[uint]float64, len(seen))
+	for _, s := range seen {
+		previous[s.PropertyID] = s.Price
+	}
+
+	now := time.Now()
+	var fresh, cheaper []models.Property
+	matches := make([]models.SavedSearchMatch, 0, len(current))
+	for _, p := range current {
+		if price, ok := previous[p.ID]; !ok {
+			fresh = append(fresh, p)
+		} else if p.Price < price {
+			cheaper = append(cheaper, p)
+		}
+		matches = append(matches, models.SavedSearchMatch{SavedSearchID: saved.ID, PropertyID: p.ID, Price: p.Price, SeenAt: now})
+	}
+
+	// Properties that stop matching keep their last price, so one that comes
+	// back is neither new nor announced again unless it got cheaper
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if len(matches) > 0 {
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "saved_search_id"}, {Name: "property_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"price", "seen_at"}),
+			}).CreateInBatches(matches, 500).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(saved).Update("last_checked_at", now).Error
+	}); err != nil {
+		return err
+	}
+
+	first := saved.LastCheckedAt == nil
+	saved.LastCheckedAt = &now
+	if first {
+		return nil
+	}
+
+	if len(fresh) > 0 {
+		m.notify(ctx, saved, "saved_search_match", "New properties for "+saved.Name, newMatchesMessage(fresh), link(saved, fresh))
+	}
+	for _, p := range cheaper {
+		m.notify(ctx, saved, "saved_search_price_drop", "Price drop for "+saved.Name,
+			fmt.Sprintf("%s is now %.2f a night, down from %.2f.", p.Name, p.Price, previous[p.ID]),
+			fmt.Sprintf("/api/properties/%d", p.ID))
+	}
+	return nil
+}
+
+// notify tells the guest about a saved search. A failure is only logged: the
+// matches are recorded already and shouldn't be announced twice.
+func (m *Matcher) notify(ctx context.Context, saved *models.SavedSearch, kind, title, message, link string) {
+	if err := m.Notifier.Notify(ctx, &models.Notification{
+		UserID:  saved.UserID,
+		Type:    kind,
+		Title:   title,
+		Message: message,
+		Link:    link,
+	}); err != nil {
+		log.Printf("Failed to notify user %d about saved search %d: %v", saved.UserID, saved.ID, err)
+	}
+}
+
+// newMatchesMessage names the first few properties that newly match a search
+func newMatchesMessage(properties []models.Property) string {
+	names := make([]string, 0, maxNamed)
+	for i, p := range properties {
+		if i == maxNamed {
+			break
+		}
+		names = append(names, p.Name)
+	}
+	listed := strings.Join(names, ", ")
+	if rest := len(properties) - len(names); rest > 0 {
+		listed += fmt.Sprintf(" and %d more", rest)
+	}
+	if len(properties) == 1 {
+		return fmt.Sprintf("%s now matches your saved search.", listed)
+	}
+	return fmt.Sprintf("%d properties now match your saved search: %s.", len(properties), listed)
+}
+
+// link points at the property when there is one, or else at the search
+func link(saved *models.SavedSearch, properties []models.Property) string {
+	if len(properties) == 1 {
+		return fmt.Sprintf("/api/properties/%d", properties[0].ID)
+	}
+	return "/api/properties/search?" + saved.Query
+}
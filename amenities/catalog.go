@@ -0,0 +1,154 @@
+package amenities
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Defaults is the catalog every installation starts with
+var Defaults = []models.Amenity{
+	{Code: "wifi", Name: "Wi-Fi", Category: "essentials", IconKey: "wifi"},
+	{Code: "air_conditioning", Name: "Air conditioning", Category: "essentials", IconKey: "snowflake"},
+	{Code: "heating", Name: "Heating", Category: "essentials", IconKey: "thermometer"},
+	{Code: "kitchen", Name: "Kitchen", Category: "essentials", IconKey: "utensils"},
+	{Code: "washer", Name: "Washer", Category: "essentials", IconKey: "washing-machine"},
+	{Code: "tv", Name: "TV", Category: "entertainment", IconKey: "tv"},
+	{Code: "workspace", Name: "Dedicated workspace", Category: "essentials", IconKey: "laptop"},
+	{Code: "pool", Name: "Pool", Category: "facilities", IconKey: "waves"},
+	{Code: "hot_tub", Name: "Hot tub", Category: "facilities", IconKey: "bath"},
+	{Code: "gym", Name: "Gym", Category: "facilities", IconKey: "dumbbell"},
+	{Code: "parking", Name: "Free parking", Category: "facilities", IconKey: "car"},
+	{Code: "ev_charger", Name: "EV charger", Category: "facilities", IconKey: "plug"},
+	{Code: "beach_access", Name: "Beach access", Category: "location", IconKey: "umbrella-beach"},
+	{Code: "garden", Name: "Garden", Category: "outdoor", IconKey: "tree"},
+	{Code: "bbq", Name: "BBQ grill", Category: "outdoor", IconKey: "flame"},
+	{Code: "pets_allowed", Name: "Pets allowed", Category: "policies", IconKey: "paw"},
+	{Code: "smoke_alarm", Name: "Smoke alarm", Category: "safety", IconKey: "alarm"},
+	{Code: "first_aid_kit", Name: "First aid kit", Category: "safety", IconKey: "first-aid"},
+}
+
+// aliases maps common free-form spellings to catalog codes
+var aliases = map[string]string{
+	"wi fi":             "wifi",
+	"wireless internet": "wifi",
+	"internet":          "wifi",
+	"ac":                "air_conditioning",
+	"a c":               "air_conditioning",
+	"aircon":            "air_conditioning",
+	"swimming pool":     "pool",
+	"private pool":      "pool",
+	"jacuzzi":           "hot_tub",
+	"spa":               "hot_tub",
+	"free parking":      "parking",
+	"washing machine":   "washer",
+	"laundry":           "washer",
+	"pet friendly":      "pets_allowed",
+	"pets":              "pets_allowed",
+	"barbecue":          "bbq",
+	"grill":             "bbq",
+	"television":        "tv",
+	"beach":             "beach_access",
+	"desk":              "workspace",
+	"fitness center":    "gym",
+}
+
+// Normalize turns a free-form amenity name such as "Wi-Fi" into its catalog code,
+// or the normalized text when it matches nothing known
+func Normalize(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+	key := strings.Join(words, " ")
+	if code, ok := aliases[key]; ok {
+		return code
+	}
+	return strings.Join(words, "_")
+}
+
+// ParseCodes splits a comma-separated list such as "pool,wifi" into normalized, de-duplicated codes
+func ParseCodes(list string) []string {
+	var codes []string
+	seen := map[string]bool{}
+	for _, part := range strings.Split(list, ",") {
+		code := Normalize(part)
+		if code == "" || seen[code] {
+			continue
+		}
+		seen[code] = true
+		codes = append(codes, code)
+	}
+	return codes
+}
+
+// Lookup loads the catalog entries for codes, failing on any code that isn't in the catalog
+func Lookup(db *gorm.DB, codes []string) ([]models.Amenity, error) {
+	if len(codes) == 0 {
+		return []models.Amenity{}, nil
+	}
+
+	var found []models.Amenity
+	if err := db.Where("code IN ?", codes).Find(&found).Error; err != nil {
+		return nil, err
+	}
+	if len(found) != len(codes) {
+		known := map[string]bool{}
+		for _, a := range found {
+			known[a.Code] = true
+		}
+		var unknown []string
+		for _, code := range codes {
+			if !known[code] {
+				unknown = append(unknown, code)
+			}
+		}
+		return nil, fmt.Errorf("unknown amenities: %s", strings.Join(unknown, ", "))
+	}
+	return found, nil
+}
+
+// Seed adds the default catalog entries that are missing, leaving edited entries alone
+func Seed(db *gorm.DB) error {
+	defaults := append([]models.Amenity(nil), Defaults...)
+	return db.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "code"}}, DoNothing: true}).Create(&defaults).Error
+}
+
+// Named returns the catalog entries named in a free-form amenities text such
+// as "Wi-Fi, swimming pool". Names that match nothing are left out.
+func Named(db *gorm.DB, text string) ([]models.Amenity, error) {
+	found := []models.Amenity{}
+	codes := ParseCodes(text)
+	if len(codes) == 0 {
+		return found, nil
+	}
+	err := db.Where("code IN ?", codes).Order("code").Find(&found).Error
+	return found, err
+}
+
+// Backfill links properties that have no catalog amenities yet to the entries
+// named in their free-form Amenities text. Names that match nothing are kept in
+// the text only.
+func Backfill(db *gorm.DB) error {
+	var properties []models.Property
+	if err := db.Where("amenities <> '' AND NOT EXISTS (SELECT 1 FROM property_amenities pa WHERE pa.property_id = properties.id)").
+		Find(&properties).Error; err != nil {
+		return err
+	}
+
+	for i := range properties {
+		links, err := Named(db, properties[i].Amenities)
+		if err != nil {
+			return err
+		}
+		if len(links) == 0 {
+			continue
+		}
+		if err := db.Model(&properties[i]).Association("AmenityList").Append(links); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package availability
+
+import (
+	"time"
+
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"gorm.io/gorm"
+)
+
+// HasConflict reports whether a stay from start to end (checkout day, exclusive)
+// overlaps a non-cancelled booking or a block on the property. A booking ID can
+// be excluded so a reservation can be moved without conflicting with itself.
+func HasConflict(db *gorm.DB, propertyID uint, start, end time.Time, excludeBookingID uint) (bool, error) {
+	var bookings int64
+	query := db.Model(&models.Booking{}).
+		Where("property_id = ? AND status != 'cancelled' AND start_date < ? AND end_date > ?", propertyID, end, start)
+	if excludeBookingID != 0 {
+		query = query.Where("id != ?", excludeBookingID)
+	}
+	if err := query.Count(&bookings).Error; err != nil {
+		return false, err
+	}
+	if bookings > 0 {
+		return true, nil
+	}
+
+	var blocks int64
+	if err := db.Model(&models.PropertyBlock{}).
+		Where("property_id = ? AND start_date < ? AND end_date > ?", propertyID, end, start).
+		Count(&blocks).Error; err != nil {
+		return false, err
+	}
+
+	return blocks > 0, nil
+}
+
+// FreeBetween is a query scope keeping only properties with no non-cancelled
+// booking or block overlapping the stay from start to end, and no dates held
+// there for another waitlisted guest than guestID (0 for anonymous searches).
+// It checks all properties in one query, for searches across many listings.
+func FreeBetween(start, end time.Time, guestID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.
+			Where("NOT EXISTS (SELECT 1 FROM bookings b WHERE b.property_id = properties.id AND b.status != 'cancelled' AND b.start_date < ? AND b.end_date > ?)", end, start).
+			Where("NOT EXISTS (SELECT 1 FROM property_blocks pb WHERE pb.property_id = properties.id AND pb.start_date < ? AND pb.end_date > ?)", end, start).
+			// The same priority windows waitlist.IsHeld checks when booking
+			Where(`NOT EXISTS (SELECT 1 FROM waitlist_entries we WHERE we.property_id = properties.id AND we.status = 'offered'
+				AND we.offer_expires_at > ? AND we.start_date < ? AND we.end_date > ? AND we.user_id != ?)`, time.Now(), end, start, guestID)
+	}
+}
@@ -0,0 +1,140 @@
+package billing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/notifications"
+	"gorm.io/gorm"
+)
+
+const (
+	MinMonths = 1
+	MaxMonths = 6
+)
+
+var (
+	ErrNothingToTerminate = errors.New("the stay already ends before the notice period is over")
+	ErrNotStarted         = errors.New("the notice period is over before the stay starts; cancel the booking instead")
+)
+
+// Schedule splits a monthly stay into one installment per month. The first
+// installment is due at booking; the others fall due at the start of their month.
+func Schedule(start time.Time, months int, monthlyPrice float64) []models.Installment {
+	installments := make([]models.Installment, 0, months)
+	for i := 0; i < months; i++ {
+		periodStart := start.AddDate(0, i, 0)
+		installment := models.Installment{
+			Sequence:    i + 1,
+			PeriodStart: periodStart,
+			PeriodEnd:   start.AddDate(0, i+1, 0),
+			DueDate:     periodStart,
+			Amount:      monthlyPrice,
+			Status:      "scheduled",
+		}
+		if i == 0 {
+			installment.Status = "due"
+		}
+		installments = append(installments, installment)
+	}
+	return installments
+}
+
+// EarliestEnd returns the first day a monthly stay may end when notice is given at noticeAt
+func EarliestEnd(noticeAt time.Time, noticePeriodDays int) time.Time {
+	return noticeAt.AddDate(0, 0, noticePeriodDays)
+}
+
+// Terminate ends a stay early at newEnd. Unpaid installments for months
+// starting on or after newEnd are voided and the month containing newEnd is
+// prorated by day. Paid installments are never changed: what was paid for the
+// nights after newEnd is returned as a refund instead. It returns the adjusted
+// installments, the new total price and the refund.
+func Terminate(installments []models.Installment, newEnd time.Time) ([]models.Installment, float64, float64) {
+	total, refund := 0.0, 0.0
+	for i := range installments {
+		inst := &installments[i]
+		if inst.Status == "void" {
+			continue
+		}
+
+		owed := inst.Amount
+		switch {
+		case !inst.PeriodStart.Before(newEnd):
+			owed = 0
+		case inst.PeriodEnd.After(newEnd):
+			usedDays := newEnd.Sub(inst.PeriodStart).Hours() / 24
+			periodDays := inst.PeriodEnd.Sub(inst.PeriodStart).Hours() / 24
+			owed = math.Round(inst.Amount*usedDays/periodDays*100) / 100
+		}
+		total += owed
+
+		if inst.Status == "paid" {
+			refund += inst.Amount - owed
+			continue
+		}
+		if owed == 0 {
+			inst.Status = "void"
+		} else if owed != inst.Amount {
+			inst.Amount = owed
+			inst.PeriodEnd = newEnd
+		}
+	}
+	return installments, math.Round(total*100) / 100, math.Round(refund*100) / 100
+}
+
+// Biller moves installments through their lifecycle and reminds guests of charges
+type Biller struct {
+	DB       *gorm.DB
+	Notifier notifications.Notifier
+}
+
+func NewBiller(db *gorm.DB, notifier notifications.Notifier) *Biller {
+	return &Biller{DB: db, Notifier: notifier}
+}
+
+// MarkDue flags scheduled installments whose due date has arrived and notifies the guests
+func (b *Biller) MarkDue(ctx context.Context) error {
+	var installments []models.Installment
+	if err := b.DB.WithContext(ctx).
+		Where("status = 'scheduled' AND due_date <= ?", time.Now()).
+		Find(&installments).Error; err != nil {
+		return err
+	}
+
+	for _, inst := range installments {
+		var booking models.Booking
+		if err := b.DB.WithContext(ctx).Preload("Property").First(&booking, inst.BookingID).Error; err != nil {
+			return err
+		}
+
+		// Installments of cancelled stays are never charged
+		status := "due"
+		if booking.Status == "cancelled" {
+			status = "void"
+		}
+		if err := b.DB.WithContext(ctx).Model(&inst).Update("status", status).Error; err != nil {
+			return err
+		}
+		// Partner guests have no account to notify
+		if status == "void" || booking.UserID == nil {
+			continue
+		}
+
+		if err := b.Notifier.Notify(ctx, &models.Notification{
+			UserID:  *booking.UserID,
+			Type:    "installment_due",
+			Title:   "Monthly payment due",
+			Message: fmt.Sprintf("Payment %d of %d for %s (%.2f) is due.", inst.Sequence, booking.Months, booking.Property.Name, inst.Amount),
+			Link:    fmt.Sprintf("/api/bookings/%d/installments", booking.ID),
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
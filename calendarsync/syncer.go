@@ -0,0 +1,223 @@
+package calendarsync
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bookaroo/bookaroo-platform-be/ical"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"gorm.io/gorm"
+)
+
+// maxFeedSize caps how much of a remote calendar is read
+const maxFeedSize = 5 << 20
+
+// Syncer imports external iCalendar feeds into property blocks
+type Syncer struct {
+	DB *gorm.DB
+	// Client fetches the feeds. The default only connects to public addresses,
+	// since feed URLs are chosen by owners.
+	Client *http.Client
+}
+
+// Errors stored on a failing source. Owners can read them, so they never
+// contain anything from the remote server's response.
+var (
+	errFetch    = errors.New("feed could not be downloaded")
+	errInvalid  = errors.New("feed is not valid iCalendar")
+	errTooLarge = errors.New("feed too large")
+	errSave     = errors.New("feed could not be saved")
+)
+
+// syncError pairs the error shown to the owner with the underlying cause, which is only logged
+type syncError struct {
+	public error
+	cause  error
+}
+
+func (e *syncError) Error() string { return e.public.Error() + ": " + e.cause.Error() }
+func (e *syncError) Unwrap() error { return e.cause }
+
+func NewSyncer(db *gorm.DB) *Syncer {
+	return &Syncer{
+		DB:     db,
+		Client: guardedClient(30 * time.Second),
+	}
+}
+
+// SyncAll synchronizes every registered calendar source. A failing source is
+// recorded on the source itself and does not stop the others.
+func (s *Syncer) SyncAll(ctx context.Context) error {
+	var sources []models.CalendarSource
+	if err := s.DB.WithContext(ctx).Find(&sources).Error; err != nil {
+		return err
+	}
+
+	failed := 0
+	for i := range sources {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := s.SyncSource(ctx, &sources[i]); err != nil {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d calendar sources failed to sync", failed, len(sources))
+	}
+	return nil
+}
+
+// SyncSource fetches one feed and reconciles its blocks: events are upserted by
+// UID and blocks whose events disappeared from the feed are removed. The outcome
+// is stored in the source's sync status and last error.
+func (s *Syncer) SyncSource(ctx context.Context, source *models.CalendarSource) error {
+	events, err := s.fetch(ctx, source.URL)
+	if err == nil {
+		if err = s.reconcile(ctx, source, events); err != nil {
+			err = &syncError{public: errSave, cause: err}
+		}
+	}
+
+	now := time.Now()
+	source.LastSyncedAt = &now
+	if err != nil {
+		log.Printf("Failed to sync calendar source %d: %v", source.ID, err)
+		source.SyncStatus = "error"
+		source.LastError = errFetch.Error()
+		var syncErr *syncError
+		if errors.As(err, &syncErr) {
+			source.LastError = syncErr.public.Error()
+		}
+	} else {
+		source.SyncStatus = "ok"
+		source.LastError = ""
+	}
+
+	if saveErr := s.DB.WithContext(ctx).Model(source).Updates(map[string]interface{}{
+		"sync_status":    source.SyncStatus,
+		"last_error":     source.LastError,
+		"last_synced_at": source.LastSyncedAt,
+	}).Error; saveErr != nil && err == nil {
+		err = saveErr
+	}
+
+	return err
+}
+
+func (s *Syncer) fetch(ctx context.Context, url string) ([]ical.Event, error) {
+	// webcal:// is the conventional scheme for subscribable calendars
+	if strings.HasPrefix(url, "webcal://") {
+		url = "https://" + strings.TrimPrefix(url, "webcal://")
+	}
+
+	// URLs were checked when registered; Client checks the addresses it dials
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, &syncError{public: errFetch, cause: err}
+	}
+	req.Header.Set("Accept", "text/calendar")
+
+	resp, err := s.Client.Do(req)
+	if errors.Is(err, ErrForbiddenAddress) {
+		return nil, &syncError{public: ErrForbiddenAddress, cause: err}
+	} else if err != nil {
+		return nil, &syncError{public: errFetch, cause: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &syncError{public: errFetch, cause: fmt.Errorf("unexpected status %s", resp.Status)}
+	}
+
+	// Read one byte past the limit so an oversized feed fails instead of being
+	// cut short, which would drop the blocks after the cut on reconcile
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxFeedSize+1))
+	if err != nil {
+		return nil, &syncError{public: errFetch, cause: err}
+	}
+	if len(body) > maxFeedSize {
+		return nil, &syncError{public: errTooLarge, cause: fmt.Errorf("feed exceeds %d bytes", maxFeedSize)}
+	}
+
+	events, err := ical.Parse(bytes.NewReader(body))
+	if err != nil {
+		return nil, &syncError{public: errInvalid, cause: err}
+	}
+	return events, nil
+}
+
+func (s *Syncer) reconcile(ctx context.Context, source *models.CalendarSource, events []ical.Event) error {
+	return s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		seen := make([]string, 0, len(events))
+
+		for _, event := range events {
+			if event.Status == "CANCELLED" {
+				continue
+			}
+
+			start := ical.DateOnly(event.Start)
+			end := ical.DateOnly(event.End)
+			if !end.After(start) {
+				end = start.AddDate(0, 0, 1)
+			}
+
+			uid := event.UID
+			if uid == "" {
+				uid = fallbackUID(event)
+			}
+			seen = append(seen, uid)
+
+			var block models.PropertyBlock
+			err := tx.Where("calendar_source_id = ? AND external_uid = ?", source.ID, uid).First(&block).Error
+			switch {
+			case err == gorm.ErrRecordNotFound:
+				block = models.PropertyBlock{
+					PropertyID:       source.PropertyID,
+					StartDate:        start,
+					EndDate:          end,
+					Note:             event.Summary,
+					Source:           "ical",
+					CalendarSourceID: &source.ID,
+					ExternalUID:      uid,
+				}
+				if err := tx.Create(&block).Error; err != nil {
+					return err
+				}
+			case err != nil:
+				return err
+			default:
+				if err := tx.Model(&block).Updates(map[string]interface{}{
+					"start_date": start,
+					"end_date":   end,
+					"note":       event.Summary,
+				}).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		// Remove blocks whose events are no longer in the feed
+		stale := tx.Where("calendar_source_id = ?", source.ID)
+		if len(seen) > 0 {
+			stale = stale.Where("external_uid NOT IN ?", seen)
+		}
+		return stale.Delete(&models.PropertyBlock{}).Error
+	})
+}
+
+// fallbackUID derives a stable identifier for feeds that omit UID
+func fallbackUID(event ical.Event) string {
+	sum := sha1.Sum([]byte(event.Start.Format(time.RFC3339) + "|" + event.End.Format(time.RFC3339) + "|" + event.Summary))
+	return hex.EncodeToString(sum[:])
+}
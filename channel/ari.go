@@ -0,0 +1,74 @@
+package channel
+
+import (
+	"time"
+
+	"github.com/bookaroo/bookaroo-platform-be/ical"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"gorm.io/gorm"
+)
+
+const dateLayout = "2006-01-02"
+
+// ARIDay is the availability, rate and inventory of a property for one night
+type ARIDay struct {
+	Date      string  `json:"date"`
+	Available bool    `json:"available"`
+	Inventory int     `json:"inventory"` // Units left to sell; a property is a single unit
+	Rate      float64 `json:"rate"`
+}
+
+// ARI is the availability, rates and inventory of a property over a date range
+type ARI struct {
+	PropertyID        uint     `json:"property_id"`
+	ExternalListingID string   `json:"external_listing_id"`
+	StartDate         string   `json:"start_date"`
+	EndDate           string   `json:"end_date"`
+	Days              []ARIDay `json:"days"`
+}
+
+// BuildARI computes the nightly availability of a property from start up to
+// (but excluding) end. Properties that can't be booked, because they are
+// archived, deleted or not published, have no availability at all.
+func BuildARI(db *gorm.DB, property models.Property, start, end time.Time) ([]ARIDay, error) {
+	start = ical.DateOnly(start)
+	end = ical.DateOnly(end)
+
+	var bookings []models.Booking
+	if err := db.Where("property_id = ? AND status != 'cancelled' AND start_date < ? AND end_date > ?", property.ID, end, start).
+		Find(&bookings).Error; err != nil {
+		return nil, err
+	}
+
+	var blocks []models.PropertyBlock
+	if err := db.Where("property_id = ? AND start_date < ? AND end_date > ?", property.ID, end, start).
+		Find(&blocks).Error; err != nil {
+		return nil, err
+	}
+
+	// Mark every night covered by a booking or block
+	occupied := make(map[string]bool)
+	mark := func(from, to time.Time) {
+		for d := ical.DateOnly(from); d.Before(ical.DateOnly(to)); d = d.AddDate(0, 0, 1) {
+			occupied[d.Format(dateLayout)] = true
+		}
+	}
+	for _, booking := range bookings {
+		mark(booking.StartDate, booking.EndDate)
+	}
+	for _, block := range blocks {
+		mark(block.StartDate, block.EndDate)
+	}
+
+	days := make([]ARIDay, 0)
+	for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+		date := d.Format(dateLayout)
+		day := ARIDay{Date: date, Available: property.Listed() && !occupied[date], Rate: property.Price}
+		if day.Available {
+			day.Inventory = 1
+		}
+		days = append(days, day)
+	}
+
+	return days, nil
+}
@@ -0,0 +1,20 @@
+package channel
+
+import (
+	"gorm.io/gorm"
+)
+
+// BackfillGuests moves the guests of partner reservations made before they
+// were kept on the booking off the password-less accounts created for them.
+// Those accounts are then removed, so the guests can register themselves.
+func BackfillGuests(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`UPDATE bookings b SET guest_name = u.name, guest_email = u.email, user_id = NULL
+			FROM users u WHERE b.user_id = u.id AND b.partner_id IS NOT NULL AND u.password = ''`).Error; err != nil {
+			return err
+		}
+		return tx.Exec(`DELETE FROM users u WHERE u.password = '' AND u.role = 'guest'
+			AND NOT EXISTS (SELECT 1 FROM bookings b WHERE b.user_id = u.id)
+			AND NOT EXISTS (SELECT 1 FROM properties p WHERE p.owner_id = u.id)`).Error
+	})
+}
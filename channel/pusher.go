@@ -0,0 +1,99 @@
+package channel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"gorm.io/gorm"
+)
+
+// Pusher sends availability and rate updates to partners that registered a webhook
+type Pusher struct {
+	DB      *gorm.DB
+	Client  *http.Client
+	Horizon int // Number of nights ahead to push
+}
+
+func NewPusher(db *gorm.DB) *Pusher {
+	return &Pusher{
+		DB:      db,
+		Client:  &http.Client{Timeout: 30 * time.Second},
+		Horizon: 180,
+	}
+}
+
+// PushAll pushes the ARI of every mapped listing to its partner. A failing
+// partner does not stop the others.
+func (p *Pusher) PushAll(ctx context.Context) error {
+	var partners []models.Partner
+	if err := p.DB.WithContext(ctx).Preload("Listings").
+		Where("active = ? AND webhook_url != ''", true).Find(&partners).Error; err != nil {
+		return err
+	}
+
+	start := time.Now()
+	end := start.AddDate(0, 0, p.Horizon)
+
+	failed := 0
+	for _, partner := range partners {
+		for _, listing := range partner.Listings {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err := p.PushListing(ctx, partner, listing, start, end); err != nil {
+				failed++
+			}
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d ARI pushes failed", failed)
+	}
+	return nil
+}
+
+// PushListing sends the ARI of one mapped listing to the partner's webhook
+func (p *Pusher) PushListing(ctx context.Context, partner models.Partner, listing models.PartnerListing, start, end time.Time) error {
+	var property models.Property
+	if err := p.DB.WithContext(ctx).First(&property, listing.PropertyID).Error; err != nil {
+		return err
+	}
+
+	days, err := BuildARI(p.DB.WithContext(ctx), property, start, end)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(ARI{
+		PropertyID:        property.ID,
+		ExternalListingID: listing.ExternalListingID,
+		StartDate:         start.Format(dateLayout),
+		EndDate:           end.Format(dateLayout),
+		Days:              days,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, partner.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("partner %s rejected ARI update: %s", partner.Code, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+// Command reindex rebuilds the search index of the backend selected by
+// SEARCH_BACKEND from the properties in the database.
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/bookaroo/bookaroo-platform-be/config"
+	"github.com/bookaroo/bookaroo-platform-be/search"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	if err := godotenv.Load(); err != nil {
+		log.Printf("Error loading .env file: %v", err)
+	}
+
+	db := config.InitDB()
+	backend, err := search.NewFromEnv(db)
+	if err != nil {
+		log.Fatal("Failed to configure search:", err)
+	}
+
+	start := time.Now()
+	if err := backend.Rebuild(context.Background()); err != nil {
+		log.Fatal("Failed to rebuild the search index:", err)
+	}
+	log.Printf("Rebuilt the search index in %s", time.Since(start).Round(time.Millisecond))
+}
@@ -5,7 +5,12 @@ import (
 	"log"
 	"os"
 
+	"github.com/bookaroo/bookaroo-platform-be/amenities"
+	"github.com/bookaroo/bookaroo-platform-be/channel"
+	"github.com/bookaroo/bookaroo-platform-be/locations"
 	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/pricing"
+	"github.com/bookaroo/bookaroo-platform-be/search"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
@@ -26,7 +31,76 @@ func InitDB() *gorm.DB {
 	}
 
 	// Auto migrate the models
-	db.AutoMigrate(&models.User{}, &models.Property{}, &models.PropertyImage{}, &models.Booking{})
+	if err := Migrate(db); err != nil {
+		log.Fatal("Failed to migrate database:", err)
+	}
 
 	return db
 }
+
+// Migrate brings the database schema up to date with the models
+func Migrate(db *gorm.DB) error {
+	if err := db.AutoMigrate(
+		&models.User{},
+		&models.Amenity{},
+		&models.Place{},
+		&models.Property{},
+		&models.PropertyImage{},
+		&models.PropertyTranslation{},
+		&models.Booking{},
+		&models.PropertyBlock{},
+		&models.CalendarSource{},
+		&models.Partner{},
+		&models.PartnerListing{},
+		&models.Notification{},
+		&models.WaitlistEntry{},
+		&models.Installment{},
+		&models.ImportJob{},
+		&models.ImportRow{},
+		&models.Wishlist{},
+		&models.WishlistItem{},
+		&models.SavedSearch{},
+		&models.SavedSearchMatch{},
+		&models.PriceChange{},
+		&models.DuplicateFlag{},
+		&models.Review{},
+	); err != nil {
+		return err
+	}
+
+	if err := search.Migrate(db); err != nil {
+		return err
+	}
+
+	// Properties with images from before covers existed use their first image
+	if err := db.Exec(`UPDATE property_images SET is_cover = true WHERE id IN (
+		SELECT MIN(id) FROM property_images GROUP BY property_id HAVING NOT bool_or(is_cover))`).Error; err != nil {
+		return err
+	}
+
+	// Link properties created before the amenities catalog to its entries
+	if err := amenities.Seed(db); err != nil {
+		return err
+	}
+	if err := amenities.Backfill(db); err != nil {
+		return err
+	}
+
+	// File properties created before the place hierarchy under their location
+	if err := locations.Backfill(db); err != nil {
+		return err
+	}
+
+	// Keep the guests of older partner reservations on the booking instead of an account
+	if err := channel.BackfillGuests(db); err != nil {
+		return err
+	}
+
+	// Fill in the public address of properties from before addresses were structured
+	if err := locations.BackfillAddresses(db); err != nil {
+		return err
+	}
+
+	// Start the price history of properties from before it was recorded
+	return pricing.Backfill(db)
+}
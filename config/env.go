@@ -0,0 +1,22 @@
+package config
+
+import (
+	"log"
+	"os"
+	"time"
+)
+
+// GetDuration reads a duration such as "30m" from the environment, falling back to def when unset or invalid
+func GetDuration(key string, def time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("Invalid %s %q, using %s", key, value, def)
+		return def
+	}
+	return d
+}
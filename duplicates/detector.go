@@ -0,0 +1,137 @@
+package duplicates
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// DefaultThreshold is the score from which two properties are flagged
+	DefaultThreshold = 0.75
+	// maxCandidates is how many properties a listing is compared with
+	maxCandidates = 500
+	// nearbyKm is how far around a listing other listings are compared with it
+	nearbyKm = 1.0
+	// hashDistanceSQL counts the bits a stored photo hash differs from the hash passed in
+	hashDistanceSQL = "LENGTH(REPLACE(CAST(CAST(perceptual_hash # ? AS bit(64)) AS text), '0', ''))"
+)
+
+// Detector flags properties that are probably listed twice. A listing is
+// compared with the other listings of its owner, those in the same place or
+// nearby, those sharing a photo and those it was flagged with before.
+type Detector struct {
+	DB        *gorm.DB
+	Threshold float64
+}
+
+func NewDetector(db *gorm.DB) *Detector {
+	return &Detector{DB: db, Threshold: DefaultThreshold}
+}
+
+// Check compares a property with the listings it could duplicate and flags
+// those scoring Threshold or more. Open flags are rescored, and dropped once
+// the pair no longer looks alike; reviewed pairs are left as they were
+// decided. It returns the open flags of the property.
+func (d *Detector) Check(ctx context.Context, propertyID uint) ([]models.DuplicateFlag, error) {
+	db := d.DB.WithContext(ctx)
+
+	var property models.Property
+	if err := db.Preload("Images").First(&property, propertyID).Error; err != nil {
+		return nil, err
+	}
+	if property.DeletedAt != nil {
+		return nil, nil
+	}
+	fp := Of(property)
+
+	candidates, err := d.candidates(db, property, fp)
+	if err != nil {
+		return nil, err
+	}
+
+	var flags, cleared []models.DuplicateFlag
+	now := time.Now()
+	for _, other := range candidates {
+		match := Compare(fp, Of(other))
+		flag := models.DuplicateFlag{
+			PropertyID:    max(property.ID, other.ID),
+			DuplicateOfID: min(property.ID, other.ID),
+			Score:         math.Round(match.Score*100) / 100,
+			Reasons:       match.Reasons,
+			Status:        models.DuplicateOpen,
+			UpdatedAt:     now,
+		}
+		if match.Score >= d.Threshold {
+			flags = append(flags, flag)
+		} else {
+			cleared = append(cleared, flag)
+		}
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		for _, flag := range cleared {
+			if err := tx.Where("property_id = ? AND duplicate_of_id = ? AND status = ?", flag.PropertyID, flag.DuplicateOfID, models.DuplicateOpen).
+				Delete(&models.DuplicateFlag{}).Error; err != nil {
+				return err
+			}
+		}
+		if len(flags) == 0 {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "property_id"}, {Name: "duplicate_of_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"score", "reasons", "updated_at"}),
+			Where:     clause.Where{Exprs: []clause.Expression{clause.Eq{Column: clause.Column{Table: "duplicate_flags", Name: "status"}, Value: models.DuplicateOpen}}},
+		}).Create(&flags).Error
+	}); err != nil {
+		return nil, err
+	}
+
+	open := []models.DuplicateFlag{}
+	if err := db.Where("(property_id = ? OR duplicate_of_id = ?) AND status = ?", property.ID, property.ID, models.DuplicateOpen).
+		Order("score DESC, id").Find(&open).Error; err != nil {
+		return nil, err
+	}
+	return open, nil
+}
+
+// candidates loads the listings a property could duplicate, with their images
+func (d *Detector) candidates(db *gorm.DB, property models.Property, fp Fingerprint) ([]models.Property, error) {
+	conditions := db.Where("properties.owner_id = ?", property.OwnerID).
+		Or(`properties.id IN (SELECT property_id FROM duplicate_flags WHERE duplicate_of_id = ? AND status = ?
+			UNION SELECT duplicate_of_id FROM duplicate_flags WHERE property_id = ? AND status = ?)`,
+			property.ID, models.DuplicateOpen, property.ID, models.DuplicateOpen)
+	if property.PlaceID != nil {
+		conditions = conditions.Or("properties.place_id = ?", *property.PlaceID)
+	}
+	if fp.Point != nil {
+		dLat := nearbyKm / 111.0
+		dLng := dLat / math.Max(math.Cos(fp.Point.Lat*math.Pi/180), 0.01)
+		conditions = conditions.Or("properties.latitude BETWEEN ? AND ? AND properties.longitude BETWEEN ? AND ?",
+			fp.Point.Lat-dLat, fp.Point.Lat+dLat, fp.Point.Lng-dLng, fp.Point.Lng+dLng)
+	}
+	if len(fp.Photos) > 0 {
+		// Photos match within MaxHashDistance bits as in Compare, so re-encoded
+		// or cropped copies are found too
+		near := make([]string, len(fp.Photos))
+		args := make([]interface{}, 0, 2*len(fp.Photos))
+		for i, h := range fp.Photos {
+			near[i] = hashDistanceSQL + " <= ?"
+			args = append(args, int64(h), MaxHashDistance)
+		}
+		conditions = conditions.Or("properties.id IN (SELECT property_id FROM property_images WHERE perceptual_hash IS NOT NULL AND ("+
+			strings.Join(near, " OR ")+"))", args...)
+	}
+
+	var candidates []models.Property
+	err := db.Preload("Images").Where(conditions).
+		Where("properties.id <> ? AND properties.deleted_at IS NULL", property.ID).
+		Order("properties.id DESC").Limit(maxCandidates).Find(&candidates).Error
+	return candidates, err
+}
@@ -0,0 +1,221 @@
+package duplicates
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/bookaroo/bookaroo-platform-be/geo"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+)
+
+const (
+	// MaxHashDistance is how many bits two photo hashes may differ by and still be the same photo
+	MaxHashDistance = 6
+	// Properties this close are at the same spot; from farApartM on, coordinates don't count
+	sameSpotM   = 50.0
+	farApartM   = 500.0
+	nameWeight  = 0.35
+	placeWeight = 0.15
+	pointWeight = 0.25
+	photoWeight = 0.25
+)
+
+// stopWords are left out of names and locations as they tell nothing apart
+var stopWords = map[string]bool{"a": true, "an": true, "and": true, "at": true, "by": true, "in": true, "of": true, "the": true}
+
+// Fingerprint is what a listing is compared on to find duplicates
+type Fingerprint struct {
+	Name     []string   // Words of the name, lowercase, sorted and without stop words
+	Location []string   // Words of the location, likewise
+	Point    *geo.Point // Coordinates, when known
+	Photos   []uint64   // Perceptual hashes of the uploaded photos
+}
+
+// Of takes the fingerprint of a property, with its images loaded
+func Of(property models.Property) Fingerprint {
+	fp := Fingerprint{Name: Words(property.Name), Location: Words(property.Location)}
+	if property.Latitude != nil && property.Longitude != nil {
+		fp.Point = &geo.Point{Lat: *property.Latitude, Lng: *property.Longitude}
+	}
+	for _, img := range property.Images {
+		if img.PerceptualHash != nil {
+			fp.Photos = append(fp.Photos, uint64(*img.PerceptualHash))
+		}
+	}
+	return fp
+}
+
+// Words normalizes text for comparison: its distinct words, lowercase and
+// sorted, without punctuation or stop words, so "The Sunset Villa!" and
+// "villa sunset" are the same
+func Words(s string) []string {
+	seen := map[string]bool{}
+	var words []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if !stopWords[w] && !seen[w] {
+			seen[w] = true
+			words = append(words, w)
+		}
+	}
+	sort.Strings(words)
+	return words
+}
+
+// Match is how alike two fingerprints are
+type Match struct {
+	Score   float64  // From 0 to 1
+	Reasons []string // What the two have in common
+}
+
+// Compare scores two fingerprints by the weighted average of how alike their
+// names, locations, coordinates and photos are. Coordinates and photos only
+// count when both sides have them.
+func Compare(a, b Fingerprint) Match {
+	var m Match
+	total, weights := 0.0, 0.0
+	add := func(score, weight float64, reason string) {
+		total += score * weight
+		weights += weight
+		if reason != "" {
+			m.Reasons = append(m.Reasons, reason)
+		}
+	}
+
+	name := overlap(a.Name, b.Name)
+	switch {
+	case name == 1:
+		add(name, nameWeight, "same name")
+	case name >= 0.5:
+		add(name, nameWeight, "similar name")
+	default:
+		add(name, nameWeight, "")
+	}
+
+	location := overlap(a.Location, b.Location)
+	if location == 1 {
+		add(location, placeWeight, "same location")
+	} else {
+		add(location, placeWeight, "")
+	}
+
+	if a.Point != nil && b.Point != nil {
+		meters := geo.DistanceKm(*a.Point, *b.Point) * 1000
+		score := 1 - (meters-sameSpotM)/(farApartM-sameSpotM)
+		score = max(0, min(1, score))
+		if score > 0 {
+			add(score, pointWeight, fmt.Sprintf("%.0f m apart", meters))
+		} else {
+			add(0, pointWeight, "")
+		}
+	}
+
+	if len(a.Photos) > 0 && len(b.Photos) > 0 {
+		matching := 0
+		for _, ha := range a.Photos {
+			for _, hb := range b.Photos {
+				if bits.OnesCount64(ha^hb) <= MaxHashDistance {
+					matching++
+					break
+				}
+			}
+		}
+		score := min(1, float64(matching)/float64(min(len(a.Photos), len(b.Photos))))
+		switch matching {
+		case 0:
+			add(0, photoWeight, "")
+		case 1:
+			add(score, photoWeight, "1 matching photo")
+		default:
+			add(score, photoWeight, fmt.Sprintf("%d matching photos", matching))
+		}
+	}
+
+	m.Score = total / weights
+	return m
+}
+
+// overlap is the share of the words of either list that both have
+func overlap(a, b []string) float64 {
+	in := make(map[string]bool, len(a))
+	for _, w := range a {
+		in[w] = true
+	}
+	both, union := 0, len(in)
+	for _, w := range b {
+		if in[w] {
+			both++
+		} else {
+			union++
+		}
+	}
+	if union == 0 {
+		return 0
+	}
+	return float64(both) / float64(union)
+}
+
+// Cluster is a group of properties linked by duplicate flags
+type Cluster struct {
+	PropertyIDs []uint                 `json:"property_ids"` // Ascending
+	Flags       []models.DuplicateFlag `json:"flags"`
+	Score       float64                `json:"score"` // Of the likeliest pair
+}
+
+// Clusters groups flagged pairs into clusters of properties that are all,
+// directly or through one another, suspected duplicates. The likeliest
+// clusters come first.
+func Clusters(flags []models.DuplicateFlag) []Cluster {
+	parent := map[uint]uint{}
+	var find func(uint) uint
+	find = func(id uint) uint {
+		if p, ok := parent[id]; ok && p != id {
+			parent[id] = find(p)
+			return parent[id]
+		}
+		parent[id] = id
+		return id
+	}
+	for _, f := range flags {
+		a, b := find(f.PropertyID), find(f.DuplicateOfID)
+		if a != b {
+			parent[max(a, b)] = min(a, b)
+		}
+	}
+
+	byRoot := map[uint]*Cluster{}
+	var roots []uint
+	for _, f := range flags {
+		root := find(f.PropertyID)
+		cluster, ok := byRoot[root]
+		if !ok {
+			cluster = &Cluster{}
+			byRoot[root] = cluster
+			roots = append(roots, root)
+		}
+		cluster.Flags = append(cluster.Flags, f)
+		cluster.Score = max(cluster.Score, f.Score)
+	}
+	for id := range parent {
+		if cluster := byRoot[find(id)]; cluster != nil {
+			cluster.PropertyIDs = append(cluster.PropertyIDs, id)
+		}
+	}
+
+	clusters := make([]Cluster, len(roots))
+	for i, root := range roots {
+		clusters[i] = *byRoot[root]
+		sort.Slice(clusters[i].PropertyIDs, func(a, b int) bool { return clusters[i].PropertyIDs[a] < clusters[i].PropertyIDs[b] })
+	}
+	sort.SliceStable(clusters, func(i, j int) bool {
+		if clusters[i].Score != clusters[j].Score {
+			return clusters[i].Score > clusters[j].Score
+		}
+		return clusters[i].PropertyIDs[0] < clusters[j].PropertyIDs[0]
+	})
+	return clusters
+}
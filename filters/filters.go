@@ -0,0 +1,279 @@
+package filters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bookaroo/bookaroo-platform-be/amenities"
+	"github.com/bookaroo/bookaroo-platform-be/availability"
+	"github.com/bookaroo/bookaroo-platform-be/geo"
+	"github.com/bookaroo/bookaroo-platform-be/i18n"
+	"github.com/bookaroo/bookaroo-platform-be/ical"
+	"github.com/bookaroo/bookaroo-platform-be/locations"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/search"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultRadiusKm = 25.0
+	maxRadiusKm     = 500.0
+	maxStayNights   = 365
+	dateLayout      = "2006-01-02"
+)
+
+// Params are the query parameters of a property search that narrow down the
+// properties, as opposed to those sorting and paging them
+var Params = []string{
+	"q", "lang", "location", "city", "region", "place_id", "min_price", "max_price", "amenities", "property_type",
+	"space_type", "guests", "check_in", "check_out", "near", "lat", "lng", "radius_km", "bbox",
+}
+
+// InvalidError reports search parameters that can't be used, in words fit for the client
+type InvalidError struct {
+	msg string
+}
+
+func (e *InvalidError) Error() string {
+	return e.msg
+}
+
+func invalid(format string, args ...interface{}) error {
+	return &InvalidError{msg: fmt.Sprintf(format, args...)}
+}
+
+// Criteria are the filters of a property search
+type Criteria struct {
+	Text   string       // Free text, matched against names, locations and descriptions
+	Lang   string       // Language Text is written in
+	Match  search.Match // Matches for Text, when given
+	Center *geo.Point   // Point searched around, when given
+	Nights int          // Length of the stay, when dates were given
+	scopes []func(*gorm.DB) *gorm.DB
+}
+
+// Parse reads the criteria of a property search from its query parameters, as
+// run by guestID (0 for anonymous searches). Parameters that can't be used are
+// reported as an *InvalidError; other errors come from the search backend.
+func Parse(ctx context.Context, params url.Values, guestID uint, geocoder geo.Geocoder, backend search.Backend) (*Criteria, error) {
+	criteria := &Criteria{}
+	where := func(condition string, args ...interface{}) {
+		criteria.scopes = append(criteria.scopes, func(db *gorm.DB) *gorm.DB {
+			return db.Where(condition, args...)
+		})
+	}
+
+	// Free text is matched against names, locations and descriptions
+	criteria.Text, criteria.Lang = strings.TrimSpace(params.Get("q")), params.Get("lang")
+	if criteria.Lang == "" {
+		criteria.Lang = search.DefaultLanguage
+	}
+	lang, err := i18n.ParseLocale(criteria.Lang)
+	if err != nil {
+		return nil, invalid("lang must be a language tag such as en, id or th")
+	}
+	criteria.Lang = lang
+	if criteria.Text != "" {
+		if criteria.Match, err = backend.Match(ctx, criteria.Text, criteria.Lang); err != nil {
+			return nil, err
+		}
+		where(criteria.Match.Condition, criteria.Match.ConditionArgs...)
+	}
+
+	// Properties are searched by the public part of their address, never the street
+	if location := params.Get("location"); location != "" {
+		criteria.scopes = append(criteria.scopes, InCityOrRegion(location))
+	}
+	if city := strings.TrimSpace(params.Get("city")); city != "" {
+		where("LOWER(properties.city) = LOWER(?)", city)
+	}
+	if region := strings.TrimSpace(params.Get("region")); region != "" {
+		where("LOWER(properties.region) = LOWER(?)", region)
+	}
+	if placeID := params.Get("place_id"); placeID != "" {
+		id, err := strconv.ParseUint(placeID, 10, 64)
+		if err != nil {
+			return nil, invalid("place_id must be a place ID")
+		}
+		criteria.scopes = append(criteria.scopes, locations.Within(uint(id)))
+	}
+
+	if minPrice := params.Get("min_price"); minPrice != "" {
+		if price, err := strconv.ParseFloat(minPrice, 64); err == nil {
+			where("price >= ?", price)
+		}
+	}
+	if maxPrice := params.Get("max_price"); maxPrice != "" {
+		if price, err := strconv.ParseFloat(maxPrice, 64); err == nil {
+			where("price <= ?", price)
+		}
+	}
+
+	// Every requested amenity must be offered
+	if codes := amenities.ParseCodes(params.Get("amenities")); len(codes) > 0 {
+		where(`properties.id IN (
+			SELECT pa.property_id FROM property_amenities pa JOIN amenities a ON a.id = pa.amenity_id
+			WHERE a.code IN ? GROUP BY pa.property_id HAVING COUNT(DISTINCT a.id) = ?)`, codes, len(codes))
+	}
+
+	// Comma-separated lists match any of the values
+	if types := splitList(params.Get("property_type")); len(types) > 0 {
+		where("properties.property_type IN ?", types)
+	}
+	if spaces := splitList(params.Get("space_type")); len(spaces) > 0 {
+		where("properties.space_type IN ?", spaces)
+	}
+
+	if guests := params.Get("guests"); guests != "" {
+		n, err := strconv.Atoi(guests)
+		if err != nil || n < 1 {
+			return nil, invalid("guests must be a positive number")
+		}
+		where("(properties.max_guests = 0 OR properties.max_guests >= ?)", n)
+	}
+
+	// Properties booked, blocked or held for a waitlisted guest on any night of the stay are left out
+	if params.Get("check_in") != "" || params.Get("check_out") != "" {
+		checkIn, checkOut, err := parseStayDates(params.Get("check_in"), params.Get("check_out"))
+		if err != nil {
+			return nil, err
+		}
+		criteria.Nights = int(checkOut.Sub(checkIn).Hours() / 24)
+		criteria.scopes = append(criteria.scopes, availability.FreeBetween(checkIn, checkOut, guestID))
+	}
+
+	center, err := searchCenter(ctx, params, geocoder)
+	if err != nil {
+		return nil, err
+	}
+	criteria.Center = center
+	if center != nil {
+		radius := defaultRadiusKm
+		if r := params.Get("radius_km"); r != "" {
+			if radius, err = strconv.ParseFloat(r, 64); err != nil || radius <= 0 || radius > maxRadiusKm {
+				return nil, invalid("radius_km must be between 0 and %g", maxRadiusKm)
+			}
+		}
+		distance, args := DistanceSQL(*center)
+		where(distance+" <= ?", append(args, radius)...)
+	}
+
+	if bbox := params.Get("bbox"); bbox != "" {
+		box, err := geo.ParseBoundingBox(bbox)
+		if err != nil {
+			return nil, invalid("%s", err.Error())
+		}
+		// Matched on the public coordinates so shrinking the box can't find the exact point
+		where(models.PublicLatitudeSQL+" BETWEEN ? AND ?", box.South, box.North)
+		if box.West <= box.East {
+			where(models.PublicLongitudeSQL+" BETWEEN ? AND ?", box.West, box.East)
+		} else {
+			// The viewport crosses the antimeridian
+			where("("+models.PublicLongitudeSQL+" >= ? OR "+models.PublicLongitudeSQL+" <= ?)", box.West, box.East)
+		}
+	}
+
+	return criteria, nil
+}
+
+// Apply restricts a query on properties to those meeting the criteria. Whether
+// the properties are listed is left to the caller.
+func (c *Criteria) Apply(db *gorm.DB) *gorm.DB {
+	return db.Scopes(c.scopes...)
+}
+
+// InCityOrRegion scopes properties to those whose city or region contains
+// text. Properties without a structured address yet match on their location.
+func InCityOrRegion(text string) func(*gorm.DB) *gorm.DB {
+	pattern := "%" + text + "%"
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(`(properties.city ILIKE ? OR properties.region ILIKE ?
+			OR (COALESCE(properties.city, '') = '' AND properties.location ILIKE ?))`, pattern, pattern, pattern)
+	}
+}
+
+// Only keeps the search parameters among params, dropping sorting and paging
+func Only(params url.Values) url.Values {
+	kept := url.Values{}
+	for _, name := range Params {
+		if v := params.Get(name); v != "" {
+			kept.Set(name, v)
+		}
+	}
+	return kept
+}
+
+// DistanceSQL returns a haversine expression for the distance in kilometres from
+// center to a property, with its arguments. Properties without coordinates yield
+// NULL. Distances are measured to the public coordinates, which guests may see,
+// so that searches from several points can't pin down the exact ones.
+func DistanceSQL(center geo.Point) (string, []interface{}) {
+	lat, lng := models.PublicLatitudeSQL, models.PublicLongitudeSQL
+	return `(6371 * 2 * ASIN(LEAST(1, SQRT(
+		POWER(SIN(RADIANS(` + lat + ` - ?) / 2), 2) +
+		COS(RADIANS(?)) * COS(RADIANS(` + lat + `)) * POWER(SIN(RADIANS(` + lng + ` - ?) / 2), 2)))))`,
+		[]interface{}{center.Lat, center.Lat, center.Lng}
+}
+
+// parseStayDates reads a check-in and check-out date pair, rejecting stays in the past or of impossible length
+func parseStayDates(checkIn, checkOut string) (time.Time, time.Time, error) {
+	start, errIn := time.Parse(dateLayout, checkIn)
+	end, errOut := time.Parse(dateLayout, checkOut)
+	if errIn != nil || errOut != nil {
+		return time.Time{}, time.Time{}, invalid("check_in and check_out must both be dates in YYYY-MM-DD format")
+	}
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, invalid("check_out must be after check_in")
+	}
+	// A day of slack lets guests ahead of UTC still search from their today
+	if start.Before(ical.DateOnly(time.Now()).AddDate(0, 0, -1)) {
+		return time.Time{}, time.Time{}, invalid("check_in must not be in the past")
+	}
+	if end.Sub(start) > maxStayNights*24*time.Hour {
+		return time.Time{}, time.Time{}, invalid("stays are limited to %d nights", maxStayNights)
+	}
+	return start, end, nil
+}
+
+// searchCenter reads the point to search around from lat/lng or a place name in near
+func searchCenter(ctx context.Context, params url.Values, geocoder geo.Geocoder) (*geo.Point, error) {
+	if near := params.Get("near"); near != "" {
+		point, err := geocoder.Geocode(ctx, near)
+		if errors.Is(err, geo.ErrNotFound) {
+			return nil, invalid("unknown place %q", near)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("looking up %q: %w", near, err)
+		}
+		return &point, nil
+	}
+
+	lat, lng := params.Get("lat"), params.Get("lng")
+	if lat == "" && lng == "" {
+		return nil, nil
+	}
+	var point geo.Point
+	var errLat, errLng error
+	point.Lat, errLat = strconv.ParseFloat(lat, 64)
+	point.Lng, errLng = strconv.ParseFloat(lng, 64)
+	if errLat != nil || errLng != nil || !point.Valid() {
+		return nil, invalid("lat and lng must both be valid coordinates")
+	}
+	return &point, nil
+}
+
+// splitList splits a comma-separated parameter into its trimmed, lowercase values
+func splitList(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.ToLower(strings.TrimSpace(v)); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,44 @@
+package geo
+
+import "strings"
+
+// countryCodes maps the countries of the built-in places to their ISO 3166-1 alpha-2 codes
+var countryCodes = map[string]string{
+	"Australia":            "AU",
+	"France":               "FR",
+	"Indonesia":            "ID",
+	"Italy":                "IT",
+	"Japan":                "JP",
+	"Malaysia":             "MY",
+	"Mexico":               "MX",
+	"Portugal":             "PT",
+	"Singapore":            "SG",
+	"South Africa":         "ZA",
+	"Spain":                "ES",
+	"Thailand":             "TH",
+	"United Arab Emirates": "AE",
+	"United Kingdom":       "GB",
+	"United States":        "US",
+}
+
+// CountryCode returns the ISO 3166-1 alpha-2 code of a country the gazetteer
+// knows by name, or "" for other countries
+func CountryCode(name string) string {
+	for country, code := range countryCodes {
+		if NormalizeName(country) == NormalizeName(name) {
+			return code
+		}
+	}
+	return ""
+}
+
+// CountryName returns the name of the country with the given code, or "" when
+// the gazetteer doesn't know it
+func CountryName(code string) string {
+	for country, c := range countryCodes {
+		if strings.EqualFold(c, code) {
+			return country
+		}
+	}
+	return ""
+}
@@ -0,0 +1,127 @@
+package geo
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Place is a named location known to the gazetteer
+type Place struct {
+	Name    string `json:"name"`
+	Region  string `json:"region,omitempty"`
+	Country string `json:"country"`
+	Point
+}
+
+// Places is the built-in list of destinations. It covers the markets the
+// platform operates in and needs no network access.
+var Places = []Place{
+	{Name: "Bali", Country: "Indonesia", Point: Point{Lat: -8.4095, Lng: 115.1889}},
+	{Name: "Ubud", Region: "Bali", Country: "Indonesia", Point: Point{Lat: -8.5069, Lng: 115.2625}},
+	{Name: "Seminyak", Region: "Bali", Country: "Indonesia", Point: Point{Lat: -8.6913, Lng: 115.1682}},
+	{Name: "Canggu", Region: "Bali", Country: "Indonesia", Point: Point{Lat: -8.6478, Lng: 115.1385}},
+	{Name: "Kuta", Region: "Bali", Country: "Indonesia", Point: Point{Lat: -8.7184, Lng: 115.1686}},
+	{Name: "Uluwatu", Region: "Bali", Country: "Indonesia", Point: Point{Lat: -8.8291, Lng: 115.0849}},
+	{Name: "Sanur", Region: "Bali", Country: "Indonesia", Point: Point{Lat: -8.6878, Lng: 115.2620}},
+	{Name: "Nusa Dua", Region: "Bali", Country: "Indonesia", Point: Point{Lat: -8.8008, Lng: 115.2304}},
+	{Name: "Lombok", Country: "Indonesia", Point: Point{Lat: -8.6500, Lng: 116.3249}},
+	{Name: "Jakarta", Country: "Indonesia", Point: Point{Lat: -6.2088, Lng: 106.8456}},
+	{Name: "Bandung", Region: "West Java", Country: "Indonesia", Point: Point{Lat: -6.9175, Lng: 107.6191}},
+	{Name: "Yogyakarta", Country: "Indonesia", Point: Point{Lat: -7.7956, Lng: 110.3695}},
+	{Name: "Singapore", Country: "Singapore", Point: Point{Lat: 1.3521, Lng: 103.8198}},
+	{Name: "Kuala Lumpur", Country: "Malaysia", Point: Point{Lat: 3.1390, Lng: 101.6869}},
+	{Name: "Bangkok", Country: "Thailand", Point: Point{Lat: 13.7563, Lng: 100.5018}},
+	{Name: "Phuket", Country: "Thailand", Point: Point{Lat: 7.8804, Lng: 98.3923}},
+	{Name: "Chiang Mai", Country: "Thailand", Point: Point{Lat: 18.7883, Lng: 98.9853}},
+	{Name: "Tokyo", Country: "Japan", Point: Point{Lat: 35.6762, Lng: 139.6503}},
+	{Name: "Kyoto", Country: "Japan", Point: Point{Lat: 35.0116, Lng: 135.7681}},
+	{Name: "Sydney", Region: "New South Wales", Country: "Australia", Point: Point{Lat: -33.8688, Lng: 151.2093}},
+	{Name: "Melbourne", Region: "Victoria", Country: "Australia", Point: Point{Lat: -37.8136, Lng: 144.9631}},
+	{Name: "Paris", Country: "France", Point: Point{Lat: 48.8566, Lng: 2.3522}},
+	{Name: "Nice", Region: "Provence-Alpes-Côte d'Azur", Country: "France", Point: Point{Lat: 43.7102, Lng: 7.2620}},
+	{Name: "London", Country: "United Kingdom", Point: Point{Lat: 51.5074, Lng: -0.1278}},
+	{Name: "Barcelona", Region: "Catalonia", Country: "Spain", Point: Point{Lat: 41.3874, Lng: 2.1686}},
+	{Name: "Lisbon", Country: "Portugal", Point: Point{Lat: 38.7223, Lng: -9.1393}},
+	{Name: "Rome", Country: "Italy", Point: Point{Lat: 41.9028, Lng: 12.4964}},
+	{Name: "New York", Region: "New York", Country: "United States", Point: Point{Lat: 40.7128, Lng: -74.0060}},
+	{Name: "Miami", Region: "Florida", Country: "United States", Point: Point{Lat: 25.7617, Lng: -80.1918}},
+	{Name: "Denver", Region: "Colorado", Country: "United States", Point: Point{Lat: 39.7392, Lng: -104.9903}},
+	{Name: "Los Angeles", Region: "California", Country: "United States", Point: Point{Lat: 34.0522, Lng: -118.2437}},
+	{Name: "San Francisco", Region: "California", Country: "United States", Point: Point{Lat: 37.7749, Lng: -122.4194}},
+	{Name: "Honolulu", Region: "Hawaii", Country: "United States", Point: Point{Lat: 21.3069, Lng: -157.8583}},
+	{Name: "Cancun", Region: "Quintana Roo", Country: "Mexico", Point: Point{Lat: 21.1619, Lng: -86.8515}},
+	{Name: "Cape Town", Country: "South Africa", Point: Point{Lat: -33.9249, Lng: 18.4241}},
+	{Name: "Dubai", Country: "United Arab Emirates", Point: Point{Lat: 25.2048, Lng: 55.2708}},
+}
+
+// Gazetteer geocodes against a fixed list of places. A query matches a place
+// when any comma-separated part of it names the place, most specific part first,
+// so "Villa Sunset, Ubud, Bali" resolves to Ubud.
+type Gazetteer struct {
+	Places []Place
+	byName map[string]Place
+}
+
+func NewGazetteer() *Gazetteer {
+	g := &Gazetteer{Places: Places, byName: make(map[string]Place, len(Places))}
+	for _, p := range Places {
+		g.byName[NormalizeName(p.Name)] = p
+	}
+	return g
+}
+
+func (g *Gazetteer) Geocode(ctx context.Context, query string) (Point, error) {
+	if p, _, ok := g.Lookup(query); ok {
+		return p.Point, nil
+	}
+	return Point{}, ErrNotFound
+}
+
+// Lookup finds the place named in query the way Geocode does, and the index of
+// the comma-separated part that named it
+func (g *Gazetteer) Lookup(query string) (Place, int, bool) {
+	for i, part := range strings.Split(query, ",") {
+		if p, ok := g.byName[NormalizeName(part)]; ok {
+			return p, i, true
+		}
+	}
+	return Place{}, -1, false
+}
+
+// IsRegion reports whether other places lie within the named one, such as Bali
+func (g *Gazetteer) IsRegion(name string) bool {
+	for _, p := range g.Places {
+		if NormalizeName(p.Region) == NormalizeName(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsCountry reports whether name is the country of a known place
+func (g *Gazetteer) IsCountry(name string) bool {
+	for _, p := range g.Places {
+		if NormalizeName(p.Country) == NormalizeName(name) {
+			return true
+		}
+	}
+	return false
+}
+
+var stripMarks = transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+// NormalizeName folds case, accents and punctuation, so "Côte d'Azur" and
+// "cote d azur" compare equal
+func NormalizeName(s string) string {
+	if folded, _, err := transform.String(stripMarks, s); err == nil {
+		s = folded
+	}
+	return strings.Join(strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
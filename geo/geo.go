@@ -0,0 +1,90 @@
+package geo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const earthRadiusKm = 6371.0
+
+var ErrNotFound = errors.New("location not found")
+
+// Point is a WGS84 coordinate
+type Point struct {
+	Lat float64 `json:"latitude"`
+	Lng float64 `json:"longitude"`
+}
+
+// Valid reports whether the point lies within the latitude and longitude ranges
+func (p Point) Valid() bool {
+	return p.Lat >= -90 && p.Lat <= 90 && p.Lng >= -180 && p.Lng <= 180
+}
+
+// DistanceKm returns the great-circle distance between two points
+func DistanceKm(a, b Point) float64 {
+	dLat := (b.Lat - a.Lat) * math.Pi / 180
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+	h := math.Pow(math.Sin(dLat/2), 2) +
+		math.Cos(a.Lat*math.Pi/180)*math.Cos(b.Lat*math.Pi/180)*math.Pow(math.Sin(dLng/2), 2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
+
+// BoundingBox is a map viewport. West may be greater than East when the box crosses the antimeridian.
+type BoundingBox struct {
+	West, South, East, North float64
+}
+
+// ParseBoundingBox reads "west,south,east,north" (min_lng,min_lat,max_lng,max_lat)
+func ParseBoundingBox(s string) (BoundingBox, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return BoundingBox{}, errors.New("bbox must be west,south,east,north")
+	}
+	var v [4]float64
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return BoundingBox{}, fmt.Errorf("bbox: invalid number %q", part)
+		}
+		v[i] = f
+	}
+	box := BoundingBox{West: v[0], South: v[1], East: v[2], North: v[3]}
+	if !(Point{Lat: box.South, Lng: box.West}).Valid() || !(Point{Lat: box.North, Lng: box.East}).Valid() || box.South > box.North {
+		return BoundingBox{}, errors.New("bbox is out of range")
+	}
+	return box, nil
+}
+
+// Contains reports whether p lies inside the box
+func (b BoundingBox) Contains(p Point) bool {
+	if p.Lat < b.South || p.Lat > b.North {
+		return false
+	}
+	if b.West <= b.East {
+		return p.Lng >= b.West && p.Lng <= b.East
+	}
+	return p.Lng >= b.West || p.Lng <= b.East
+}
+
+// Geocoder turns a free-form place description into coordinates
+type Geocoder interface {
+	Geocode(ctx context.Context, query string) (Point, error)
+}
+
+// NewFromEnv builds the geocoder selected by GEOCODER: "gazetteer" (default,
+// offline) or "nominatim" (OpenStreetMap, configured with NOMINATIM_URL)
+func NewFromEnv() (Geocoder, error) {
+	switch name := os.Getenv("GEOCODER"); name {
+	case "", "gazetteer":
+		return NewGazetteer(), nil
+	case "nominatim":
+		return NewNominatim(os.Getenv("NOMINATIM_URL")), nil
+	default:
+		return nil, fmt.Errorf("unknown geocoder %q", name)
+	}
+}
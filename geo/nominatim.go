@@ -0,0 +1,182 @@
+package geo
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultNominatimURL = "https://nominatim.openstreetmap.org"
+	// Nominatim's usage policy allows one request per second
+	nominatimInterval = time.Second
+	// A lookup waiting longer than this for its turn fails instead
+	nominatimMaxWait   = 5 * time.Second
+	nominatimCacheSize = 10000
+	nominatimCacheTTL  = 24 * time.Hour
+)
+
+// ErrUnavailable means the geocoder couldn't be reached or is too busy to answer now
+var ErrUnavailable = errors.New("geocoder unavailable")
+
+// Nominatim geocodes with an OpenStreetMap Nominatim server. Answers, places
+// not found included, are cached, and requests are spaced Interval apart, so
+// searches can't make it exceed the server's usage policy.
+type Nominatim struct {
+	BaseURL  string
+	Client   *http.Client
+	Interval time.Duration // Between two requests to the server
+	MaxWait  time.Duration // Longest a lookup waits for its turn
+
+	mu      sync.Mutex
+	next    time.Time // When the next request may be sent
+	cache   map[string]*list.Element
+	recency *list.List // Cached answers, most recently used first
+}
+
+// nominatimAnswer is a cached lookup: a point, or ErrNotFound
+type nominatimAnswer struct {
+	query   string
+	point   Point
+	err     error
+	expires time.Time
+}
+
+func NewNominatim(baseURL string) *Nominatim {
+	if baseURL == "" {
+		baseURL = defaultNominatimURL
+	}
+	return &Nominatim{
+		BaseURL:  strings.TrimRight(baseURL, "/"),
+		Client:   &http.Client{Timeout: 10 * time.Second},
+		Interval: nominatimInterval,
+		MaxWait:  nominatimMaxWait,
+		cache:    map[string]*list.Element{},
+		recency:  list.New(),
+	}
+}
+
+// Geocode looks the query up, from the cache when it was asked recently.
+// Failures of the server are reported as ErrUnavailable.
+func (n *Nominatim) Geocode(ctx context.Context, query string) (Point, error) {
+	key := strings.ToLower(strings.Join(strings.Fields(query), " "))
+	if answer, ok := n.cached(key); ok {
+		return answer.point, answer.err
+	}
+
+	if err := n.wait(ctx); err != nil {
+		return Point{}, err
+	}
+	point, err := n.lookup(ctx, query)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return Point{}, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	n.store(key, point, err)
+	return point, err
+}
+
+func (n *Nominatim) lookup(ctx context.Context, query string) (Point, error) {
+	endpoint := n.BaseURL + "/search?" + url.Values{"q": {query}, "format": {"json"}, "limit": {"1"}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return Point{}, err
+	}
+	// Nominatim's usage policy requires an identifying user agent
+	req.Header.Set("User-Agent", "bookaroo-platform-be")
+
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return Point{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Point{}, fmt.Errorf("geocoder responded with %s", resp.Status)
+	}
+
+	var results []struct {
+		Lat string `json:"lat"`
+		Lon string `json:"lon"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		return Point{}, err
+	}
+	if len(results) == 0 {
+		return Point{}, ErrNotFound
+	}
+
+	lat, errLat := strconv.ParseFloat(results[0].Lat, 64)
+	lng, errLng := strconv.ParseFloat(results[0].Lon, 64)
+	if errLat != nil || errLng != nil {
+		return Point{}, fmt.Errorf("geocoder returned invalid coordinates %q,%q", results[0].Lat, results[0].Lon)
+	}
+	return Point{Lat: lat, Lng: lng}, nil
+}
+
+// wait takes the next free turn to send a request and sleeps until it comes.
+// Lookups that would wait longer than MaxWait fail right away.
+func (n *Nominatim) wait(ctx context.Context) error {
+	n.mu.Lock()
+	now := time.Now()
+	turn := n.next
+	if turn.Before(now) {
+		turn = now
+	}
+	if turn.Sub(now) > n.MaxWait {
+		n.mu.Unlock()
+		return fmt.Errorf("%w: too many lookups", ErrUnavailable)
+	}
+	n.next = turn.Add(n.Interval)
+	n.mu.Unlock()
+
+	timer := time.NewTimer(turn.Sub(now))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (n *Nominatim) cached(key string) (*nominatimAnswer, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	elem, ok := n.cache[key]
+	if !ok {
+		return nil, false
+	}
+	answer := elem.Value.(*nominatimAnswer)
+	if time.Now().After(answer.expires) {
+		n.recency.Remove(elem)
+		delete(n.cache, key)
+		return nil, false
+	}
+	n.recency.MoveToFront(elem)
+	return answer, true
+}
+
+// store caches an answer, dropping the least recently used once the cache is full
+func (n *Nominatim) store(key string, point Point, err error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	answer := &nominatimAnswer{query: key, point: point, err: err, expires: time.Now().Add(nominatimCacheTTL)}
+	if elem, ok := n.cache[key]; ok {
+		elem.Value = answer
+		n.recency.MoveToFront(elem)
+		return
+	}
+	n.cache[key] = n.recency.PushFront(answer)
+	if n.recency.Len() > nominatimCacheSize {
+		oldest := n.recency.Back()
+		n.recency.Remove(oldest)
+		delete(n.cache, oldest.Value.(*nominatimAnswer).query)
+	}
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/bookaroo/bookaroo-platform-be/amenities"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type AmenityHandler struct {
+	DB *gorm.DB
+}
+
+func NewAmenityHandler(db *gorm.DB) *AmenityHandler {
+	return &AmenityHandler{DB: db}
+}
+
+type CreateAmenityRequest struct {
+	Code     string `json:"code" binding:"required"`
+	Name     string `json:"name" binding:"required"`
+	Category string `json:"category" binding:"required"`
+	IconKey  string `json:"icon_key"`
+}
+
+// ListAmenities returns the amenities catalog
+// @Summary List amenities
+// @Description Retrieve the amenities catalog, optionally limited to one category
+// @Tags amenities
+// @Produce json
+// @Param category query string false "Category"
+// @Success 200 {array} models.Amenity
+// @Router /amenities [get]
+func (h *AmenityHandler) ListAmenities(c *gin.Context) {
+	query := h.DB.Order("category, name")
+	if category := c.Query("category"); category != "" {
+		query = query.Where("category = ?", category)
+	}
+
+	var catalog []models.Amenity
+	if err := query.Find(&catalog).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching amenities"})
+		return
+	}
+
+	c.JSON(http.StatusOK, catalog)
+}
+
+// CreateAmenity adds an entry to the amenities catalog
+// @Summary Create an amenity
+// @Description Add an amenity to the catalog (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param amenity body CreateAmenityRequest true "Amenity details"
+// @Success 201 {object} models.Amenity
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/amenities [post]
+func (h *AmenityHandler) CreateAmenity(c *gin.Context) {
+	var req CreateAmenityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	amenity := models.Amenity{
+		Code:     amenities.Normalize(req.Code),
+		Name:     req.Name,
+		Category: req.Category,
+		IconKey:  req.IconKey,
+	}
+	if amenity.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid amenity code"})
+		return
+	}
+
+	var existing int64
+	h.DB.Model(&models.Amenity{}).Where("code = ?", amenity.Code).Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "An amenity with this code already exists"})
+		return
+	}
+
+	if err := h.DB.Create(&amenity).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create amenity"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, amenity)
+}
@@ -1,26 +1,61 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"time"
 
+	"github.com/bookaroo/bookaroo-platform-be/availability"
+	"github.com/bookaroo/bookaroo-platform-be/billing"
 	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/notifications"
+	"github.com/bookaroo/bookaroo-platform-be/pagination"
+	"github.com/bookaroo/bookaroo-platform-be/waitlist"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errNoMonthlyStays  = errors.New("property does not offer monthly stays")
+	errHeldForWaitlist = errors.New("dates are held for a waitlisted guest")
 )
 
 type BookingHandler struct {
-	DB *gorm.DB
+	DB       *gorm.DB
+	Waitlist *waitlist.Manager
+	Notifier notifications.Notifier
 }
 
 func NewBookingHandler(db *gorm.DB) *BookingHandler {
-	return &BookingHandler{DB: db}
+	return &BookingHandler{
+		DB:       db,
+		Waitlist: waitlist.NewManager(db, notifications.NewDBNotifier(db)),
+		Notifier: notifications.NewDBNotifier(db),
+	}
 }
 
 type CreateBookingRequest struct {
 	PropertyID uint      `json:"property_id" binding:"required"`
 	StartDate  time.Time `json:"start_date" binding:"required"`
-	EndDate    time.Time `json:"end_date" binding:"required"`
+	EndDate    time.Time `json:"end_date"`  // Required for nightly stays
+	StayType   string    `json:"stay_type"` // nightly (default) or monthly
+	Months     int       `json:"months"`    // Length of a monthly stay, 1 to 6
+}
+
+type TerminateStayRequest struct {
+	MoveOutDate *time.Time `json:"move_out_date"` // Defaults to the end of the notice period
+}
+
+type InstallmentsResponse struct {
+	BookingID    uint                 `json:"booking_id"`
+	MonthlyPrice float64              `json:"monthly_price"`
+	TotalPrice   float64              `json:"total_price"`
+	RefundDue    float64              `json:"refund_due"` // Paid for nights after an early termination
+	Upcoming     []models.Installment `json:"upcoming"`
+	Past         []models.Installment `json:"past"`
 }
 
 type GuestBookingResponse struct {
@@ -41,9 +76,18 @@ type PropertyDetails struct {
 	Amenities   string  `json:"amenities"`
 }
 
+// BookingDetailResponse is a booking as seen by its guest or the property owner
+type BookingDetailResponse struct {
+	models.Booking
+	CheckInInstructions string `json:"check_in_instructions,omitempty"` // Only once the booking is confirmed
+	// Full address of the property, including its street and postal code; only once the booking is confirmed
+	Address *models.Address `json:"address,omitempty"`
+}
+
 type GuestBookingsResponse struct {
 	Bookings   []GuestBookingResponse `json:"bookings"`
 	Statistics GuestBookingStats      `json:"statistics"`
+	Meta       pagination.Meta        `json:"meta"`
 }
 
 type GuestBookingStats struct {
@@ -64,7 +108,7 @@ type GuestBookingStats struct {
 // @Failure 401 {object} models.ErrorResponse
 // @Router /bookings [post]
 func (h *BookingHandler) CreateBooking(c *gin.Context) {
-	userID, _ := c.Get("user_id") // Get user ID from context
+	guestID := currentUserID(c)
 
 	var req CreateBookingRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -72,71 +116,123 @@ func (h *BookingHandler) CreateBooking(c *gin.Context) {
 		return
 	}
 
-	// Check if property exists
-	var property models.Property
-	if err := h.DB.First(&property, req.PropertyID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Property not found"})
+	switch req.StayType {
+	case "", "nightly":
+		req.StayType = "nightly"
+	case "monthly":
+		// Monthly stays run for whole months from the check-in date
+		if req.Months < billing.MinMonths || req.Months > billing.MaxMonths {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "months must be between 1 and 6 for monthly stays"})
+			return
+		}
+		req.EndDate = req.StartDate.AddDate(0, req.Months, 0)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "stay_type must be nightly or monthly"})
 		return
 	}
 
-	// Check if dates are available
-	var conflictingBookings int64
-	h.DB.Model(&models.Booking{}).
-		Where("property_id = ? AND status != 'cancelled' AND "+
-			"((start_date BETWEEN ? AND ?) OR (end_date BETWEEN ? AND ?))",
-			req.PropertyID, req.StartDate, req.EndDate, req.StartDate, req.EndDate).
-		Count(&conflictingBookings)
-
-	if conflictingBookings > 0 {
-		c.JSON(http.StatusConflict, gin.H{"error": "Property is not available for these dates"})
+	if !req.EndDate.After(req.StartDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must be after start_date"})
 		return
 	}
 
-	// Calculate total price
-	days := req.EndDate.Sub(req.StartDate).Hours() / 24
-	totalPrice := property.Price * float64(days)
+	var booking models.Booking
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the property so concurrent bookings and its deletion wait for this one
+		var property models.Property
+		if err := listedProperties(tx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&property, req.PropertyID).Error; err != nil {
+			return err
+		}
 
-	booking := models.Booking{
-		PropertyID: req.PropertyID,
-		UserID:     userID.(uint),
-		StartDate:  req.StartDate,
-		EndDate:    req.EndDate,
-		TotalPrice: totalPrice,
-		Status:     "pending",
-	}
+		if req.StayType == "monthly" && property.MonthlyPrice <= 0 {
+			return errNoMonthlyStays
+		}
+
+		// Check if dates are available
+		conflict, err := availability.HasConflict(tx, req.PropertyID, req.StartDate, req.EndDate, 0)
+		if err != nil {
+			return err
+		}
+		if conflict {
+			return errUnavailable
+		}
+
+		// Dates freed by a cancellation are held for the waitlisted guest during their priority window
+		held, err := waitlist.IsHeld(tx, req.PropertyID, req.StartDate, req.EndDate, guestID)
+		if err != nil {
+			return err
+		}
+		if held {
+			return errHeldForWaitlist
+		}
+
+		// Calculate total price
+		days := req.EndDate.Sub(req.StartDate).Hours() / 24
+		totalPrice := property.Price * float64(days)
 
-	if err := h.DB.Create(&booking).Error; err != nil {
+		booking = models.Booking{
+			PropertyID: req.PropertyID,
+			UserID:     &guestID,
+			StartDate:  req.StartDate,
+			EndDate:    req.EndDate,
+			TotalPrice: totalPrice,
+			Status:     "pending",
+			StayType:   req.StayType,
+		}
+
+		// Monthly stays are charged month by month rather than up front
+		if req.StayType == "monthly" {
+			booking.Months = req.Months
+			booking.TotalPrice = property.MonthlyPrice * float64(req.Months)
+			booking.Installments = billing.Schedule(req.StartDate, req.Months, property.MonthlyPrice)
+		}
+
+		return tx.Create(&booking).Error
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Property not found"})
+		return
+	case errors.Is(err, errNoMonthlyStays):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Property does not offer monthly stays"})
+		return
+	case errors.Is(err, errUnavailable):
+		c.JSON(http.StatusConflict, gin.H{"error": "Property is not available for these dates", "waitlist": "/api/waitlist"})
+		return
+	case errors.Is(err, errHeldForWaitlist):
+		c.JSON(http.StatusConflict, gin.H{"error": "These dates are held for a waitlisted guest", "waitlist": "/api/waitlist"})
+		return
+	case err != nil:
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create booking"})
 		return
 	}
 
+	// Close the guest's waitlist offer for these dates, if they had one
+	if err := waitlist.MarkBooked(h.DB, booking.PropertyID, guestID, booking.StartDate, booking.EndDate); err != nil {
+		log.Printf("Failed to close the waitlist offer of booking %d: %v", booking.ID, err)
+	}
+
 	c.JSON(http.StatusCreated, gin.H{"message": "Booking created successfully"})
 }
 
-// GetGuestBookings returns a list of bookings for a specific guest
+// GetGuestBookings returns a page of the authenticated guest's bookings, newest first
 // @Summary Get bookings for a guest
-// @Description Retrieve a list of bookings for the authenticated guest
+// @Description Retrieve a page of bookings for the authenticated guest, newest first, with statistics over all of them. Pass meta.next_cursor back as cursor to get the next page.
 // @Tags bookings
 // @Accept json
 // @Produce json
+// @Param limit query int false "Bookings per page (default 20, max 100)"
+// @Param cursor query string false "next_cursor from the previous page"
 // @Success 200 {object} GuestBookingsResponse
+// @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
 // @Router /bookings [get]
 func (h *BookingHandler) GetGuestBookings(c *gin.Context) {
-	// Get guest ID from URL
-	guestID := c.Param("guest_id")
-
-	// Check if guest exists
-	var guest models.User
-	if err := h.DB.First(&guest, guestID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Guest not found"})
-		return
-	}
+	guestID := currentUserID(c)
 
-	// Get all bookings for this guest
-	var bookings []models.Booking
-	if err := h.DB.Preload("Property").Where("user_id = ?", guestID).Find(&bookings).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bookings"})
+	page, err := pagination.Parse(c.Query("limit"), c.Query("cursor"), "")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -146,12 +242,31 @@ func (h *BookingHandler) GetGuestBookings(c *gin.Context) {
 		Statistics: GuestBookingStats{},
 	}
 
-	now := time.Now()
+	// Statistics cover every booking, not just this page
+	if err := h.DB.Model(&models.Booking{}).
+		Select(`COUNT(*) AS total_bookings, COALESCE(SUM(total_price), 0) AS total_spent,
+			COUNT(*) FILTER (WHERE start_date > ? AND status IN ('confirmed', 'pending')) AS upcoming_bookings`, time.Now()).
+		Where("user_id = ?", guestID).
+		Scan(&response.Statistics).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bookings"})
+		return
+	}
+
+	var bookings []models.Booking
+	if err := keysetPage(h.DB.Preload("Property").Where("user_id = ?", guestID), "", nil, "bookings.id", true, page).
+		Find(&bookings).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bookings"})
+		return
+	}
+	bookings, response.Meta = pagination.Trim(bookings, page, int64(response.Statistics.TotalBookings), func(b models.Booking) pagination.Cursor {
+		return pagination.Cursor{ID: b.ID}
+	})
 
-	// Process bookings
 	for _, booking := range bookings {
-		// Add to bookings list
-		bookingResponse := GuestBookingResponse{
+		if booking.Status != "confirmed" {
+			booking.Property.Conceal()
+		}
+		response.Bookings = append(response.Bookings, GuestBookingResponse{
 			ID: booking.ID,
 			Property: PropertyDetails{
 				ID:          booking.Property.ID,
@@ -165,18 +280,377 @@ func (h *BookingHandler) GetGuestBookings(c *gin.Context) {
 			EndDate:    booking.EndDate,
 			Status:     booking.Status,
 			TotalPrice: booking.TotalPrice,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetGuestBookingsByID is the older form of GetGuestBookings addressing the guest by ID
+// @Summary Get bookings for a guest by ID
+// @Description Deprecated: use GET /bookings. Same as GET /bookings for the authenticated guest, whose ID must be guest_id.
+// @Tags bookings
+// @Produce json
+// @Param guest_id path int true "Guest ID"
+// @Param limit query int false "Bookings per page (default 20, max 100)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Success 200 {object} GuestBookingsResponse
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /bookings/guest/{guest_id} [get]
+// @Deprecated
+func (h *BookingHandler) GetGuestBookingsByID(c *gin.Context) {
+	c.Header("Deprecation", "true")
+	c.Header("Link", `</api/bookings>; rel="successor-version"`)
+
+	var guest models.User
+	if err := h.DB.First(&guest, c.Param("guest_id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Guest not found"})
+		return
+	}
+	if guest.ID != currentUserID(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only list your own bookings"})
+		return
+	}
+
+	h.GetGuestBookings(c)
+}
+
+// CancelBooking cancels a booking and offers the freed dates to the waitlist
+// @Summary Cancel a booking
+// @Description Cancel a booking as its guest or as the property owner
+// @Tags bookings
+// @Produce json
+// @Param id path int true "Booking ID"
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /bookings/{id}/cancel [post]
+func (h *BookingHandler) CancelBooking(c *gin.Context) {
+	userID := currentUserID(c)
+
+	var booking models.Booking
+	if err := h.DB.Preload("Property").First(&booking, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Booking not found"})
+		return
+	}
+
+	if !booking.IsGuest(userID) && booking.Property.OwnerID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to cancel this booking"})
+		return
+	}
+
+	if booking.Status == "cancelled" || booking.Status == "completed" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Booking can no longer be cancelled"})
+		return
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&booking).Update("status", "cancelled").Error; err != nil {
+			return err
+		}
+		// Unpaid installments of a cancelled monthly stay are no longer owed
+		return tx.Model(&models.Installment{}).
+			Where("booking_id = ? AND status IN ('scheduled', 'due')", booking.ID).
+			Update("status", "void").Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel booking"})
+		return
+	}
+
+	if err := h.Waitlist.OfferFreedDates(c.Request.Context(), booking.PropertyID, booking.StartDate, booking.EndDate); err != nil {
+		log.Printf("Failed to offer freed dates of booking %d to the waitlist: %v", booking.ID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Booking cancelled successfully"})
+}
+
+// ConfirmBooking accepts a pending booking as the property owner
+// @Summary Confirm a booking
+// @Description Accept a pending booking of one of your properties. The guest is notified and can then see the check-in instructions and full address in the booking details (property owner only).
+// @Tags bookings
+// @Produce json
+// @Param id path int true "Booking ID"
+// @Success 200 {object} models.Booking
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /bookings/{id}/confirm [post]
+func (h *BookingHandler) ConfirmBooking(c *gin.Context) {
+	var booking models.Booking
+	if err := h.DB.Preload("Property").First(&booking, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Booking not found"})
+		return
+	}
+
+	if booking.Property.OwnerID != currentUserID(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the property owner can confirm this booking"})
+		return
+	}
+
+	// Only pending bookings can be confirmed, even when a cancellation races the owner
+	result := h.DB.Model(&models.Booking{}).
+		Where("id = ? AND status = ?", booking.ID, "pending").
+		Update("status", "confirmed")
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to confirm booking"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only pending bookings can be confirmed"})
+		return
+	}
+	booking.Status = "confirmed"
+
+	// Partner guests have no account to notify
+	if booking.UserID != nil {
+		if err := h.Notifier.Notify(c.Request.Context(), &models.Notification{
+			UserID:  *booking.UserID,
+			Type:    "booking_confirmed",
+			Title:   "Your booking is confirmed",
+			Message: fmt.Sprintf("Your stay at %s from %s is confirmed. Check-in details are in your booking.", booking.Property.Name, booking.StartDate.Format("2 Jan 2006")),
+			Link:    fmt.Sprintf("/api/bookings/%d", booking.ID),
+		}); err != nil {
+			log.Printf("Failed to notify guest of booking %d: %v", booking.ID, err)
 		}
-		response.Bookings = append(response.Bookings, bookingResponse)
+	}
+
+	c.JSON(http.StatusOK, booking)
+}
+
+// findBookingForParticipant loads the booking from the :id path parameter and
+// verifies the authenticated user is its guest or the property owner
+func (h *BookingHandler) findBookingForParticipant(c *gin.Context) (*models.Booking, bool) {
+	userID := currentUserID(c)
 
-		// Update statistics
-		response.Statistics.TotalBookings++
-		response.Statistics.TotalSpent += booking.TotalPrice
+	var booking models.Booking
+	if err := h.DB.Preload("Property").First(&booking, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Booking not found"})
+		return nil, false
+	}
+
+	if !booking.IsGuest(userID) && booking.Property.OwnerID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to view this booking"})
+		return nil, false
+	}
+
+	return &booking, true
+}
+
+// GetBooking returns a booking with its property and house rules
+// @Summary Get a booking
+// @Description Retrieve a booking as its guest or the property owner, with the property and its house rules. Check-in instructions and the full address of the property are included once the booking is confirmed.
+// @Tags bookings
+// @Produce json
+// @Param id path int true "Booking ID"
+// @Success 200 {object} BookingDetailResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /bookings/{id} [get]
+func (h *BookingHandler) GetBooking(c *gin.Context) {
+	booking, ok := h.findBookingForParticipant(c)
+	if !ok {
+		return
+	}
+
+	response := BookingDetailResponse{Booking: *booking}
+	if booking.Status == "confirmed" {
+		response.CheckInInstructions = booking.Property.CheckInInstructions
+		address := booking.Property.Address()
+		response.Address = &address
+	} else if booking.Property.OwnerID != currentUserID(c) {
+		response.Property.Conceal()
+	}
 
-		// Count upcoming bookings
-		if booking.StartDate.After(now) && (booking.Status == "confirmed" || booking.Status == "pending") {
-			response.Statistics.UpcomingBookings++
+	c.JSON(http.StatusOK, response)
+}
+
+// GetInstallments returns the monthly installments of a long-term stay
+// @Summary Get booking installments
+// @Description Retrieve the upcoming and past monthly installments of a monthly stay
+// @Tags bookings
+// @Produce json
+// @Param id path int true "Booking ID"
+// @Success 200 {object} InstallmentsResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /bookings/{id}/installments [get]
+func (h *BookingHandler) GetInstallments(c *gin.Context) {
+	booking, ok := h.findBookingForParticipant(c)
+	if !ok {
+		return
+	}
+
+	var installments []models.Installment
+	if err := h.DB.Where("booking_id = ?", booking.ID).Order("sequence").Find(&installments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch installments"})
+		return
+	}
+
+	response := InstallmentsResponse{
+		BookingID:    booking.ID,
+		MonthlyPrice: booking.Property.MonthlyPrice,
+		TotalPrice:   booking.TotalPrice,
+		RefundDue:    booking.RefundDue,
+		Upcoming:     make([]models.Installment, 0),
+		Past:         make([]models.Installment, 0),
+	}
+
+	now := time.Now()
+	for _, installment := range installments {
+		if installment.DueDate.After(now) {
+			response.Upcoming = append(response.Upcoming, installment)
+		} else {
+			response.Past = append(response.Past, installment)
 		}
 	}
 
 	c.JSON(http.StatusOK, response)
 }
+
+// TerminateStay ends a monthly stay early, respecting the property's notice period
+// @Summary Terminate a monthly stay early
+// @Description Give notice on a monthly stay, as its guest. The stay ends on the requested move-out date or at the end of the notice period, whichever is later; later unpaid installments are voided and the last month is prorated. Paid installments are kept and what was paid for nights after the new end is returned as refund_due. Stays that wouldn't have started by then must be cancelled instead.
+// @Tags bookings
+// @Accept json
+// @Produce json
+// @Param id path int true "Booking ID"
+// @Param termination body TerminateStayRequest false "Termination details"
+// @Success 200 {object} InstallmentsResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /bookings/{id}/terminate [post]
+func (h *BookingHandler) TerminateStay(c *gin.Context) {
+	booking, ok := h.findBookingForParticipant(c)
+	if !ok {
+		return
+	}
+	// The notice period protects the owner, so only the guest gives notice
+	if !booking.IsGuest(currentUserID(c)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the guest can give notice on this stay"})
+		return
+	}
+
+	var req TerminateStayRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if booking.StayType != "monthly" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only monthly stays can be terminated early"})
+		return
+	}
+
+	if booking.Status == "cancelled" || booking.NoticeGivenAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Notice has already been given for this stay"})
+		return
+	}
+
+	now := time.Now()
+	newEnd := billing.EarliestEnd(now, booking.Property.NoticePeriodDays)
+	if req.MoveOutDate != nil && req.MoveOutDate.After(newEnd) {
+		newEnd = *req.MoveOutDate
+	}
+	if !newEnd.After(booking.StartDate) {
+		c.JSON(http.StatusConflict, gin.H{"error": billing.ErrNotStarted.Error()})
+		return
+	}
+	if !newEnd.Before(booking.EndDate) {
+		c.JSON(http.StatusConflict, gin.H{"error": billing.ErrNothingToTerminate.Error()})
+		return
+	}
+
+	var installments []models.Installment
+	if err := h.DB.Where("booking_id = ?", booking.ID).Order("sequence").Find(&installments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch installments"})
+		return
+	}
+
+	installments, total, refund := billing.Terminate(installments, newEnd)
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		for i := range installments {
+			if err := tx.Model(&installments[i]).Updates(map[string]interface{}{
+				"status":     installments[i].Status,
+				"amount":     installments[i].Amount,
+				"period_end": installments[i].PeriodEnd,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(booking).Updates(map[string]interface{}{
+			"end_date":        newEnd,
+			"total_price":     total,
+			"refund_due":      refund,
+			"notice_given_at": now,
+		}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to terminate stay"})
+		return
+	}
+
+	// The freed months may be wanted by waitlisted guests
+	if err := h.Waitlist.OfferFreedDates(c.Request.Context(), booking.PropertyID, newEnd, booking.EndDate); err != nil {
+		log.Printf("Failed to offer freed dates of booking %d to the waitlist: %v", booking.ID, err)
+	}
+
+	h.GetInstallments(c)
+}
+
+// MarkInstallmentPaid records that the owner received a monthly payment
+// @Summary Mark an installment as paid
+// @Description Record payment of a monthly installment (property owner only)
+// @Tags bookings
+// @Produce json
+// @Param id path int true "Booking ID"
+// @Param installment_id path int true "Installment ID"
+// @Success 200 {object} models.Installment
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /bookings/{id}/installments/{installment_id}/paid [post]
+func (h *BookingHandler) MarkInstallmentPaid(c *gin.Context) {
+	booking, ok := h.findBookingForParticipant(c)
+	if !ok {
+		return
+	}
+
+	if booking.Property.OwnerID != currentUserID(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the property owner can record payments"})
+		return
+	}
+
+	var installment models.Installment
+	if err := h.DB.Where("booking_id = ?", booking.ID).First(&installment, c.Param("installment_id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Installment not found"})
+		return
+	}
+
+	if installment.Status == "paid" || installment.Status == "void" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Installment is already settled"})
+		return
+	}
+
+	now := time.Now()
+	installment.Status = "paid"
+	installment.PaidAt = &now
+	if err := h.DB.Model(&installment).Updates(map[string]interface{}{"status": "paid", "paid_at": now}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update installment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, installment)
+}
@@ -0,0 +1,403 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bookaroo/bookaroo-platform-be/calendarsync"
+	"github.com/bookaroo/bookaroo-platform-be/ical"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const calendarDomain = "bookaroo.com"
+
+type CalendarHandler struct {
+	DB     *gorm.DB
+	Syncer *calendarsync.Syncer
+}
+
+func NewCalendarHandler(db *gorm.DB) *CalendarHandler {
+	return &CalendarHandler{DB: db, Syncer: calendarsync.NewSyncer(db)}
+}
+
+type CreateBlockRequest struct {
+	StartDate time.Time `json:"start_date" binding:"required"`
+	EndDate   time.Time `json:"end_date" binding:"required"`
+	Note      string    `json:"note"`
+}
+
+type CreateCalendarSourceRequest struct {
+	Name string `json:"name" binding:"required"`
+	URL  string `json:"url" binding:"required"`
+}
+
+type CalendarTokenResponse struct {
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
+
+// ExportCalendar returns the property's bookings and blocks as an iCalendar feed
+// @Summary Export property calendar
+// @Description Retrieve the bookings that aren't cancelled and the blocks the owner added, not those imported from other calendars, as an RFC 5545 iCalendar feed
+// @Tags calendar
+// @Produce text/calendar
+// @Param id path int true "Property ID"
+// @Param token query string true "Calendar feed token"
+// @Success 200 {string} string
+// @Failure 404 {object} models.ErrorResponse
+// @Router /properties/{id}/calendar.ics [get]
+func (h *CalendarHandler) ExportCalendar(c *gin.Context) {
+	var property models.Property
+	if err := h.DB.First(&property, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Property not found"})
+		return
+	}
+
+	// Answer a wrong token exactly like a missing property so feeds can't be probed
+	token := c.Query("token")
+	if property.CalendarToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(property.CalendarToken)) != 1 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Property not found"})
+		return
+	}
+
+	// Pending bookings hold their dates too, so they are exported like availability.HasConflict counts them
+	var bookings []models.Booking
+	if err := h.DB.Where("property_id = ? AND status != ?", property.ID, "cancelled").Order("start_date").Find(&bookings).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching bookings"})
+		return
+	}
+
+	// Blocks imported from other calendars are left out: their notes are the
+	// other channel's event titles, and each channel already has its own dates
+	var blocks []models.PropertyBlock
+	if err := h.DB.Where("property_id = ? AND calendar_source_id IS NULL", property.ID).Order("start_date").Find(&blocks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching blocks"})
+		return
+	}
+
+	now := time.Now()
+	cal := ical.Calendar{
+		ProdID: "-//Bookaroo//Bookaroo Platform//EN",
+		Name:   property.Name,
+		Events: make([]ical.Event, 0, len(bookings)+len(blocks)),
+	}
+
+	// Guest details are deliberately left out of the feed. Pending bookings are
+	// tentative so external calendars can tell them from firm ones.
+	for _, booking := range bookings {
+		status := "CONFIRMED"
+		if booking.Status == "pending" {
+			status = "TENTATIVE"
+		}
+		cal.Events = append(cal.Events, ical.Event{
+			UID:     ical.UID("booking", booking.ID, calendarDomain),
+			Start:   ical.DateOnly(booking.StartDate),
+			End:     ical.DateOnly(booking.EndDate),
+			Summary: "Reserved",
+			Status:  status,
+			Stamp:   now,
+		})
+	}
+
+	for _, block := range blocks {
+		cal.Events = append(cal.Events, ical.Event{
+			UID:         ical.UID("block", block.ID, calendarDomain),
+			Start:       ical.DateOnly(block.StartDate),
+			End:         ical.DateOnly(block.EndDate),
+			Summary:     "Not available",
+			Description: block.Note,
+			Status:      "CONFIRMED",
+			Stamp:       now,
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := cal.Write(&buf); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render calendar"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=\"property-%d.ics\"", property.ID))
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", buf.Bytes())
+}
+
+// RotateCalendarToken issues a new calendar feed token, invalidating the previous one
+// @Summary Rotate calendar feed token
+// @Description Generate a new secret token for the property's iCalendar feed
+// @Tags calendar
+// @Produce json
+// @Param id path int true "Property ID"
+// @Success 200 {object} CalendarTokenResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /properties/{id}/calendar/token [post]
+func (h *CalendarHandler) RotateCalendarToken(c *gin.Context) {
+	property, ok := findOwnedProperty(h.DB, c)
+	if !ok {
+		return
+	}
+
+	token, err := generateToken(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	if err := h.DB.Model(property).Update("calendar_token", token).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update calendar token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, CalendarTokenResponse{
+		Token: token,
+		URL:   fmt.Sprintf("/api/properties/%d/calendar.ics?token=%s", property.ID, token),
+	})
+}
+
+// ListBlocks returns the blocked date ranges of a property
+// @Summary List property blocks
+// @Description Retrieve the date ranges the owner has blocked
+// @Tags calendar
+// @Produce json
+// @Param id path int true "Property ID"
+// @Success 200 {array} models.PropertyBlock
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /properties/{id}/blocks [get]
+func (h *CalendarHandler) ListBlocks(c *gin.Context) {
+	property, ok := findOwnedProperty(h.DB, c)
+	if !ok {
+		return
+	}
+
+	// Blocks imported from other calendars are left out: their notes are the
+	// other channel's event titles, and each channel already has its own dates
+	var blocks []models.PropertyBlock
+	if err := h.DB.Where("property_id = ? AND calendar_source_id IS NULL", property.ID).Order("start_date").Find(&blocks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching blocks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, blocks)
+}
+
+// CreateBlock blocks a date range so it can no longer be booked
+// @Summary Block property dates
+// @Description Block a date range on the property's calendar
+// @Tags calendar
+// @Accept json
+// @Produce json
+// @Param id path int true "Property ID"
+// @Param block body CreateBlockRequest true "Block details"
+// @Success 201 {object} models.PropertyBlock
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /properties/{id}/blocks [post]
+func (h *CalendarHandler) CreateBlock(c *gin.Context) {
+	property, ok := findOwnedProperty(h.DB, c)
+	if !ok {
+		return
+	}
+
+	var req CreateBlockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !req.EndDate.After(req.StartDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must be after start_date"})
+		return
+	}
+
+	block := models.PropertyBlock{
+		PropertyID: property.ID,
+		StartDate:  req.StartDate,
+		EndDate:    req.EndDate,
+		Note:       req.Note,
+		Source:     "owner",
+	}
+
+	if err := h.DB.Create(&block).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create block"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, block)
+}
+
+// DeleteBlock removes a blocked date range
+// @Summary Unblock property dates
+// @Description Remove a block from the property's calendar
+// @Tags calendar
+// @Produce json
+// @Param id path int true "Property ID"
+// @Param block_id path int true "Block ID"
+// @Success 204
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /properties/{id}/blocks/{block_id} [delete]
+func (h *CalendarHandler) DeleteBlock(c *gin.Context) {
+	property, ok := findOwnedProperty(h.DB, c)
+	if !ok {
+		return
+	}
+
+	var block models.PropertyBlock
+	if err := h.DB.Where("property_id = ?", property.ID).First(&block, c.Param("block_id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Block not found"})
+		return
+	}
+
+	if err := h.DB.Delete(&block).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete block"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListCalendarSources returns the external calendars imported into the property with their sync status
+// @Summary List calendar sources
+// @Description Retrieve the external iCalendar feeds of a property, including sync status and last error
+// @Tags calendar
+// @Produce json
+// @Param id path int true "Property ID"
+// @Success 200 {array} models.CalendarSource
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /properties/{id}/calendar/sources [get]
+func (h *CalendarHandler) ListCalendarSources(c *gin.Context) {
+	property, ok := findOwnedProperty(h.DB, c)
+	if !ok {
+		return
+	}
+
+	var sources []models.CalendarSource
+	if err := h.DB.Where("property_id = ?", property.ID).Order("id").Find(&sources).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching calendar sources"})
+		return
+	}
+
+	c.JSON(http.StatusOK, sources)
+}
+
+// CreateCalendarSource registers an external calendar and runs its first sync
+// @Summary Add calendar source
+// @Description Register an external iCalendar feed whose events block the property's dates
+// @Tags calendar
+// @Accept json
+// @Produce json
+// @Param id path int true "Property ID"
+// @Param source body CreateCalendarSourceRequest true "Calendar source details"
+// @Success 201 {object} models.CalendarSource
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /properties/{id}/calendar/sources [post]
+func (h *CalendarHandler) CreateCalendarSource(c *gin.Context) {
+	property, ok := findOwnedProperty(h.DB, c)
+	if !ok {
+		return
+	}
+
+	var req CreateCalendarSourceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := calendarsync.CheckURL(req.URL); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	source := models.CalendarSource{
+		PropertyID: property.ID,
+		Name:       req.Name,
+		URL:        req.URL,
+		SyncStatus: "pending",
+	}
+
+	if err := h.DB.Create(&source).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create calendar source"})
+		return
+	}
+
+	// A failed first sync is reported through the source's status rather than the response code
+	h.Syncer.SyncSource(c.Request.Context(), &source)
+
+	c.JSON(http.StatusCreated, source)
+}
+
+// SyncCalendarSource imports the external calendar immediately instead of waiting for the scheduler
+// @Summary Sync calendar source
+// @Description Fetch an external iCalendar feed now and update the property's blocks
+// @Tags calendar
+// @Produce json
+// @Param id path int true "Property ID"
+// @Param source_id path int true "Calendar source ID"
+// @Success 200 {object} models.CalendarSource
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /properties/{id}/calendar/sources/{source_id}/sync [post]
+func (h *CalendarHandler) SyncCalendarSource(c *gin.Context) {
+	property, ok := findOwnedProperty(h.DB, c)
+	if !ok {
+		return
+	}
+
+	var source models.CalendarSource
+	if err := h.DB.Where("property_id = ?", property.ID).First(&source, c.Param("source_id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Calendar source not found"})
+		return
+	}
+
+	h.Syncer.SyncSource(c.Request.Context(), &source)
+
+	c.JSON(http.StatusOK, source)
+}
+
+// DeleteCalendarSource stops importing an external calendar and removes its blocks
+// @Summary Remove calendar source
+// @Description Remove an external iCalendar feed together with the blocks it created
+// @Tags calendar
+// @Produce json
+// @Param id path int true "Property ID"
+// @Param source_id path int true "Calendar source ID"
+// @Success 204
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /properties/{id}/calendar/sources/{source_id} [delete]
+func (h *CalendarHandler) DeleteCalendarSource(c *gin.Context) {
+	property, ok := findOwnedProperty(h.DB, c)
+	if !ok {
+		return
+	}
+
+	var source models.CalendarSource
+	if err := h.DB.Where("property_id = ?", property.ID).First(&source, c.Param("source_id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Calendar source not found"})
+		return
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("calendar_source_id = ?", source.ID).Delete(&models.PropertyBlock{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&source).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete calendar source"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
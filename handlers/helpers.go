@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/bookaroo/bookaroo-platform-be/amenities"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/pagination"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// currentUserID returns the ID of the authenticated user set by AuthMiddleware
+func currentUserID(c *gin.Context) uint {
+	userID, _ := c.Get("user_id")
+	id, _ := userID.(uint)
+	return id
+}
+
+// findOwnedProperty loads the property from the :id path parameter and verifies
+// that it belongs to the authenticated user. Deleted properties are not found.
+// It writes the error response itself and returns false when the caller should stop.
+func findOwnedProperty(db *gorm.DB, c *gin.Context) (*models.Property, bool) {
+	return findOwnedPropertyIncludingDeleted(db.Where("properties.deleted_at IS NULL"), c)
+}
+
+// findOwnedPropertyIncludingDeleted is findOwnedProperty for restoring, the only
+// thing owners can still do with a deleted property
+func findOwnedPropertyIncludingDeleted(db *gorm.DB, c *gin.Context) (*models.Property, bool) {
+	var property models.Property
+	if err := db.First(&property, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Property not found"})
+		return nil, false
+	}
+
+	if property.OwnerID != currentUserID(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to manage this property"})
+		return nil, false
+	}
+
+	return &property, true
+}
+
+// listedProperties scopes a query to properties guests can see and book
+func listedProperties(db *gorm.DB) *gorm.DB {
+	return db.Scopes(models.ListedScope)
+}
+
+// orderedImages sorts preloaded images into their display order
+func orderedImages(db *gorm.DB) *gorm.DB {
+	return db.Order("property_images.position, property_images.id")
+}
+
+// conceal hides what only owners and guests with a confirmed booking may see
+// of properties, see models.Property.Conceal
+func conceal(properties []*models.Property) {
+	for _, p := range properties {
+		p.Conceal()
+	}
+}
+
+// publicOwner limits a preloaded owner to what anyone may see of them
+func publicOwner(db *gorm.DB) *gorm.DB {
+	return db.Select("id", "name", "role", "business_name")
+}
+
+// coverImage limits preloaded images to the cover, for lighter listing payloads
+func coverImage(db *gorm.DB) *gorm.DB {
+	return db.Where("property_images.is_cover = ?", true)
+}
+
+// propertySort is how a sort query value orders properties
+type propertySort struct {
+	key  string // SQL sort key compared before the id, empty to sort by id alone
+	desc bool
+}
+
+// propertySorts are the orderings every property list accepts
+var propertySorts = map[string]propertySort{
+	"":           {},
+	"newest":     {desc: true},
+	"price_asc":  {key: "properties.price"},
+	"price_desc": {key: "properties.price", desc: true},
+	"rating":     {key: "properties.rating_average", desc: true},
+}
+
+// propertyCursor returns the cursor pointing after p in a list ordered by sort
+func propertyCursor(sort string) func(models.Property) pagination.Cursor {
+	return func(p models.Property) pagination.Cursor {
+		switch propertySorts[sort].key {
+		case "":
+			return pagination.Cursor{ID: p.ID}
+		case "properties.rating_average":
+			return pagination.Cursor{Key: p.RatingAverage, ID: p.ID}
+		}
+		return pagination.Cursor{Key: p.Price, ID: p.ID}
+	}
+}
+
+// keysetPage orders query by key, an SQL expression taking keyArgs, then by the
+// id column as a tie-breaker, and limits it to the rows of page. Paging compares
+// against the cursor rather than skipping rows, so deep pages stay cheap and
+// rows don't repeat or vanish when earlier ones are added or removed.
+func keysetPage(query *gorm.DB, key string, keyArgs []interface{}, id string, desc bool, page pagination.Page) *gorm.DB {
+	dir, cmp := "", ">"
+	if desc {
+		dir, cmp = " DESC", "<"
+	}
+
+	if key == "" {
+		if page.After != nil {
+			query = query.Where(id+" "+cmp+" ?", page.After.ID)
+		}
+		return query.Order(id + dir).Limit(page.Fetch())
+	}
+
+	if page.After != nil {
+		args := append(append([]interface{}{}, keyArgs...), page.After.Key, page.After.ID)
+		query = query.Where("("+key+", "+id+") "+cmp+" (?, ?)", args...)
+	}
+	return query.Clauses(clause.OrderBy{Expression: clause.Expr{
+		SQL:                key + dir + ", " + id + dir,
+		Vars:               keyArgs,
+		WithoutParentheses: true,
+	}}).Limit(page.Fetch())
+}
+
+// languageError describes what the named parameter takes: a BCP 47 language tag
+func languageError(param string) string {
+	return param + " must be a language tag such as en, id or th"
+}
+
+// normalizeAmenityCodes cleans up amenity codes sent by clients, e.g. "Wi-Fi" becomes "wifi"
+func normalizeAmenityCodes(codes []string) []string {
+	return amenities.ParseCodes(strings.Join(codes, ","))
+}
+
+// generateToken returns a random hex-encoded token of n bytes
+func generateToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/bookaroo/bookaroo-platform-be/locations"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultLocationSuggestions = 8
+	maxLocationSuggestions     = 20
+)
+
+type LocationHandler struct {
+	DB *gorm.DB
+}
+
+func NewLocationHandler(db *gorm.DB) *LocationHandler {
+	return &LocationHandler{DB: db}
+}
+
+// AutocompleteLocations suggests places as the user types into the location search box
+// @Summary Autocomplete locations
+// @Description Returns cities, regions and countries with listed properties whose name, or a word of it, starts with q, with their listing counts, most listings first. Pass a suggestion's id as place_id to search properties there.
+// @Tags locations
+// @Produce json
+// @Param q query string true "Text typed so far, e.g. ubu"
+// @Param limit query int false "Number of suggestions (default 8, max 20)"
+// @Success 200 {array} locations.Suggestion
+// @Failure 400 {object} map[string]string
+// @Router /locations/autocomplete [get]
+func (h *LocationHandler) AutocompleteLocations(c *gin.Context) {
+	limit := defaultLocationSuggestions
+	if l := c.Query("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be a number between 1 and %d", maxLocationSuggestions)})
+			return
+		}
+		limit = min(n, maxLocationSuggestions)
+	}
+
+	suggestions, err := locations.Autocomplete(h.DB, c.Query("q"), limit)
+	if errors.Is(err, locations.ErrBlankQuery) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error suggesting locations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, suggestions)
+}
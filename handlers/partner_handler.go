@@ -0,0 +1,566 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/bookaroo/bookaroo-platform-be/availability"
+	"github.com/bookaroo/bookaroo-platform-be/channel"
+	"github.com/bookaroo/bookaroo-platform-be/middleware"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/notifications"
+	"github.com/bookaroo/bookaroo-platform-be/waitlist"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxARIDays caps the date range a partner can pull at once
+const maxARIDays = 366
+
+var (
+	errUnavailable         = errors.New("property is not available for these dates")
+	errReservationConflict = errors.New("reservation already exists")
+)
+
+type PartnerHandler struct {
+	DB       *gorm.DB
+	Waitlist *waitlist.Manager
+}
+
+func NewPartnerHandler(db *gorm.DB) *PartnerHandler {
+	return &PartnerHandler{
+		DB:       db,
+		Waitlist: waitlist.NewManager(db, notifications.NewDBNotifier(db)),
+	}
+}
+
+type CreatePartnerRequest struct {
+	Name       string `json:"name" binding:"required"`
+	Code       string `json:"code" binding:"required"`
+	WebhookURL string `json:"webhook_url"`
+}
+
+type CreatePartnerResponse struct {
+	Partner models.Partner `json:"partner"`
+	APIKey  string         `json:"api_key"` // Only returned once
+}
+
+type CreatePartnerListingRequest struct {
+	PropertyID        uint   `json:"property_id" binding:"required"`
+	ExternalListingID string `json:"external_listing_id" binding:"required"`
+}
+
+type PartnerReservationRequest struct {
+	PropertyID  uint      `json:"property_id" binding:"required"`
+	ExternalRef string    `json:"external_ref" binding:"required"`
+	StartDate   time.Time `json:"start_date" binding:"required"`
+	EndDate     time.Time `json:"end_date" binding:"required"`
+	GuestName   string    `json:"guest_name" binding:"required"`
+	GuestEmail  string    `json:"guest_email" binding:"required,email"`
+	TotalPrice  *float64  `json:"total_price"` // Defaults to our nightly rate when omitted
+}
+
+type ModifyPartnerReservationRequest struct {
+	StartDate  time.Time `json:"start_date" binding:"required"`
+	EndDate    time.Time `json:"end_date" binding:"required"`
+	TotalPrice *float64  `json:"total_price"`
+}
+
+type PartnerReservationResponse struct {
+	ID          uint      `json:"id"`
+	ExternalRef string    `json:"external_ref"`
+	PropertyID  uint      `json:"property_id"`
+	StartDate   time.Time `json:"start_date"`
+	EndDate     time.Time `json:"end_date"`
+	Status      string    `json:"status"`
+	TotalPrice  float64   `json:"total_price"`
+	Source      string    `json:"source"`
+}
+
+func newPartnerReservationResponse(booking models.Booking) PartnerReservationResponse {
+	return PartnerReservationResponse{
+		ID:          booking.ID,
+		ExternalRef: booking.ExternalRef,
+		PropertyID:  booking.PropertyID,
+		StartDate:   booking.StartDate,
+		EndDate:     booking.EndDate,
+		Status:      booking.Status,
+		TotalPrice:  booking.TotalPrice,
+		Source:      booking.Source,
+	}
+}
+
+func currentPartner(c *gin.Context) (uint, string) {
+	partnerID, _ := c.Get("partner_id")
+	partnerCode, _ := c.Get("partner_code")
+	id, _ := partnerID.(uint)
+	code, _ := partnerCode.(string)
+	return id, code
+}
+
+// CreatePartner registers a distribution partner and issues its API key
+// @Summary Register a partner
+// @Description Register an external distribution partner and issue its API key
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param partner body CreatePartnerRequest true "Partner details"
+// @Success 201 {object} CreatePartnerResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /admin/partners [post]
+func (h *PartnerHandler) CreatePartner(c *gin.Context) {
+	var req CreatePartnerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var existing models.Partner
+	if err := h.DB.Where("code = ?", req.Code).First(&existing).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Partner code already registered"})
+		return
+	}
+
+	apiKey, err := generateToken(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate API key"})
+		return
+	}
+
+	partner := models.Partner{
+		Name:       req.Name,
+		Code:       req.Code,
+		APIKeyHash: middleware.HashAPIKey(apiKey),
+		WebhookURL: req.WebhookURL,
+		Active:     true,
+	}
+
+	if err := h.DB.Create(&partner).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create partner"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreatePartnerResponse{Partner: partner, APIKey: apiKey})
+}
+
+// CreatePartnerListing makes a property available to a partner
+// @Summary Map a property to a partner
+// @Description Distribute a property through a partner under the partner's listing ID
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Partner ID"
+// @Param listing body CreatePartnerListingRequest true "Listing mapping"
+// @Success 201 {object} models.PartnerListing
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /admin/partners/{id}/listings [post]
+func (h *PartnerHandler) CreatePartnerListing(c *gin.Context) {
+	var partner models.Partner
+	if err := h.DB.First(&partner, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Partner not found"})
+		return
+	}
+
+	var req CreatePartnerListingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var property models.Property
+	if err := h.DB.First(&property, req.PropertyID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Property not found"})
+		return
+	}
+
+	listing := models.PartnerListing{
+		PartnerID:         partner.ID,
+		PropertyID:        property.ID,
+		ExternalListingID: req.ExternalListingID,
+	}
+
+	if err := h.DB.Create(&listing).Error; err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Property is already mapped to this partner"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, listing)
+}
+
+// findListing returns the partner's mapping for a property, writing a 404 when the property isn't distributed to them
+func (h *PartnerHandler) findListing(c *gin.Context, partnerID uint, propertyID interface{}) (*models.PartnerListing, bool) {
+	var listing models.PartnerListing
+	if err := h.DB.Where("partner_id = ? AND property_id = ?", partnerID, propertyID).First(&listing).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Property not found"})
+		return nil, false
+	}
+	return &listing, true
+}
+
+// GetARI returns availability, rates and inventory of a property for a date range
+// @Summary Pull ARI
+// @Description Retrieve nightly availability, rates and inventory for a distributed property
+// @Tags partner
+// @Produce json
+// @Param id path int true "Property ID"
+// @Param start_date query string true "First night (YYYY-MM-DD)"
+// @Param end_date query string true "Day after the last night (YYYY-MM-DD)"
+// @Success 200 {object} channel.ARI
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /partner/properties/{id}/ari [get]
+func (h *PartnerHandler) GetARI(c *gin.Context) {
+	partnerID, _ := currentPartner(c)
+
+	listing, ok := h.findListing(c, partnerID, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	start, err := time.Parse("2006-01-02", c.Query("start_date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start_date must be formatted as YYYY-MM-DD"})
+		return
+	}
+	end, err := time.Parse("2006-01-02", c.Query("end_date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must be formatted as YYYY-MM-DD"})
+		return
+	}
+	if !end.After(start) || end.Sub(start) > maxARIDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must be after start_date and at most 366 days later"})
+		return
+	}
+
+	var property models.Property
+	if err := h.DB.First(&property, listing.PropertyID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Property not found"})
+		return
+	}
+
+	days, err := channel.BuildARI(h.DB, property, start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute availability"})
+		return
+	}
+
+	c.JSON(http.StatusOK, channel.ARI{
+		PropertyID:        property.ID,
+		ExternalListingID: listing.ExternalListingID,
+		StartDate:         c.Query("start_date"),
+		EndDate:           c.Query("end_date"),
+		Days:              days,
+	})
+}
+
+// CreateReservation books a property on behalf of a partner
+// @Summary Create partner reservation
+// @Description Create a confirmed booking from a partner reservation
+// @Tags partner
+// @Accept json
+// @Produce json
+// @Param reservation body PartnerReservationRequest true "Reservation details"
+// @Success 201 {object} PartnerReservationResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /partner/reservations [post]
+func (h *PartnerHandler) CreateReservation(c *gin.Context) {
+	partnerID, partnerCode := currentPartner(c)
+
+	var req PartnerReservationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !req.EndDate.After(req.StartDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must be after start_date"})
+		return
+	}
+
+	if _, ok := h.findListing(c, partnerID, req.PropertyID); !ok {
+		return
+	}
+
+	var booking models.Booking
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the property so concurrent reservations can't both pass the availability check
+		var property models.Property
+		if err := listedProperties(tx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&property, req.PropertyID).Error; err != nil {
+			return err
+		}
+
+		// Retried requests are answered with a conflict; the unique index on
+		// (partner_id, external_ref) backs this up for other properties
+		var existing int64
+		if err := tx.Model(&models.Booking{}).Where("partner_id = ? AND external_ref = ?", partnerID, req.ExternalRef).
+			Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return errReservationConflict
+		}
+
+		conflict, err := availability.HasConflict(tx, property.ID, req.StartDate, req.EndDate, 0)
+		if err != nil {
+			return err
+		}
+		if conflict {
+			return errUnavailable
+		}
+
+		// Partner guests never hold a waitlist offer, so any active priority window blocks them
+		held, err := waitlist.IsHeld(tx, property.ID, req.StartDate, req.EndDate, 0)
+		if err != nil {
+			return err
+		}
+		if held {
+			return errUnavailable
+		}
+
+		// Partner guests don't have an account with us and are only kept as the booking's contact
+		booking = models.Booking{
+			PropertyID:  property.ID,
+			GuestName:   req.GuestName,
+			GuestEmail:  req.GuestEmail,
+			StartDate:   req.StartDate,
+			EndDate:     req.EndDate,
+			TotalPrice:  reservationPrice(property, req.StartDate, req.EndDate, req.TotalPrice),
+			Status:      "confirmed",
+			Source:      partnerCode,
+			PartnerID:   &partnerID,
+			ExternalRef: req.ExternalRef,
+		}
+		return tx.Create(&booking).Error
+	})
+
+	if errors.Is(err, errUnavailable) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Property is not available for these dates"})
+		return
+	}
+	if errors.Is(err, errReservationConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Reservation already exists"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create reservation"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, newPartnerReservationResponse(booking))
+}
+
+// findReservation loads the partner's reservation from the :external_ref path parameter
+func (h *PartnerHandler) findReservation(c *gin.Context, partnerID uint) (*models.Booking, bool) {
+	var booking models.Booking
+	if err := h.DB.Where("partner_id = ? AND external_ref = ?", partnerID, c.Param("external_ref")).First(&booking).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reservation not found"})
+		return nil, false
+	}
+	return &booking, true
+}
+
+// GetReservation returns a partner reservation by its external reference
+// @Summary Get partner reservation
+// @Description Retrieve a reservation by the partner's reference ID
+// @Tags partner
+// @Produce json
+// @Param external_ref path string true "Partner reservation ID"
+// @Success 200 {object} PartnerReservationResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /partner/reservations/{external_ref} [get]
+func (h *PartnerHandler) GetReservation(c *gin.Context) {
+	partnerID, _ := currentPartner(c)
+
+	booking, ok := h.findReservation(c, partnerID)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, newPartnerReservationResponse(*booking))
+}
+
+// ModifyReservation moves a partner reservation to new dates
+// @Summary Modify partner reservation
+// @Description Change the dates and price of a partner reservation
+// @Tags partner
+// @Accept json
+// @Produce json
+// @Param external_ref path string true "Partner reservation ID"
+// @Param reservation body ModifyPartnerReservationRequest true "New reservation details"
+// @Success 200 {object} PartnerReservationResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /partner/reservations/{external_ref} [patch]
+func (h *PartnerHandler) ModifyReservation(c *gin.Context) {
+	partnerID, _ := currentPartner(c)
+
+	booking, ok := h.findReservation(c, partnerID)
+	if !ok {
+		return
+	}
+
+	var req ModifyPartnerReservationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !req.EndDate.After(req.StartDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must be after start_date"})
+		return
+	}
+
+	if booking.Status == "cancelled" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Reservation is cancelled"})
+		return
+	}
+
+	oldStart, oldEnd := booking.StartDate, booking.EndDate
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		// Archived, suspended or deleted listings can't take new dates
+		var property models.Property
+		if err := listedProperties(tx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&property, booking.PropertyID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errUnavailable
+			}
+			return err
+		}
+
+		conflict, err := availability.HasConflict(tx, property.ID, req.StartDate, req.EndDate, booking.ID)
+		if err != nil {
+			return err
+		}
+		if conflict {
+			return errUnavailable
+		}
+
+		held, err := waitlist.IsHeld(tx, property.ID, req.StartDate, req.EndDate, 0)
+		if err != nil {
+			return err
+		}
+		if held {
+			return errUnavailable
+		}
+
+		booking.StartDate = req.StartDate
+		booking.EndDate = req.EndDate
+		booking.TotalPrice = reservationPrice(property, req.StartDate, req.EndDate, req.TotalPrice)
+		return tx.Model(booking).Updates(map[string]interface{}{
+			"start_date":  booking.StartDate,
+			"end_date":    booking.EndDate,
+			"total_price": booking.TotalPrice,
+		}).Error
+	})
+
+	if errors.Is(err, errUnavailable) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Property is not available for these dates"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to modify reservation"})
+		return
+	}
+
+	// Nights of the old stay that the new dates no longer cover go to the waitlist
+	for _, freed := range freedRanges(oldStart, oldEnd, booking.StartDate, booking.EndDate) {
+		if err := h.Waitlist.OfferFreedDates(c.Request.Context(), booking.PropertyID, freed[0], freed[1]); err != nil {
+			log.Printf("Failed to offer freed dates of booking %d to the waitlist: %v", booking.ID, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, newPartnerReservationResponse(*booking))
+}
+
+// CancelReservation cancels a partner reservation and frees its dates
+// @Summary Cancel partner reservation
+// @Description Cancel a reservation by the partner's reference ID. Cancelling an already cancelled reservation returns it unchanged.
+// @Tags partner
+// @Produce json
+// @Param external_ref path string true "Partner reservation ID"
+// @Success 200 {object} PartnerReservationResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /partner/reservations/{external_ref} [delete]
+func (h *PartnerHandler) CancelReservation(c *gin.Context) {
+	partnerID, _ := currentPartner(c)
+
+	booking, ok := h.findReservation(c, partnerID)
+	if !ok {
+		return
+	}
+
+	// Only the request that actually cancels frees the dates, so retried or
+	// concurrent cancellations don't offer them to the waitlist again
+	cancelled := false
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Booking{}).
+			Where("id = ? AND status <> ?", booking.ID, "cancelled").
+			Update("status", "cancelled")
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		cancelled = true
+		// Unpaid installments of a cancelled monthly stay are no longer owed
+		return tx.Model(&models.Installment{}).
+			Where("booking_id = ? AND status IN ('scheduled', 'due')", booking.ID).
+			Update("status", "void").Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel reservation"})
+		return
+	}
+	booking.Status = "cancelled"
+
+	if cancelled {
+		if err := h.Waitlist.OfferFreedDates(c.Request.Context(), booking.PropertyID, booking.StartDate, booking.EndDate); err != nil {
+			log.Printf("Failed to offer freed dates of booking %d to the waitlist: %v", booking.ID, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, newPartnerReservationResponse(*booking))
+}
+
+// freedRanges returns the parts of the old stay that the new stay no longer covers
+func freedRanges(oldStart, oldEnd, newStart, newEnd time.Time) [][2]time.Time {
+	var freed [][2]time.Time
+	if oldStart.Before(newStart) {
+		end := oldEnd
+		if newStart.Before(end) {
+			end = newStart
+		}
+		freed = append(freed, [2]time.Time{oldStart, end})
+	}
+	if newEnd.Before(oldEnd) {
+		start := oldStart
+		if newEnd.After(start) {
+			start = newEnd
+		}
+		freed = append(freed, [2]time.Time{start, oldEnd})
+	}
+	return freed
+}
+
+// reservationPrice uses the partner's price when given, otherwise our nightly rate
+func reservationPrice(property models.Property, start, end time.Time, partnerPrice *float64) float64 {
+	if partnerPrice != nil {
+		return *partnerPrice
+	}
+	days := end.Sub(start).Hours() / 24
+	return property.Price * days
+}
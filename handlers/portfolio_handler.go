@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/portfolio"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxImportBytes is the largest file accepted for import
+const maxImportBytes = 5 << 20
+
+type PortfolioHandler struct {
+	DB *gorm.DB
+}
+
+func NewPortfolioHandler(db *gorm.DB) *PortfolioHandler {
+	return &PortfolioHandler{DB: db}
+}
+
+// ImportProperties queues a file of properties to be created in the background
+// @Summary Import properties
+// @Description Upload up to 1000 properties as CSV or JSON Lines in the request body. The file is checked right away; its rows are then validated and created as drafts in the background. Poll the returned import for progress and per-row results. With dry_run=true the rows are only validated.
+// @Tags portfolio
+// @Accept text/csv
+// @Accept application/x-ndjson
+// @Produce json
+// @Param format query string false "csv or jsonl; taken from the Content-Type when omitted"
+// @Param dry_run query bool false "Only validate the rows"
+// @Success 202 {object} models.ImportJob
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Router /portfolio/imports [post]
+func (h *PortfolioHandler) ImportProperties(c *gin.Context) {
+	format := c.Query("format")
+	if format == "" {
+		format = importFormat(c.GetHeader("Content-Type"))
+	}
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "dry_run must be true or false"})
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("the file must not exceed %d MB", maxImportBytes>>20)})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read the file"})
+		return
+	}
+
+	// Files that can't be read at all are refused now rather than failing later
+	rows, err := portfolio.Read(format, data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(rows) > portfolio.MaxRows {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("the file has %d properties; import at most %d at a time", len(rows), portfolio.MaxRows)})
+		return
+	}
+
+	job := models.ImportJob{
+		OwnerID:   currentUserID(c),
+		Format:    format,
+		DryRun:    dryRun,
+		Status:    "queued",
+		Input:     string(data),
+		TotalRows: len(rows),
+	}
+	if err := h.DB.Create(&job).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to queue the import"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// GetImport returns the progress of an import and the results of its processed rows
+// @Summary Get an import
+// @Description Retrieve the status and progress of an import, with the outcome and validation errors of each processed row
+// @Tags portfolio
+// @Produce json
+// @Param id path int true "Import ID"
+// @Success 200 {object} models.ImportJob
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /portfolio/imports/{id} [get]
+func (h *PortfolioHandler) GetImport(c *gin.Context) {
+	var job models.ImportJob
+	if err := h.DB.Preload("Rows", func(db *gorm.DB) *gorm.DB {
+		return db.Order("line")
+	}).First(&job, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Import not found"})
+		return
+	}
+
+	if job.OwnerID != currentUserID(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to view this import"})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// ExportProperties downloads the authenticated owner's properties
+// @Summary Export properties
+// @Description Download all properties of the authenticated owner as CSV or JSON Lines, in the format accepted by imports
+// @Tags portfolio
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param format query string false "csv (default) or jsonl"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /portfolio/export [get]
+func (h *PortfolioHandler) ExportProperties(c *gin.Context) {
+	format := c.DefaultQuery("format", portfolio.FormatCSV)
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": portfolio.ErrUnknownFormat.Error()})
+		return
+	}
+
+	var properties []models.Property
+	if err := h.DB.Where("owner_id = ? AND deleted_at IS NULL", currentUserID(c)).Order("id").
+		Preload("Images", orderedImages).Preload("AmenityList").
+		Find(&properties).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching properties"})
+		return
+	}
+
+	records := make([]portfolio.Record, len(properties))
+	for i, p := range properties {
+		records[i] = portfolio.FromProperty(p)
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="properties.%s"`, format))
+	c.Status(http.StatusOK)
+	if err := portfolio.Write(c.Writer, format, records); err != nil {
+		log.Printf("Failed to export properties: %v", err)
+	}
+}
+
+var exportContentTypes = map[string]string{
+	portfolio.FormatCSV:       "text/csv; charset=utf-8",
+	portfolio.FormatJSONLines: "application/x-ndjson",
+}
+
+// importFormat guesses the format of an uploaded file from its content type
+func importFormat(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv":
+		return portfolio.FormatCSV
+	case "application/x-ndjson", "application/jsonl", "application/x-jsonlines":
+		return portfolio.FormatJSONLines
+	}
+	return ""
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/bookaroo/bookaroo-platform-be/duplicates"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/gin-gonic/gin"
+)
+
+// duplicateStatuses are the values accepted for the status filter of the duplicates report
+var duplicateStatuses = map[string]bool{
+	models.DuplicateOpen:      true,
+	models.DuplicateConfirmed: true,
+	models.DuplicateDismissed: true,
+}
+
+// DuplicateCluster is a group of properties suspected of being the same place, with the flags linking them
+type DuplicateCluster struct {
+	duplicates.Cluster
+	Properties []models.Property `json:"properties"`
+}
+
+// ListDuplicateClusters reports the properties suspected of being listed more than once
+// @Summary List suspected duplicate listings
+// @Description Retrieve clusters of properties flagged as probable duplicates of each other, likeliest first. Properties are flagged when saved or imported, comparing their names, locations, coordinates and uploaded photos with the owner's other listings and nearby ones (admin only)
+// @Tags admin
+// @Produce json
+// @Param status query string false "Flags to group: open, confirmed or dismissed (default open)"
+// @Success 200 {array} DuplicateCluster
+// @Failure 400 {object} map[string]string
+// @Router /admin/duplicates [get]
+func (h *PropertyHandler) ListDuplicateClusters(c *gin.Context) {
+	status := c.DefaultQuery("status", models.DuplicateOpen)
+	if !duplicateStatuses[status] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be open, confirmed or dismissed"})
+		return
+	}
+
+	var flags []models.DuplicateFlag
+	if err := h.DB.Where("status = ?", status).Order("id").Find(&flags).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching duplicates"})
+		return
+	}
+	clusters := duplicates.Clusters(flags)
+
+	var ids []uint
+	for _, cluster := range clusters {
+		ids = append(ids, cluster.PropertyIDs...)
+	}
+	var properties []models.Property
+	if len(ids) > 0 {
+		if err := h.DB.Preload("Images", coverImage).Preload("Owner").Find(&properties, ids).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching duplicates"})
+			return
+		}
+	}
+	byID := make(map[uint]models.Property, len(properties))
+	for _, p := range properties {
+		byID[p.ID] = p
+	}
+
+	response := make([]DuplicateCluster, len(clusters))
+	for i, cluster := range clusters {
+		response[i] = DuplicateCluster{Cluster: cluster, Properties: make([]models.Property, 0, len(cluster.PropertyIDs))}
+		for _, id := range cluster.PropertyIDs {
+			if p, ok := byID[id]; ok {
+				response[i].Properties = append(response[i].Properties, p)
+			}
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ConfirmDuplicate records that a flagged pair is the same place listed twice
+// @Summary Confirm a duplicate
+// @Description Mark a flagged pair of properties as the same place. Neither listing is changed: suspend the copy to hide it (admin only)
+// @Tags admin
+// @Produce json
+// @Param id path int true "Duplicate flag ID"
+// @Success 200 {object} models.DuplicateFlag
+// @Failure 404 {object} map[string]string
+// @Router /admin/duplicates/{id}/confirm [post]
+func (h *PropertyHandler) ConfirmDuplicate(c *gin.Context) {
+	h.reviewDuplicate(c, models.DuplicateConfirmed)
+}
+
+// DismissDuplicate records that a flagged pair are different places
+// @Summary Dismiss a duplicate
+// @Description Mark a flagged pair of properties as different places; the pair isn't flagged again (admin only)
+// @Tags admin
+// @Produce json
+// @Param id path int true "Duplicate flag ID"
+// @Success 200 {object} models.DuplicateFlag
+// @Failure 404 {object} map[string]string
+// @Router /admin/duplicates/{id}/dismiss [post]
+func (h *PropertyHandler) DismissDuplicate(c *gin.Context) {
+	h.reviewDuplicate(c, models.DuplicateDismissed)
+}
+
+// reviewDuplicate records an admin's decision on the flag from the :id path parameter
+func (h *PropertyHandler) reviewDuplicate(c *gin.Context, status string) {
+	var flag models.DuplicateFlag
+	if err := h.DB.First(&flag, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Duplicate flag not found"})
+		return
+	}
+
+	reviewerID, now := currentUserID(c), time.Now()
+	flag.Status, flag.ReviewedByID, flag.ReviewedAt = status, &reviewerID, &now
+	if err := h.DB.Model(&flag).Select("status", "reviewed_by_id", "reviewed_at").Updates(&flag).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to review duplicate"})
+		return
+	}
+
+	c.JSON(http.StatusOK, flag)
+}
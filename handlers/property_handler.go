@@ -1,55 +1,150 @@
 package handlers
 
 import (
+	"errors"
+	"log"
 	"net/http"
-	"strconv"
+	"strings"
 	"time"
 
+	"github.com/bookaroo/bookaroo-platform-be/amenities"
+	"github.com/bookaroo/bookaroo-platform-be/duplicates"
+	"github.com/bookaroo/bookaroo-platform-be/filters"
+	"github.com/bookaroo/bookaroo-platform-be/geo"
+	"github.com/bookaroo/bookaroo-platform-be/i18n"
+	"github.com/bookaroo/bookaroo-platform-be/locations"
+	"github.com/bookaroo/bookaroo-platform-be/media"
 	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/moderation"
+	"github.com/bookaroo/bookaroo-platform-be/notifications"
+	"github.com/bookaroo/bookaroo-platform-be/pagination"
+	"github.com/bookaroo/bookaroo-platform-be/pricing"
+	"github.com/bookaroo/bookaroo-platform-be/recommend"
+	"github.com/bookaroo/bookaroo-platform-be/search"
+	"github.com/bookaroo/bookaroo-platform-be/storage"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+var errHasUpcomingBookings = errors.New("property has upcoming bookings")
+
 type PropertyHandler struct {
-	DB *gorm.DB
+	DB        *gorm.DB
+	Storage   storage.Storage
+	WebP      media.WebPEncoder // Encodes WebP copies of uploaded photos; nil stores JPEG only
+	Geocoder  geo.Geocoder
+	Search    search.Backend
+	Moderator *moderation.Moderator
+	// Recommender scores the similar properties suggested alongside a property
+	Recommender recommend.Scorer
+	// Duplicates flags listings that look like another listing, for admins to review
+	Duplicates *duplicates.Detector
 }
 
-func NewPropertyHandler(db *gorm.DB) *PropertyHandler {
-	return &PropertyHandler{DB: db}
+func NewPropertyHandler(db *gorm.DB, backend search.Backend, geocoder geo.Geocoder) *PropertyHandler {
+	store, err := storage.NewFromEnv()
+	if err != nil {
+		log.Fatal("Failed to configure image storage:", err)
+	}
+	webp, err := media.NewWebPEncoderFromEnv()
+	if err != nil {
+		log.Fatal("Failed to configure WebP encoder:", err)
+	}
+	return &PropertyHandler{
+		DB:        db,
+		Storage:   store,
+		WebP:      webp,
+		Geocoder:  geocoder,
+		Search:    backend,
+		Moderator: moderation.NewModerator(db, notifications.NewDBNotifier(db)),
+		// Swap in another scorer to change what "similar" means
+		Recommender: recommend.Default(),
+		Duplicates:  duplicates.NewDetector(db),
+	}
 }
 
-// ListProperties returns all properties with optional filtering
+// PropertyListResponse is a page of properties
+type PropertyListResponse struct {
+	Properties []models.Property `json:"properties"`
+	Meta       pagination.Meta   `json:"meta"`
+}
+
+// ListProperties returns a page of properties with optional filtering
 // @Summary List all properties
-// @Description Retrieve a list of all properties
+// @Description Retrieve a page of properties. Pass meta.next_cursor back as cursor, with the same sort, to get the next page.
 // @Tags properties
 // @Accept json
 // @Produce json
-// @Success 200 {array} models.Property
+// @Param location query string false "City or region to filter by, matching part of the name"
+// @Param sort query string false "newest, price_asc, price_desc or rating (highest rated first)"
+// @Param limit query int false "Properties per page (default 20, max 100)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Param lang query string false "Locale to show listings in, e.g. id; overrides Accept-Language"
+// @Param Accept-Language header string false "Preferred locales, e.g. th, en;q=0.8"
+// @Success 200 {object} PropertyListResponse
+// @Failure 400 {object} map[string]string
 // @Router /properties [get]
 func (h *PropertyHandler) ListProperties(c *gin.Context) {
-	var properties []models.Property
-	query := h.DB.Preload("Images").Preload("Owner")
+	sort, ok := propertySorts[c.Query("sort")]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be newest, price_asc, price_desc or rating"})
+		return
+	}
+	page, err := pagination.Parse(c.Query("limit"), c.Query("cursor"), c.Query("sort"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := listedProperties(h.DB.Model(&models.Property{}))
 
 	// Handle search parameters
 	if location := c.Query("location"); location != "" {
-		query = query.Where("location ILIKE ?", "%"+location+"%")
+		query = query.Scopes(filters.InCityOrRegion(location))
 	}
 
-	if err := query.Find(&properties).Error; err != nil {
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching properties"})
 		return
 	}
 
-	c.JSON(http.StatusOK, properties)
+	properties := []models.Property{}
+	if err := keysetPage(query.Session(&gorm.Session{}), sort.key, nil, "properties.id", sort.desc, page).
+		Preload("Images", coverImage).Preload("AmenityList").Preload("Owner", publicOwner).
+		Find(&properties).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching properties"})
+		return
+	}
+	if err := localize(h.DB, c, properties); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching properties"})
+		return
+	}
+	favorites := make([]*models.Property, len(properties))
+	for i := range properties {
+		favorites[i] = &properties[i]
+	}
+	if err := markFavorited(h.DB, c, favorites); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching properties"})
+		return
+	}
+	conceal(favorites)
+
+	var response PropertyListResponse
+	response.Properties, response.Meta = pagination.Trim(properties, page, total, propertyCursor(c.Query("sort")))
+	c.JSON(http.StatusOK, response)
 }
 
 // GetProperty returns details of a specific property
 // @Summary Get a specific property
-// @Description Retrieve a property by its ID
+// @Description Retrieve a property by its ID, with its name, description and house rules in the best available locale
 // @Tags properties
 // @Accept json
 // @Produce json
 // @Param id path int true "Property ID"
+// @Param lang query string false "Locale to show the listing in, e.g. id; overrides Accept-Language"
+// @Param Accept-Language header string false "Preferred locales, e.g. th, en;q=0.8"
 // @Success 200 {object} models.Property
 // @Failure 404 {object} map[string]string
 // @Router /properties/{id} [get]
@@ -57,79 +152,129 @@ func (h *PropertyHandler) GetProperty(c *gin.Context) {
 	id := c.Param("id")
 	var property models.Property
 
-	if err := h.DB.Preload("Images").Preload("Owner").First(&property, id).Error; err != nil {
+	if err := listedProperties(h.DB).Preload("Images", orderedImages).Preload("AmenityList").Preload("Owner", publicOwner).First(&property, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Property not found"})
 		return
 	}
 
-	c.JSON(http.StatusOK, property)
-}
-
-// SearchProperties handles property search with various filters
-// @Summary Search properties
-// @Description Search for properties based on criteria
-// @Tags properties
-// @Accept json
-// @Produce json
-// @Param location query string false "Location to search"
-// @Success 200 {array} models.Property
-// @Router /properties/search [get]
-func (h *PropertyHandler) SearchProperties(c *gin.Context) {
-	var properties []models.Property
-	query := h.DB.Preload("Images").Preload("Owner")
-
-	// Apply filters
-	if location := c.Query("location"); location != "" {
-		query = query.Where("location ILIKE ?", "%"+location+"%")
+	localized := []models.Property{property}
+	if err := localize(h.DB, c, localized); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching property"})
+		return
 	}
-
-	if minPrice := c.Query("min_price"); minPrice != "" {
-		if price, err := strconv.ParseFloat(minPrice, 64); err == nil {
-			query = query.Where("price >= ?", price)
-		}
+	if err := markFavorited(h.DB, c, []*models.Property{&localized[0]}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching property"})
+		return
 	}
+	conceal([]*models.Property{&localized[0]})
+	c.Header("Content-Language", localized[0].Locale)
 
-	if maxPrice := c.Query("max_price"); maxPrice != "" {
-		if price, err := strconv.ParseFloat(maxPrice, 64); err == nil {
-			query = query.Where("price <= ?", price)
-		}
-	}
+	c.JSON(http.StatusOK, localized[0])
+}
 
-	if err := query.Find(&properties).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error searching properties"})
-		return
+type CreatePropertyRequest struct {
+	Name             string                       `json:"name" binding:"required"`
+	Description      string                       `json:"description" binding:"required"`
+	Location         string                       `json:"location" binding:"required"`
+	Price            float64                      `json:"price" binding:"required"`
+	MonthlyPrice     float64                      `json:"monthly_price"`              // Set to offer monthly stays
+	MaxGuests        int                          `json:"max_guests" binding:"min=0"` // 0 for no limit
+	NoticePeriodDays int                          `json:"notice_period_days"`         // Defaults to 30
+	Amenities        string                       `json:"amenities"`
+	AmenityCodes     []string                     `json:"amenity_codes"` // Catalog codes, e.g. ["pool", "wifi"]
+	Language         string                       `json:"language"`      // Of the name and description, e.g. "fr" or "id"; defaults to "en"
+	Latitude         *float64                     `json:"latitude"`      // Geocoded from location when omitted
+	Longitude        *float64                     `json:"longitude"`
+	OwnerID          uint                         `json:"owner_id"` // Optional; properties always belong to the caller, so any other owner is refused
+	Images           []CreatePropertyImageRequest `json:"images"`
+	// The kind of place and the stay guests can expect
+	PropertyType        string            `json:"property_type" binding:"omitempty,oneof=apartment house villa cabin bungalow guesthouse hotel hostel other"`
+	SpaceType           string            `json:"space_type" binding:"omitempty,oneof=entire_place private_room shared_room"` // Defaults to entire_place
+	HouseRules          HouseRulesRequest `json:"house_rules"`
+	CheckInInstructions string            `json:"check_in_instructions"` // Revealed to guests once their booking is confirmed
+	// Read from location when omitted
+	Address *AddressRequest `json:"address"`
+}
+
+// HouseRulesRequest sets the house rules of a property
+type HouseRulesRequest struct {
+	SmokingAllowed  bool   `json:"smoking_allowed"`
+	PartiesAllowed  bool   `json:"parties_allowed"`
+	PetsAllowed     bool   `json:"pets_allowed"`
+	QuietHoursStart string `json:"quiet_hours_start" binding:"omitempty,datetime=15:04"` // HH:MM, with quiet_hours_end
+	QuietHoursEnd   string `json:"quiet_hours_end" binding:"omitempty,datetime=15:04"`
+	Other           string `json:"other"`
+}
+
+// houseRules checks the request and returns the rules to store
+func (r HouseRulesRequest) houseRules() (models.HouseRules, error) {
+	if (r.QuietHoursStart == "") != (r.QuietHoursEnd == "") {
+		return models.HouseRules{}, errors.New("quiet_hours_start and quiet_hours_end must be set together")
 	}
+	return models.HouseRules{
+		SmokingAllowed:  r.SmokingAllowed,
+		PartiesAllowed:  r.PartiesAllowed,
+		PetsAllowed:     r.PetsAllowed,
+		QuietHoursStart: r.QuietHoursStart,
+		QuietHoursEnd:   r.QuietHoursEnd,
+		Other:           strings.TrimSpace(r.Other),
+	}, nil
+}
 
-	c.JSON(http.StatusOK, properties)
+// AddressRequest sets the structured address of a property
+type AddressRequest struct {
+	Street      string `json:"street"` // Revealed to guests once their booking is confirmed
+	City        string `json:"city" binding:"required"`
+	Region      string `json:"region"`
+	PostalCode  string `json:"postal_code"`                                       // Revealed to guests once their booking is confirmed
+	CountryCode string `json:"country_code" binding:"omitempty,iso3166_1_alpha2"` // e.g. ID
 }
 
-type CreatePropertyRequest struct {
-	Name        string                       `json:"name" binding:"required"`
-	Description string                       `json:"description" binding:"required"`
-	Location    string                       `json:"location" binding:"required"`
-	Price       float64                      `json:"price" binding:"required"`
-	Amenities   string                       `json:"amenities"`
-	OwnerID     uint                         `json:"owner_id" binding:"required"`
-	Images      []CreatePropertyImageRequest `json:"images"`
+// address returns the address to store
+func (r AddressRequest) address() models.Address {
+	return models.Address{
+		Street:      strings.TrimSpace(r.Street),
+		City:        strings.TrimSpace(r.City),
+		Region:      strings.TrimSpace(r.Region),
+		PostalCode:  strings.TrimSpace(r.PostalCode),
+		CountryCode: r.CountryCode,
+	}
 }
 
+// CreatePropertyImageRequest links an externally hosted image; the first one becomes the cover
 type CreatePropertyImageRequest struct {
 	ImageURL string `json:"image_url" binding:"required"`
+	Caption  string `json:"caption"`
+	AltText  string `json:"alt_text"`
 }
 
+// UpdatePropertyRequest holds a partial update; omitted fields are left unchanged
 type UpdatePropertyRequest struct {
-	Name        string                       `json:"name" binding:"required"`
-	Description string                       `json:"description" binding:"required"`
-	Location    string                       `json:"location" binding:"required"`
-	Price       float64                      `json:"price" binding:"required"`
-	Amenities   string                       `json:"amenities"`
-	OwnerID     uint                         `json:"owner_id" binding:"required"`
-	Images      []CreatePropertyImageRequest `json:"images"`
+	Name             *string                       `json:"name"`
+	Description      *string                       `json:"description"`
+	Location         *string                       `json:"location"`
+	Price            *float64                      `json:"price"`
+	MonthlyPrice     *float64                      `json:"monthly_price"`
+	MaxGuests        *int                          `json:"max_guests"`
+	NoticePeriodDays *int                          `json:"notice_period_days"`
+	Amenities        *string                       `json:"amenities"`     // Relinks the catalog entries it names unless amenity_codes is sent too
+	AmenityCodes     *[]string                     `json:"amenity_codes"` // Replaces the catalog amenities when present
+	Latitude         *float64                      `json:"latitude"`      // Location changes are geocoded unless coordinates are sent too
+	Longitude        *float64                      `json:"longitude"`
+	Language         *string                       `json:"language"`
+	Images           *[]CreatePropertyImageRequest `json:"images"` // Replaces all images when present; use the image endpoints to edit or reorder
+	// The kind of place and the stay guests can expect
+	PropertyType        *string            `json:"property_type" binding:"omitempty,oneof=apartment house villa cabin bungalow guesthouse hotel hostel other"`
+	SpaceType           *string            `json:"space_type" binding:"omitempty,oneof=entire_place private_room shared_room"`
+	HouseRules          *HouseRulesRequest `json:"house_rules"` // Replaces all house rules when present
+	CheckInInstructions *string            `json:"check_in_instructions"`
+	// Replaces the whole address when present
+	Address *AddressRequest `json:"address"`
 }
 
 // CreateProperty handles new property creation
 // @Summary Create a new property
-// @Description Create a new property with the given details
+// @Description Create a new property with the given details. It starts as a draft, hidden from guests until it is submitted for review and approved.
 // @Tags properties
 // @Accept json
 // @Produce json
@@ -137,6 +282,7 @@ type UpdatePropertyRequest struct {
 // @Success 201 {object} models.Property
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Router /properties [post]
 func (h *PropertyHandler) CreateProperty(c *gin.Context) {
 	userID, _ := c.Get("user_id") // Get user ID from context
@@ -147,25 +293,100 @@ func (h *PropertyHandler) CreateProperty(c *gin.Context) {
 		return
 	}
 
+	if req.OwnerID != 0 && req.OwnerID != userID.(uint) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Properties can only be created for yourself"})
+		return
+	}
+
+	if req.Language != "" {
+		locale, err := i18n.ParseLocale(req.Language)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": languageError("language")})
+			return
+		}
+		req.Language = locale
+	}
+
+	houseRules, err := req.HouseRules.houseRules()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	amenityList, err := amenities.Lookup(h.DB, normalizeAmenityCodes(req.AmenityCodes))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// Without codes, the catalog entries named in the free-form text are linked
+	if len(req.AmenityCodes) == 0 {
+		if amenityList, err = amenities.Named(h.DB, req.Amenities); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to look up amenities"})
+			return
+		}
+	}
+
 	property := models.Property{
-		Name:        req.Name,
-		Description: req.Description,
-		Location:    req.Location,
-		Price:       req.Price,
-		OwnerID:     userID.(uint), // Associate property with the user
+		Name:             req.Name,
+		Description:      req.Description,
+		Location:         req.Location,
+		Price:            req.Price,
+		MonthlyPrice:     req.MonthlyPrice,
+		MaxGuests:        req.MaxGuests,
+		NoticePeriodDays: req.NoticePeriodDays,
+		Amenities:        req.Amenities,
+		AmenityList:      amenityList,
+		Language:         req.Language,
+		Status:           models.ListingDraft, // Published once submitted and approved
+		OwnerID:          userID.(uint),       // Associate property with the user
+		// The kind of place and the stay guests can expect
+		PropertyType:        req.PropertyType,
+		SpaceType:           req.SpaceType,
+		HouseRules:          houseRules,
+		CheckInInstructions: strings.TrimSpace(req.CheckInInstructions),
+	}
+	if req.Address != nil {
+		property.SetAddress(req.Address.address())
+	} else {
+		property.SetAddress(locations.Address(property.Location))
+	}
+	if err := h.setCoordinates(c, &property, req.Latitude, req.Longitude); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := setPlace(h.DB, &property); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to file property location"})
+		return
+	}
+	for i, img := range req.Images {
+		property.Images = append(property.Images, models.PropertyImage{
+			ImageURL: img.ImageURL,
+			Caption:  img.Caption,
+			AltText:  img.AltText,
+			Position: i,
+			IsCover:  i == 0,
+		})
 	}
 
-	if err := h.DB.Create(&property).Error; err != nil {
+	if err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&property).Error; err != nil {
+			return err
+		}
+		return pricing.Record(tx, nil, property, property.OwnerID, pricing.SourceOwner)
+	}); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create property"})
 		return
 	}
 
+	h.reindex(c, property)
+	h.checkDuplicates(c, property.ID)
+
 	c.JSON(http.StatusCreated, property)
 }
 
 // UpdateProperty handles updating an existing property
 // @Summary Update a property
-// @Description Update a property with the given details
+// @Description Partially update a property owned by the authenticated user. Changing the name, description, location or address of a published property, or adding images, sends it back to pending_review until an admin approves it again.
 // @Tags properties
 // @Accept json
 // @Produce json
@@ -174,69 +395,184 @@ func (h *PropertyHandler) CreateProperty(c *gin.Context) {
 // @Success 200 {object} models.Property
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Router /properties/{id} [patch]
 func (h *PropertyHandler) UpdateProperty(c *gin.Context) {
+	// Ownership comes from the token, never from the request body
+	existingProperty, ok := findOwnedProperty(h.DB, c)
+	if !ok {
+		return
+	}
+
 	var req UpdatePropertyRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Get property ID from URL
-	propertyID := c.Param("id")
-
-	// Check if property exists
-	var existingProperty models.Property
-	if err := h.DB.First(&existingProperty, propertyID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Property not found"})
+	if (req.Name != nil && *req.Name == "") || (req.Price != nil && *req.Price <= 0) || (req.MaxGuests != nil && *req.MaxGuests < 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name must not be empty, price must be positive and max_guests must not be negative"})
 		return
 	}
 
-	// Verify owner exists
-	var owner models.User
-	if err := h.DB.First(&owner, req.OwnerID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Owner not found"})
-		return
+	if req.Language != nil {
+		locale, err := i18n.ParseLocale(*req.Language)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": languageError("language")})
+			return
+		}
+		req.Language = &locale
 	}
 
-	// Verify owner has permission to update this property
-	if existingProperty.OwnerID != req.OwnerID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to update this property"})
-		return
+	var houseRules models.HouseRules
+	if req.HouseRules != nil {
+		var err error
+		if houseRules, err = req.HouseRules.houseRules(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	locationChanged := req.Location != nil && *req.Location != existingProperty.Location
+
+	var amenityList []models.Amenity
+	linkAmenities := req.AmenityCodes != nil || req.Amenities != nil
+	if req.AmenityCodes != nil {
+		var err error
+		if amenityList, err = amenities.Lookup(h.DB, normalizeAmenityCodes(*req.AmenityCodes)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	} else if req.Amenities != nil {
+		// New free-form text relinks the catalog entries it names, so the two don't drift apart
+		var err error
+		if amenityList, err = amenities.Named(h.DB, *req.Amenities); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to look up amenities"})
+			return
+		}
 	}
 
+	before := *existingProperty
+
 	// Start transaction
 	tx := h.DB.Begin()
 
 	// Update property details
-	existingProperty.Name = req.Name
-	existingProperty.Description = req.Description
-	existingProperty.Location = req.Location
-	existingProperty.Price = req.Price
-	existingProperty.Amenities = req.Amenities
+	if req.Name != nil {
+		existingProperty.Name = *req.Name
+	}
+	if req.Description != nil {
+		existingProperty.Description = *req.Description
+	}
+	if req.Location != nil {
+		existingProperty.Location = *req.Location
+	}
+	if req.Price != nil {
+		existingProperty.Price = *req.Price
+	}
+	if req.Amenities != nil {
+		existingProperty.Amenities = *req.Amenities
+	}
+	if req.MonthlyPrice != nil {
+		existingProperty.MonthlyPrice = *req.MonthlyPrice
+	}
+	if req.MaxGuests != nil {
+		existingProperty.MaxGuests = *req.MaxGuests
+	}
+	if req.NoticePeriodDays != nil {
+		existingProperty.NoticePeriodDays = *req.NoticePeriodDays
+	}
+	if req.Language != nil {
+		existingProperty.Language = *req.Language
+	}
+	if req.PropertyType != nil {
+		existingProperty.PropertyType = *req.PropertyType
+	}
+	if req.SpaceType != nil {
+		existingProperty.SpaceType = *req.SpaceType
+	}
+	if req.HouseRules != nil {
+		existingProperty.HouseRules = houseRules
+	}
+	if req.CheckInInstructions != nil {
+		existingProperty.CheckInInstructions = strings.TrimSpace(*req.CheckInInstructions)
+	}
+	if req.Address != nil {
+		existingProperty.SetAddress(req.Address.address())
+	} else if locationChanged && existingProperty.Street == "" && existingProperty.PostalCode == "" {
+		// Properties without an address of their own follow their location
+		existingProperty.SetAddress(locations.Address(existingProperty.Location))
+	}
+	if req.Latitude != nil || req.Longitude != nil || locationChanged {
+		if err := h.setCoordinates(c, existingProperty, req.Latitude, req.Longitude); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if locationChanged || req.Address != nil {
+		if err := setPlace(tx, existingProperty); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to file property location"})
+			return
+		}
+	}
 
-	if err := tx.Save(&existingProperty).Error; err != nil {
+	if err := tx.Save(existingProperty).Error; err != nil {
 		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update property"})
 		return
 	}
 
-	// Delete existing images
-	if err := tx.Where("property_id = ?", existingProperty.ID).Delete(&models.PropertyImage{}).Error; err != nil {
+	if err := pricing.Record(tx, &before, *existingProperty, currentUserID(c), pricing.SourceOwner); err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update property images"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record price change"})
 		return
 	}
 
-	// Create new images
-	for _, img := range req.Images {
-		propertyImage := models.PropertyImage{
-			PropertyID: existingProperty.ID,
-			ImageURL:   img.ImageURL,
+	if linkAmenities {
+		if err := tx.Model(existingProperty).Association("AmenityList").Replace(amenityList); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update property amenities"})
+			return
 		}
-		if err := tx.Create(&propertyImage).Error; err != nil {
+	}
+
+	var replaced []models.PropertyImage
+	if req.Images != nil {
+		tx.Where("property_id = ?", existingProperty.ID).Find(&replaced)
+
+		// Delete existing images
+		if err := tx.Where("property_id = ?", existingProperty.ID).Delete(&models.PropertyImage{}).Error; err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create new property images"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update property images"})
+			return
+		}
+
+		// Create new images
+		for i, img := range *req.Images {
+			propertyImage := models.PropertyImage{
+				PropertyID: existingProperty.ID,
+				ImageURL:   img.ImageURL,
+				Caption:    img.Caption,
+				AltText:    img.AltText,
+				Position:   i,
+				IsCover:    i == 0,
+			}
+			if err := tx.Create(&propertyImage).Error; err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create new property images"})
+				return
+			}
+		}
+	}
+
+	// A new name, description or address, and new photos, go live once an admin approves them again
+	if moderation.ContentChanged(before, *existingProperty) || (req.Images != nil && addsImages(replaced, *req.Images)) {
+		if err := h.Moderator.Edited(c.Request.Context(), tx, existingProperty); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update property"})
 			return
 		}
 	}
@@ -247,18 +583,146 @@ func (h *PropertyHandler) UpdateProperty(c *gin.Context) {
 		return
 	}
 
+	// Uploaded files of replaced images are no longer referenced
+	for i := range replaced {
+		h.removeStoredImage(c.Request.Context(), &replaced[i])
+	}
+
 	// Load the updated property with images
-	h.DB.Preload("Images").First(&existingProperty, existingProperty.ID)
+	h.DB.Preload("Images", orderedImages).Preload("AmenityList").First(existingProperty, existingProperty.ID)
+
+	h.reindex(c, *existingProperty)
+	h.checkDuplicates(c, existingProperty.ID)
 
 	c.JSON(http.StatusOK, existingProperty)
 }
 
+// DeleteProperty removes a listing while keeping its booking history
+// @Summary Delete a property
+// @Description Soft-delete a property owned by the authenticated user. Refused while the property has upcoming bookings; archive it instead to stop new bookings.
+// @Tags properties
+// @Produce json
+// @Param id path int true "Property ID"
+// @Success 204
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /properties/{id} [delete]
+func (h *PropertyHandler) DeleteProperty(c *gin.Context) {
+	property, ok := findOwnedProperty(h.DB, c)
+	if !ok {
+		return
+	}
+
+	// Guests with upcoming stays must not lose their booking. The property is
+	// locked, as bookings lock it, so none is made between the check and the deletion.
+	var upcoming int64
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("deleted_at IS NULL").Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.Property{}, property.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Booking{}).
+			Where("property_id = ? AND status NOT IN ('cancelled', 'completed') AND end_date > ?", property.ID, time.Now()).
+			Count(&upcoming).Error; err != nil {
+			return err
+		}
+		if upcoming > 0 {
+			return errHasUpcomingBookings
+		}
+		return tx.Model(property).Update("deleted_at", time.Now()).Error
+	})
+	switch {
+	case errors.Is(err, errHasUpcomingBookings):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":             "Property has upcoming bookings; cancel them or archive the property instead",
+			"upcoming_bookings": upcoming,
+		})
+		return
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Property not found"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete property"})
+		return
+	}
+	if err := h.Search.Remove(c.Request.Context(), property.ID); err != nil {
+		log.Printf("Failed to remove property %d from the search index: %v", property.ID, err)
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ArchiveProperty hides a listing from guests without cancelling existing bookings
+// @Summary Archive a property
+// @Description Hide a property from listings and search and stop new bookings; existing bookings are kept
+// @Tags properties
+// @Produce json
+// @Param id path int true "Property ID"
+// @Success 200 {object} models.Property
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /properties/{id}/archive [post]
+func (h *PropertyHandler) ArchiveProperty(c *gin.Context) {
+	property, ok := findOwnedProperty(h.DB, c)
+	if !ok {
+		return
+	}
+
+	if property.ArchivedAt == nil {
+		now := time.Now()
+		property.ArchivedAt = &now
+		if err := h.DB.Model(property).Update("archived_at", now).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to archive property"})
+			return
+		}
+		h.reindex(c, *property)
+	}
+
+	c.JSON(http.StatusOK, property)
+}
+
+// RestoreProperty makes an archived or deleted listing visible again
+// @Summary Restore a property
+// @Description Restore an archived or deleted property so it can be found and booked again
+// @Tags properties
+// @Produce json
+// @Param id path int true "Property ID"
+// @Success 200 {object} models.Property
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /properties/{id}/restore [post]
+func (h *PropertyHandler) RestoreProperty(c *gin.Context) {
+	property, ok := findOwnedPropertyIncludingDeleted(h.DB, c)
+	if !ok {
+		return
+	}
+
+	if err := h.DB.Model(property).Updates(map[string]interface{}{"archived_at": nil, "deleted_at": nil}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore property"})
+		return
+	}
+	property.ArchivedAt = nil
+	property.DeletedAt = nil
+	h.reindex(c, *property)
+
+	c.JSON(http.StatusOK, property)
+}
+
 type PropertyDetailsResponse struct {
 	models.Property
 	IsAvailable       bool          `json:"is_available"`
 	NextAvailableDate *time.Time    `json:"next_available_date"`
 	BookingHistory    []BookingInfo `json:"booking_history"`
 	Statistics        BookingStats  `json:"statistics"`
+	// External calendars with their sync status and last error
+	CalendarSources []models.CalendarSource `json:"calendar_sources"`
+	// Revealed to guests once their booking is confirmed
+	CheckInInstructions string `json:"check_in_instructions"`
+	// Including the street and postal code, revealed to guests the same way
+	Address models.Address `json:"address"`
 }
 
 type BookingInfo struct {
@@ -283,42 +747,33 @@ type BookingStats struct {
 // @Accept json
 // @Produce json
 // @Param id path int true "Property ID"
-// @Param owner_id query int true "Owner ID"
 // @Success 200 {object} PropertyDetailsResponse
-// @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
-// @Router /properties/{id}/details [get]
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /properties/{id}/owner-details [get]
 func (h *PropertyHandler) GetPropertyDetailsForOwner(c *gin.Context) {
-	// Get property ID from URL
-	propertyID := c.Param("id")
-
-	// Get owner ID from query
-	ownerID := c.Query("owner_id")
-	if ownerID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "owner_id is required"})
+	// Verify owner has permission to view this property
+	owned, ok := findOwnedProperty(h.DB, c)
+	if !ok {
 		return
 	}
 
-	// Check if property exists
 	var property models.Property
-	if err := h.DB.Preload("Images").First(&property, propertyID).Error; err != nil {
+	if err := h.DB.Preload("Images", orderedImages).First(&property, owned.ID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Property not found"})
 		return
 	}
 
-	// Verify owner has permission to view this property
-	if property.OwnerID != uint(property.OwnerID) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to view this property's details"})
-		return
-	}
-
 	// Get all bookings for this property
 	var bookings []models.Booking
 	h.DB.Preload("User").Where("property_id = ?", property.ID).Find(&bookings)
 
 	// Prepare response
 	response := PropertyDetailsResponse{
-		Property: property,
+		Property:            property,
+		CheckInInstructions: property.CheckInInstructions,
+		Address:             property.Address(),
 	}
 
 	now := time.Now()
@@ -333,7 +788,7 @@ func (h *PropertyHandler) GetPropertyDetailsForOwner(c *gin.Context) {
 		// Add to booking history
 		bookingInfo := BookingInfo{
 			ID:         booking.ID,
-			GuestName:  booking.User.Name,
+			GuestName:  booking.Guest(),
 			StartDate:  booking.StartDate,
 			EndDate:    booking.EndDate,
 			Status:     booking.Status,
@@ -366,5 +821,78 @@ func (h *PropertyHandler) GetPropertyDetailsForOwner(c *gin.Context) {
 	response.BookingHistory = bookingHistory
 	response.Statistics = stats
 
+	// Include imported calendars so sync problems are visible to the owner
+	response.CalendarSources = make([]models.CalendarSource, 0)
+	h.DB.Where("property_id = ?", property.ID).Find(&response.CalendarSources)
+
 	c.JSON(http.StatusOK, response)
 }
+
+// setCoordinates stores the coordinates sent by the owner, or geocodes the
+// property's location when none were sent. A location the geocoder doesn't know
+// leaves the property without coordinates rather than failing the request.
+func (h *PropertyHandler) setCoordinates(c *gin.Context, property *models.Property, lat, lng *float64) error {
+	if lat != nil || lng != nil {
+		if lat == nil || lng == nil || !(geo.Point{Lat: *lat, Lng: *lng}).Valid() {
+			return errors.New("latitude and longitude must both be valid coordinates")
+		}
+		property.Latitude, property.Longitude = lat, lng
+		return nil
+	}
+
+	property.Latitude, property.Longitude = nil, nil
+	point, err := h.Geocoder.Geocode(c.Request.Context(), property.Location)
+	if err != nil {
+		if !errors.Is(err, geo.ErrNotFound) {
+			log.Printf("Failed to geocode %q: %v", property.Location, err)
+		}
+		return nil
+	}
+	property.Latitude, property.Longitude = &point.Lat, &point.Lng
+	return nil
+}
+
+// setPlace files the property under the place its address, or else its
+// location, names, so location autocomplete and place_id searches find it
+func setPlace(db *gorm.DB, property *models.Property) error {
+	place, err := locations.ResolveProperty(db, *property)
+	if err != nil {
+		return err
+	}
+	property.PlaceID, property.Place = nil, nil
+	if place != nil {
+		property.PlaceID = &place.ID
+	}
+	return nil
+}
+
+// reindex brings the search index up to date with a saved property. The
+// database stays authoritative, so failures are only logged: rebuilding the
+// index catches up.
+func (h *PropertyHandler) reindex(c *gin.Context, property models.Property) {
+	if err := h.Search.Index(c.Request.Context(), property); err != nil {
+		log.Printf("Failed to update the search index for property %d: %v", property.ID, err)
+	}
+}
+
+// checkDuplicates flags a saved property when it looks like another listing.
+// Failures are only logged: the next save checks it again.
+func (h *PropertyHandler) checkDuplicates(c *gin.Context, propertyID uint) {
+	if _, err := h.Duplicates.Check(c.Request.Context(), propertyID); err != nil {
+		log.Printf("Failed to check property %d for duplicates: %v", propertyID, err)
+	}
+}
+
+// addsImages reports whether a new image list has any image the old one didn't
+func addsImages(old []models.PropertyImage, images []CreatePropertyImageRequest) bool {
+	urls := make(map[string]bool, len(old))
+	for _, img := range old {
+		urls[img.ImageURL] = true
+	}
+	for _, img := range images {
+		if !urls[img.ImageURL] {
+			return true
+		}
+	}
+	return false
+}
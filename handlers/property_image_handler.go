@@ -0,0 +1,389 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+
+	"github.com/bookaroo/bookaroo-platform-be/media"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxImagesPerUpload caps how many files a single upload request may carry
+const maxImagesPerUpload = 10
+
+// UpdatePropertyImageRequest edits one image; omitted fields are left unchanged
+type UpdatePropertyImageRequest struct {
+	Caption *string `json:"caption"`
+	AltText *string `json:"alt_text"`
+	IsCover *bool   `json:"is_cover"` // Only true is accepted; it moves the cover to this image
+}
+
+// ReorderPropertyImagesRequest lists every image of the property in its new display order
+type ReorderPropertyImagesRequest struct {
+	ImageIDs []uint `json:"image_ids" binding:"required"`
+}
+
+// UploadPropertyImages handles multipart photo uploads for a property
+// @Summary Upload property images
+// @Description Upload up to 10 JPEG, PNG or WebP photos (10 MB each) in the "images" form field. Each photo is stored as resized JPEG variants, plus WebP copies of the medium and thumbnail sizes when a WebP encoder is configured, with EXIF metadata removed. A published property goes back to pending_review until an admin approves the new photos.
+// @Tags properties
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "Property ID"
+// @Param images formData file true "Image files"
+// @Success 201 {array} models.PropertyImage
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Router /properties/{id}/images [post]
+func (h *PropertyHandler) UploadPropertyImages(c *gin.Context) {
+	property, ok := findOwnedProperty(h.DB, c)
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImagesPerUpload*media.MaxUploadSize+1<<20)
+	form, err := c.MultipartForm()
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Upload is too large"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Expected a multipart form with an images field"})
+		return
+	}
+
+	files := form.File["images"]
+	if len(files) == 0 || len(files) > maxImagesPerUpload {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Upload between 1 and %d images", maxImagesPerUpload)})
+		return
+	}
+
+	// Validate and process every file before storing anything
+	results := make([]*media.Result, len(files))
+	for i, file := range files {
+		if file.Size > media.MaxUploadSize {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("%s: %s", file.Filename, media.ErrTooLarge)})
+			return
+		}
+		f, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read upload"})
+			return
+		}
+		data, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read upload"})
+			return
+		}
+
+		results[i], err = media.Process(data, h.WebP)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s: %s", file.Filename, err)})
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	images := make([]models.PropertyImage, 0, len(results))
+	for _, result := range results {
+		image, err := h.storeImage(ctx, property.ID, result)
+		if err != nil {
+			for i := range images {
+				h.removeStoredImage(ctx, &images[i])
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store image"})
+			return
+		}
+		images = append(images, *image)
+	}
+
+	// New photos go after the existing ones; the first photo of a property becomes its cover
+	var existing struct {
+		MaxPosition int
+		Covers      int
+	}
+	h.DB.Model(&models.PropertyImage{}).
+		Select("COALESCE(MAX(position), -1) AS max_position, COUNT(*) FILTER (WHERE is_cover) AS covers").
+		Where("property_id = ?", property.ID).
+		Scan(&existing)
+	for i := range images {
+		images[i].Position = existing.MaxPosition + 1 + i
+		images[i].IsCover = existing.Covers == 0 && i == 0
+	}
+
+	// New photos of a published listing are reviewed before guests see them
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&images).Error; err != nil {
+			return err
+		}
+		return h.Moderator.Edited(ctx, tx, property)
+	})
+	if err != nil {
+		for i := range images {
+			h.removeStoredImage(ctx, &images[i])
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save images"})
+		return
+	}
+	h.reindex(c, *property)
+	h.checkDuplicates(c, property.ID)
+
+	c.JSON(http.StatusCreated, images)
+}
+
+// DeletePropertyImage removes an image and its stored files
+// @Summary Delete a property image
+// @Description Remove an image from a property owned by the authenticated user, including any uploaded files
+// @Tags properties
+// @Param id path int true "Property ID"
+// @Param image_id path int true "Image ID"
+// @Success 204
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /properties/{id}/images/{image_id} [delete]
+func (h *PropertyHandler) DeletePropertyImage(c *gin.Context) {
+	property, ok := findOwnedProperty(h.DB, c)
+	if !ok {
+		return
+	}
+
+	image, ok := h.findPropertyImage(c, property.ID)
+	if !ok {
+		return
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(image).Error; err != nil {
+			return err
+		}
+		if !image.IsCover {
+			return nil
+		}
+
+		// Hand the cover to the next image in display order
+		var next models.PropertyImage
+		err := orderedImages(tx).Where("property_id = ?", property.ID).First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Model(&next).Update("is_cover", true).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete image"})
+		return
+	}
+	h.removeStoredImage(c.Request.Context(), image)
+
+	c.Status(http.StatusNoContent)
+}
+
+// UpdatePropertyImage edits the caption, alt text or cover flag of one image
+// @Summary Update a property image
+// @Description Edit an image's caption and alt text, or make it the property's cover. The change is shown right away.
+// @Tags properties
+// @Accept json
+// @Produce json
+// @Param id path int true "Property ID"
+// @Param image_id path int true "Image ID"
+// @Param image body UpdatePropertyImageRequest true "Image details"
+// @Success 200 {object} models.PropertyImage
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /properties/{id}/images/{image_id} [patch]
+func (h *PropertyHandler) UpdatePropertyImage(c *gin.Context) {
+	property, ok := findOwnedProperty(h.DB, c)
+	if !ok {
+		return
+	}
+
+	image, ok := h.findPropertyImage(c, property.ID)
+	if !ok {
+		return
+	}
+
+	var req UpdatePropertyImageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.IsCover != nil && !*req.IsCover && image.IsCover {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Make another image the cover instead"})
+		return
+	}
+
+	if req.Caption != nil {
+		image.Caption = *req.Caption
+	}
+	if req.AltText != nil {
+		image.AltText = *req.AltText
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if req.IsCover != nil && *req.IsCover && !image.IsCover {
+			if err := tx.Model(&models.PropertyImage{}).
+				Where("property_id = ? AND is_cover", property.ID).
+				Update("is_cover", false).Error; err != nil {
+				return err
+			}
+			image.IsCover = true
+		}
+		// Captions and the cover go live right away; only new photos are reviewed
+		return tx.Save(image).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update image"})
+		return
+	}
+	h.reindex(c, *property)
+
+	c.JSON(http.StatusOK, image)
+}
+
+// ReorderPropertyImages sets the display order of a property's images
+// @Summary Reorder property images
+// @Description Set the display order of all images of a property. Every image ID must be listed exactly once.
+// @Tags properties
+// @Accept json
+// @Produce json
+// @Param id path int true "Property ID"
+// @Param order body ReorderPropertyImagesRequest true "Image IDs in display order"
+// @Success 200 {array} models.PropertyImage
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /properties/{id}/images/order [put]
+func (h *PropertyHandler) ReorderPropertyImages(c *gin.Context) {
+	property, ok := findOwnedProperty(h.DB, c)
+	if !ok {
+		return
+	}
+
+	var req ReorderPropertyImagesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var images []models.PropertyImage
+	if err := h.DB.Where("property_id = ?", property.ID).Find(&images).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch images"})
+		return
+	}
+
+	// A partial order would leave positions ambiguous
+	position := make(map[uint]int, len(req.ImageIDs))
+	for i, id := range req.ImageIDs {
+		position[id] = i
+	}
+	valid := len(position) == len(req.ImageIDs) && len(req.ImageIDs) == len(images)
+	for _, img := range images {
+		if _, listed := position[img.ID]; !listed {
+			valid = false
+		}
+	}
+	if !valid {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "image_ids must list every image of the property exactly once"})
+		return
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		for i := range images {
+			images[i].Position = position[images[i].ID]
+			if err := tx.Model(&images[i]).Update("position", images[i].Position).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reorder images"})
+		return
+	}
+
+	sort.Slice(images, func(i, j int) bool { return images[i].Position < images[j].Position })
+	c.JSON(http.StatusOK, images)
+}
+
+// findPropertyImage loads the image from the :image_id path parameter, writing a 404 when it isn't on the property
+func (h *PropertyHandler) findPropertyImage(c *gin.Context, propertyID uint) (*models.PropertyImage, bool) {
+	var image models.PropertyImage
+	if err := h.DB.Where("id = ? AND property_id = ?", c.Param("image_id"), propertyID).First(&image).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Image not found"})
+		return nil, false
+	}
+	return &image, true
+}
+
+// storeImage writes the processed variants under a fresh key and returns the unsaved image record
+func (h *PropertyHandler) storeImage(ctx context.Context, propertyID uint, result *media.Result) (*models.PropertyImage, error) {
+	token, err := generateToken(12)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := int64(result.PerceptualHash)
+	image := &models.PropertyImage{
+		PropertyID:     propertyID,
+		StorageKey:     fmt.Sprintf("properties/%d/%s", propertyID, token),
+		Width:          result.Width,
+		Height:         result.Height,
+		PerceptualHash: &hash,
+	}
+	for _, v := range result.Variants {
+		key := image.StorageKey + "/" + v.Name
+		if err := h.Storage.Put(ctx, key, bytes.NewReader(v.Data), v.ContentType); err != nil {
+			h.removeStoredImage(ctx, image)
+			return nil, err
+		}
+
+		url := h.Storage.URL(key)
+		switch v.Name {
+		case "large.jpg":
+			image.ImageURL = url
+		case "medium.jpg":
+			image.MediumURL = url
+		case "medium.webp":
+			image.MediumWebPURL = url
+		case "thumb.jpg":
+			image.ThumbnailURL = url
+		case "thumb.webp":
+			image.ThumbnailWebPURL = url
+		}
+	}
+
+	return image, nil
+}
+
+// removeStoredImage deletes the uploaded files behind an image. Failures are
+// only logged: the database row is already gone and an orphaned file is harmless.
+func (h *PropertyHandler) removeStoredImage(ctx context.Context, image *models.PropertyImage) {
+	if image.StorageKey == "" {
+		return
+	}
+	for _, name := range media.VariantNames() {
+		if err := h.Storage.Delete(ctx, image.StorageKey+"/"+name); err != nil {
+			log.Printf("Failed to delete stored image %s/%s: %v", image.StorageKey, name, err)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/moderation"
+	"github.com/bookaroo/bookaroo-platform-be/pagination"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ReviewRequest gives the reason for rejecting or suspending a listing
+type ReviewRequest struct {
+	Reason string `json:"reason" binding:"required"` // Shown to the owner, e.g. "Photos don't show the property"
+}
+
+// listingStatuses are the values accepted for the status filter of the review queue
+var listingStatuses = map[string]bool{
+	models.ListingDraft:         true,
+	models.ListingPendingReview: true,
+	models.ListingPublished:     true,
+	models.ListingSuspended:     true,
+}
+
+// SubmitProperty asks for a draft or suspended listing to be reviewed
+// @Summary Submit a property for review
+// @Description Submit a draft, rejected or suspended property for review. It is shown to guests once an admin approves it. The name, description, location and price must be filled in.
+// @Tags properties
+// @Produce json
+// @Param id path int true "Property ID"
+// @Success 200 {object} models.Property
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /properties/{id}/submit [post]
+func (h *PropertyHandler) SubmitProperty(c *gin.Context) {
+	property, ok := findOwnedProperty(h.DB, c)
+	if !ok {
+		return
+	}
+
+	if err := h.Moderator.Submit(c.Request.Context(), property); err != nil {
+		moderationError(c, err, "Failed to submit property for review")
+		return
+	}
+
+	c.JSON(http.StatusOK, property)
+}
+
+// ListPropertiesForReview returns the listings in a moderation status, oldest first
+// @Summary List properties for review
+// @Description Retrieve a page of properties in a listing status, pending_review by default, oldest first (admin only). Pass meta.next_cursor back as cursor to get the next page.
+// @Tags admin
+// @Produce json
+// @Param status query string false "draft, pending_review, published or suspended (default pending_review)"
+// @Param limit query int false "Properties per page (default 20, max 100)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Success 200 {object} PropertyListResponse
+// @Failure 400 {object} map[string]string
+// @Router /admin/properties [get]
+func (h *PropertyHandler) ListPropertiesForReview(c *gin.Context) {
+	status := c.DefaultQuery("status", models.ListingPendingReview)
+	if !listingStatuses[status] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be draft, pending_review, published or suspended"})
+		return
+	}
+	page, err := pagination.Parse(c.Query("limit"), c.Query("cursor"), "")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := h.DB.Model(&models.Property{}).Where("properties.status = ? AND properties.deleted_at IS NULL", status)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching properties"})
+		return
+	}
+
+	properties := []models.Property{}
+	if err := keysetPage(query.Session(&gorm.Session{}), "", nil, "properties.id", false, page).
+		Preload("Images", orderedImages).Preload("AmenityList").Preload("Owner").
+		Find(&properties).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching properties"})
+		return
+	}
+
+	var response PropertyListResponse
+	response.Properties, response.Meta = pagination.Trim(properties, page, total, propertyCursor(""))
+	c.JSON(http.StatusOK, response)
+}
+
+// ApproveProperty publishes a listing waiting for review
+// @Summary Approve a property
+// @Description Publish a property waiting for review so guests can find and book it; its owner is notified (admin only)
+// @Tags admin
+// @Produce json
+// @Param id path int true "Property ID"
+// @Success 200 {object} models.Property
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/properties/{id}/approve [post]
+func (h *PropertyHandler) ApproveProperty(c *gin.Context) {
+	h.review(c, func(ctx context.Context, property *models.Property, _ string) error {
+		return h.Moderator.Approve(ctx, property, currentUserID(c))
+	}, false)
+}
+
+// RejectProperty sends a listing waiting for review back to its owner
+// @Summary Reject a property
+// @Description Send a property waiting for review back to draft with the reason, which its owner is notified of (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Property ID"
+// @Param review body ReviewRequest true "Reason for the rejection"
+// @Success 200 {object} models.Property
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/properties/{id}/reject [post]
+func (h *PropertyHandler) RejectProperty(c *gin.Context) {
+	h.review(c, func(ctx context.Context, property *models.Property, reason string) error {
+		return h.Moderator.Reject(ctx, property, currentUserID(c), reason)
+	}, true)
+}
+
+// SuspendProperty hides a published listing until its owner resubmits it
+// @Summary Suspend a property
+// @Description Hide a published property from guests with the reason, which its owner is notified of. Existing bookings are kept; the owner can submit the property for review again (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Property ID"
+// @Param review body ReviewRequest true "Reason for the suspension"
+// @Success 200 {object} models.Property
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/properties/{id}/suspend [post]
+func (h *PropertyHandler) SuspendProperty(c *gin.Context) {
+	h.review(c, func(ctx context.Context, property *models.Property, reason string) error {
+		return h.Moderator.Suspend(ctx, property, currentUserID(c), reason)
+	}, true)
+}
+
+// review loads the property from the :id path parameter, applies an admin's
+// decision to it and brings the search index up to date
+func (h *PropertyHandler) review(c *gin.Context, decide func(context.Context, *models.Property, string) error, needsReason bool) {
+	var req ReviewRequest
+	if needsReason {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var property models.Property
+	if err := h.DB.Where("deleted_at IS NULL").First(&property, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Property not found"})
+		return
+	}
+
+	if err := decide(c.Request.Context(), &property, req.Reason); err != nil {
+		moderationError(c, err, "Failed to review property")
+		return
+	}
+	h.reindex(c, property)
+
+	c.JSON(http.StatusOK, property)
+}
+
+// moderationError writes the response for an error from the moderator
+func moderationError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, moderation.ErrIncomplete), errors.Is(err, moderation.ErrReasonRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, moderation.ErrInvalidTransition):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		log.Printf("%s: %v", message, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/pagination"
+	"github.com/bookaroo/bookaroo-platform-be/pricing"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// PriceHistoryResponse is a page of the price changes of a property
+type PriceHistoryResponse struct {
+	Changes []models.PriceChange `json:"changes"`
+	Meta    pagination.Meta      `json:"meta"`
+}
+
+// GetPriceHistory lists the price changes of one of the owner's properties
+// @Summary Get price history
+// @Description Retrieve a page of the changes to the nightly and monthly prices of a property, newest first, with who made them. Prices set before history was recorded appear as changes from the backfill source. Pass meta.next_cursor back as cursor to get the next page (property owner only).
+// @Tags properties
+// @Produce json
+// @Param id path int true "Property ID"
+// @Param field query string false "Only changes to this price: price or monthly_price"
+// @Param limit query int false "Changes per page (default 20, max 100)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Success 200 {object} PriceHistoryResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /properties/{id}/price-history [get]
+func (h *PropertyHandler) GetPriceHistory(c *gin.Context) {
+	property, ok := findOwnedProperty(h.DB, c)
+	if !ok {
+		return
+	}
+
+	page, err := pagination.Parse(c.Query("limit"), c.Query("cursor"), "")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := h.DB.Model(&models.PriceChange{}).Where("property_id = ?", property.ID)
+	switch field := c.Query("field"); field {
+	case "":
+	case pricing.FieldPrice, pricing.FieldMonthlyPrice:
+		query = query.Where("field = ?", field)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "field must be price or monthly_price"})
+		return
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching price history"})
+		return
+	}
+
+	changes := []models.PriceChange{}
+	if err := keysetPage(query.Session(&gorm.Session{}), "", nil, "price_changes.id", true, page).
+		Find(&changes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching price history"})
+		return
+	}
+
+	response := PriceHistoryResponse{}
+	response.Changes, response.Meta = pagination.Trim(changes, page, total, func(pc models.PriceChange) pagination.Cursor {
+		return pagination.Cursor{ID: pc.ID}
+	})
+	c.JSON(http.StatusOK, response)
+}
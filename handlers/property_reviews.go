@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/pagination"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const maxReviewLength = 2000
+
+var errAlreadyReviewed = errors.New("booking already reviewed")
+
+type CreateReviewRequest struct {
+	Rating  int    `json:"rating" binding:"required"` // 1 to 5 stars
+	Comment string `json:"comment"`
+}
+
+// ReviewListResponse is a page of the reviews of a property
+type ReviewListResponse struct {
+	Reviews []models.Review `json:"reviews"`
+	Meta    pagination.Meta `json:"meta"`
+}
+
+// CreateReview rates a stay once it is over
+// @Summary Review a stay
+// @Description Rate a confirmed or completed stay from 1 to 5 stars, with an optional comment, once its end date has passed. Each booking can be reviewed once, by its guest. The property's rating_average and rating_count are updated right away.
+// @Tags bookings
+// @Accept json
+// @Produce json
+// @Param id path int true "Booking ID"
+// @Param review body CreateReviewRequest true "Rating and comment"
+// @Success 201 {object} models.Review
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /bookings/{id}/review [post]
+func (h *BookingHandler) CreateReview(c *gin.Context) {
+	userID := currentUserID(c)
+
+	var req CreateReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Comment = strings.TrimSpace(req.Comment)
+	if req.Rating < 1 || req.Rating > 5 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "rating must be between 1 and 5"})
+		return
+	}
+	if len(req.Comment) > maxReviewLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "comment is too long"})
+		return
+	}
+
+	var booking models.Booking
+	if err := h.DB.First(&booking, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Booking not found"})
+		return
+	}
+	if !booking.IsGuest(userID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the guest can review this stay"})
+		return
+	}
+	if (booking.Status != "confirmed" && booking.Status != "completed") || booking.EndDate.After(time.Now()) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Stays can be reviewed once they are over"})
+		return
+	}
+
+	review := models.Review{
+		BookingID:  booking.ID,
+		PropertyID: booking.PropertyID,
+		UserID:     userID,
+		Rating:     req.Rating,
+		Comment:    req.Comment,
+	}
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the property so concurrent reviews don't compute the average from stale rows
+		var property models.Property
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&property, booking.PropertyID).Error; err != nil {
+			return err
+		}
+		var existing int64
+		if err := tx.Model(&models.Review{}).Where("booking_id = ?", booking.ID).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return errAlreadyReviewed
+		}
+		if err := tx.Create(&review).Error; err != nil {
+			return err
+		}
+
+		var stats struct {
+			Average float64
+			Count   int
+		}
+		if err := tx.Model(&models.Review{}).Select("COALESCE(AVG(rating), 0) AS average, COUNT(*) AS count").
+			Where("property_id = ?", booking.PropertyID).Scan(&stats).Error; err != nil {
+			return err
+		}
+		return tx.Model(&property).Updates(map[string]interface{}{
+			"rating_average": stats.Average,
+			"rating_count":   stats.Count,
+		}).Error
+	})
+	if errors.Is(err, errAlreadyReviewed) {
+		c.JSON(http.StatusConflict, gin.H{"error": "This stay has already been reviewed"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save review"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, review)
+}
+
+// ListReviews returns the reviews guests left for a property
+// @Summary List property reviews
+// @Description Retrieve a page of the reviews of a listed property, newest first. Pass meta.next_cursor back as cursor to get the next page.
+// @Tags properties
+// @Produce json
+// @Param id path int true "Property ID"
+// @Param limit query int false "Reviews per page (default 20, max 100)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Success 200 {object} ReviewListResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /properties/{id}/reviews [get]
+func (h *PropertyHandler) ListReviews(c *gin.Context) {
+	var property models.Property
+	if err := listedProperties(h.DB).First(&property, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Property not found"})
+		return
+	}
+
+	page, err := pagination.Parse(c.Query("limit"), c.Query("cursor"), "")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := ReviewListResponse{Reviews: []models.Review{}}
+	if err := keysetPage(h.DB.Where("property_id = ?", property.ID), "", nil, "reviews.id", true, page).
+		Find(&response.Reviews).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching reviews"})
+		return
+	}
+
+	response.Reviews, response.Meta = pagination.Trim(response.Reviews, page, int64(property.RatingCount), func(r models.Review) pagination.Cursor {
+		return pagination.Cursor{ID: r.ID}
+	})
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,330 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/bookaroo/bookaroo-platform-be/filters"
+	"github.com/bookaroo/bookaroo-platform-be/geo"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/pagination"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultSuggestions = 5
+	maxSuggestions     = 10
+)
+
+// AmenityFacet counts the search results offering one amenity
+type AmenityFacet struct {
+	Code     string `json:"code"`
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	Count    int64  `json:"count"`
+}
+
+// SearchFacets summarises the search results for narrowing them further
+type SearchFacets struct {
+	Amenities []AmenityFacet `json:"amenities"`
+}
+
+// SearchResult is a property matching a search
+type SearchResult struct {
+	models.Property
+	DistanceKm *float64 `json:"distance_km,omitempty"` // From the search center, when one was given
+	Nights     int      `json:"nights,omitempty"`      // Length of the stay, when dates were given
+	TotalPrice *float64 `json:"total_price,omitempty"` // Price of the whole stay, when dates were given
+	Relevance  *float64 `json:"relevance,omitempty"`   // How well the property matches q, when given
+	Snippet    string   `json:"snippet,omitempty"`     // HTML excerpt of the description with matches in <mark>
+}
+
+// SearchResponse is the result of a property search
+type SearchResponse struct {
+	Properties []SearchResult  `json:"properties"`
+	Facets     SearchFacets    `json:"facets"`
+	Meta       pagination.Meta `json:"meta"`
+}
+
+// PropertySuggestion completes the text typed into the search box
+type PropertySuggestion struct {
+	ID       uint   `json:"id"`
+	Name     string `json:"name"`
+	Location string `json:"location"`
+}
+
+// searchRow is one ranked match before the full property is loaded
+type searchRow struct {
+	ID            uint
+	Price         float64
+	RatingAverage float64
+	DistanceKm    *float64
+	TotalPrice    *float64
+	Relevance     *float64
+}
+
+// SearchProperties handles property search with various filters
+// @Summary Search properties
+// @Description Search for properties based on criteria. Free text in q is matched against names, then cities and regions, then descriptions, ranked by relevance, with highlighted description snippets. With check_in and check_out, only properties free for the whole stay are returned, each with the total price of the stay. Geographic search takes a center (lat/lng or a place name in near) with radius_km, or a map viewport in bbox. The response includes facet counts of the amenities offered by the matching properties. Results are paged; pass meta.next_cursor back as cursor, with the same sort, to get the next page.
+// @Tags properties
+// @Accept json
+// @Produce json
+// @Param q query string false "Free text matched against names, cities, regions and descriptions, e.g. beach villa with pool"
+// @Param lang query string false "Language of q, e.g. fr (default en)"
+// @Param location query string false "City or region to search, matching part of the name"
+// @Param city query string false "City, matched exactly"
+// @Param region query string false "Region, matched exactly"
+// @Param place_id query int false "Place from location autocomplete; matches properties in it or anywhere within it"
+// @Param min_price query number false "Minimum nightly price"
+// @Param max_price query number false "Maximum nightly price"
+// @Param amenities query string false "Comma-separated amenity codes that must all be offered, e.g. pool,wifi"
+// @Param lat query number false "Latitude of the search center"
+// @Param lng query number false "Longitude of the search center"
+// @Param near query string false "Place name to use as the search center, e.g. Ubud"
+// @Param radius_km query number false "Radius around the center in kilometres (default 25, max 500)"
+// @Param bbox query string false "Map viewport as west,south,east,north"
+// @Param check_in query string false "Check-in date (YYYY-MM-DD); only properties free for the whole stay are returned"
+// @Param check_out query string false "Check-out date (YYYY-MM-DD)"
+// @Param guests query int false "Number of guests"
+// @Param property_type query string false "Comma-separated property types, e.g. villa,house"
+// @Param space_type query string false "Comma-separated space types: entire_place, private_room or shared_room"
+// @Param sort query string false "relevance (default with q), distance, newest, price_asc, price_desc or rating (highest rated first)"
+// @Param limit query int false "Properties per page (default 20, max 100)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Success 200 {object} SearchResponse
+// @Failure 400 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /properties/search [get]
+func (h *PropertyHandler) SearchProperties(c *gin.Context) {
+	criteria, err := filters.Parse(c.Request.Context(), c.Request.URL.Query(), currentUserID(c), h.Geocoder, h.Search)
+	var invalid *filters.InvalidError
+	if errors.As(err, &invalid) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, geo.ErrUnavailable) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Places can't be looked up right now, try again later"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error searching properties"})
+		return
+	}
+	query := criteria.Apply(listedProperties(h.DB.Model(&models.Property{})))
+
+	// Matches for free text are ranked by relevance unless sorted otherwise
+	sortName := c.Query("sort")
+	if sortName == "" && criteria.Text != "" {
+		sortName = "relevance"
+	}
+	sortKey, sortArgs, desc := "", []interface{}(nil), false
+	switch sortName {
+	case "relevance":
+		if criteria.Text == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "sort=relevance needs q"})
+			return
+		}
+		sortKey, sortArgs, desc = criteria.Match.Rank, criteria.Match.RankArgs, true
+	case "distance":
+		if criteria.Center == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "sort=distance needs lat/lng or near"})
+			return
+		}
+		sortKey, sortArgs = filters.DistanceSQL(*criteria.Center)
+	default:
+		sort, ok := propertySorts[sortName]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be relevance, distance, newest, price_asc, price_desc or rating"})
+			return
+		}
+		sortKey, desc = sort.key, sort.desc
+	}
+	page, err := pagination.Parse(c.Query("limit"), c.Query("cursor"), sortName)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error searching properties"})
+		return
+	}
+
+	// Rank one page of matches, then load the full properties for the ranked IDs
+	columns, args := "properties.id, properties.price, properties.rating_average", []interface{}{}
+	if criteria.Center != nil {
+		distance, distanceArgs := filters.DistanceSQL(*criteria.Center)
+		columns += ", " + distance + " AS distance_km"
+		args = append(args, distanceArgs...)
+	}
+	if criteria.Nights > 0 {
+		columns += ", properties.price * ? AS total_price"
+		args = append(args, criteria.Nights)
+	}
+	if criteria.Text != "" {
+		columns += ", " + criteria.Match.Rank + " AS relevance"
+		args = append(args, criteria.Match.RankArgs...)
+	}
+	ranked := keysetPage(query.Session(&gorm.Session{}).Select(columns, args...), sortKey, sortArgs, "properties.id", desc, page)
+
+	var rows []searchRow
+	if err := ranked.Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error searching properties"})
+		return
+	}
+	rows, meta := pagination.Trim(rows, page, total, func(row searchRow) pagination.Cursor {
+		switch {
+		case sortName == "relevance":
+			return pagination.Cursor{Key: *row.Relevance, ID: row.ID}
+		case sortName == "distance":
+			return pagination.Cursor{Key: *row.DistanceKm, ID: row.ID}
+		case sortName == "rating":
+			return pagination.Cursor{Key: row.RatingAverage, ID: row.ID}
+		case sortKey != "":
+			return pagination.Cursor{Key: row.Price, ID: row.ID}
+		}
+		return pagination.Cursor{ID: row.ID}
+	})
+
+	response := SearchResponse{Properties: []SearchResult{}, Facets: SearchFacets{Amenities: []AmenityFacet{}}, Meta: meta}
+	if response.Properties, err = h.loadSearchResults(rows, criteria.Nights); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error searching properties"})
+		return
+	}
+	if err := h.addSnippets(c, response.Properties, criteria.Text, criteria.Lang); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error searching properties"})
+		return
+	}
+	favorites := make([]*models.Property, len(response.Properties))
+	for i := range response.Properties {
+		favorites[i] = &response.Properties[i].Property
+	}
+	if err := markFavorited(h.DB, c, favorites); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error searching properties"})
+		return
+	}
+	conceal(favorites)
+
+	if err := h.DB.Table("amenities a").
+		Select("a.code, a.name, a.category, COUNT(DISTINCT pa.property_id) AS count").
+		Joins("JOIN property_amenities pa ON pa.amenity_id = a.id").
+		Where("pa.property_id IN (?)", query.Session(&gorm.Session{}).Select("properties.id")).
+		Group("a.id").
+		Order("count DESC, a.name").
+		Scan(&response.Facets.Amenities).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error counting amenities"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// SuggestProperties completes the text typed into the search box with matching properties
+// @Summary Suggest properties as the user types
+// @Description Returns properties whose name, city, region or description match q, treating its last word as unfinished, best first
+// @Tags properties
+// @Produce json
+// @Param q query string true "Text typed so far, e.g. beach vil"
+// @Param limit query int false "Number of suggestions (default 5, max 10)"
+// @Success 200 {object} map[string][]PropertySuggestion
+// @Failure 400 {object} map[string]string
+// @Router /properties/suggest [get]
+func (h *PropertyHandler) SuggestProperties(c *gin.Context) {
+	text := strings.TrimSpace(c.Query("q"))
+	if text == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+	limit := defaultSuggestions
+	if l := c.Query("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be a number between 1 and %d", maxSuggestions)})
+			return
+		}
+		limit = min(n, maxSuggestions)
+	}
+
+	ids, err := h.Search.Suggest(c.Request.Context(), text, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error suggesting properties"})
+		return
+	}
+
+	var found []models.Property
+	if len(ids) > 0 {
+		if err := listedProperties(h.DB).Select("id, name, location, city, region").
+			Where("id IN ?", ids).Find(&found).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error suggesting properties"})
+			return
+		}
+	}
+
+	// Keep the backend's order
+	byID := make(map[uint]PropertySuggestion, len(found))
+	for _, p := range found {
+		byID[p.ID] = PropertySuggestion{ID: p.ID, Name: p.Name, Location: p.PublicLocation()}
+	}
+	suggestions := make([]PropertySuggestion, 0, len(found))
+	for _, id := range ids {
+		if s, ok := byID[id]; ok {
+			suggestions = append(suggestions, s)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"suggestions": suggestions})
+}
+
+// loadSearchResults loads the ranked properties with their cover image, keeping the rank order
+func (h *PropertyHandler) loadSearchResults(rows []searchRow, nights int) ([]SearchResult, error) {
+	results := make([]SearchResult, 0, len(rows))
+	if len(rows) == 0 {
+		return results, nil
+	}
+
+	ids := make([]uint, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+	var properties []models.Property
+	if err := h.DB.Preload("Images", coverImage).Preload("AmenityList").Preload("Owner", publicOwner).
+		Where("id IN ?", ids).Find(&properties).Error; err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uint]models.Property, len(properties))
+	for _, p := range properties {
+		byID[p.ID] = p
+	}
+	for _, row := range rows {
+		if p, ok := byID[row.ID]; ok {
+			results = append(results, SearchResult{Property: p, DistanceKm: row.DistanceKm, Nights: nights, TotalPrice: row.TotalPrice, Relevance: row.Relevance})
+		}
+	}
+	return results, nil
+}
+
+// addSnippets adds excerpts of the results' descriptions with the words matching text highlighted
+func (h *PropertyHandler) addSnippets(c *gin.Context, results []SearchResult, text, lang string) error {
+	if text == "" || len(results) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(results))
+	for i, r := range results {
+		ids[i] = r.ID
+	}
+	snippets, err := h.Search.Snippets(c.Request.Context(), ids, text, lang)
+	if err != nil {
+		return err
+	}
+	for i := range results {
+		results[i].Snippet = snippets[results[i].ID]
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/bookaroo/bookaroo-platform-be/filters"
+	"github.com/bookaroo/bookaroo-platform-be/geo"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/recommend"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultSimilar = 6
+	maxSimilar     = 20
+	// similarCandidates is how many of the nearest, or closest priced, properties are scored
+	similarCandidates = 200
+)
+
+// SimilarProperty is a property recommended alongside another
+type SimilarProperty struct {
+	models.Property
+	Score float64 `json:"score"` // Similarity to the viewed property, from 0 to 1
+}
+
+// SimilarProperties recommends properties like the one being viewed
+// @Summary Get similar properties
+// @Description Recommend other published properties like this one, most similar first, weighing how close they are, their price, the amenities they share and how many guests they take
+// @Tags properties
+// @Produce json
+// @Param id path int true "Property ID"
+// @Param limit query int false "Number of properties (default 6, max 20)"
+// @Param lang query string false "Locale to show listings in, e.g. id; overrides Accept-Language"
+// @Success 200 {array} SimilarProperty
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /properties/{id}/similar [get]
+func (h *PropertyHandler) SimilarProperties(c *gin.Context) {
+	limit := defaultSimilar
+	if l := c.Query("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive number"})
+			return
+		}
+		limit = min(n, maxSimilar)
+	}
+
+	var property models.Property
+	if err := listedProperties(h.DB).Preload("AmenityList").First(&property, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Property not found"})
+		return
+	}
+
+	// Only the likeliest candidates are scored: the nearest ones, or without
+	// coordinates those closest in price
+	pool := listedProperties(h.DB.Model(&models.Property{})).Where("properties.id <> ?", property.ID)
+	if property.Latitude != nil && property.Longitude != nil {
+		distance, args := filters.DistanceSQL(geo.Point{Lat: *property.Latitude, Lng: *property.Longitude})
+		pool = pool.Clauses(clause.OrderBy{Expression: clause.Expr{SQL: distance + " NULLS LAST, properties.id", Vars: args, WithoutParentheses: true}})
+	} else {
+		pool = pool.Clauses(clause.OrderBy{Expression: clause.Expr{SQL: "ABS(properties.price - ?), properties.id", Vars: []interface{}{property.Price}, WithoutParentheses: true}})
+	}
+	var candidates []models.Property
+	if err := pool.Limit(similarCandidates).Preload("Images", coverImage).Preload("AmenityList").Preload("Owner", publicOwner).
+		Find(&candidates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching similar properties"})
+		return
+	}
+
+	ranked := recommend.Rank(property, candidates, h.Recommender, limit)
+	properties := make([]models.Property, len(ranked))
+	for i, r := range ranked {
+		properties[i] = r.Property
+	}
+	if err := localize(h.DB, c, properties); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching similar properties"})
+		return
+	}
+	favorites := make([]*models.Property, len(properties))
+	for i := range properties {
+		favorites[i] = &properties[i]
+	}
+	if err := markFavorited(h.DB, c, favorites); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching similar properties"})
+		return
+	}
+	conceal(favorites)
+
+	response := make([]SimilarProperty, len(ranked))
+	for i, r := range ranked {
+		response[i] = SimilarProperty{Property: properties[i], Score: r.Score}
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/bookaroo/bookaroo-platform-be/i18n"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TranslationRequest sets the listing text of a property in one locale
+type TranslationRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description" binding:"required"`
+	HouseRules  string `json:"house_rules"` // Translation of the other house rules; shown untranslated when empty
+}
+
+// ListPropertyTranslations returns the translations of a property's listing text
+// @Summary List property translations
+// @Description Retrieve the translations of a property's name, description and house rules (owner only)
+// @Tags properties
+// @Produce json
+// @Param id path int true "Property ID"
+// @Success 200 {array} models.PropertyTranslation
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /properties/{id}/translations [get]
+func (h *PropertyHandler) ListPropertyTranslations(c *gin.Context) {
+	property, ok := findOwnedProperty(h.DB, c)
+	if !ok {
+		return
+	}
+
+	translations := []models.PropertyTranslation{}
+	if err := h.DB.Where("property_id = ?", property.ID).Order("locale").Find(&translations).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching translations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, translations)
+}
+
+// PutPropertyTranslation adds or replaces the translation of a property's listing text
+// @Summary Set a property translation
+// @Description Add or replace the name, description and house rules of a property in a locale other than its language. The translation is shown right away (owner only).
+// @Tags properties
+// @Accept json
+// @Produce json
+// @Param id path int true "Property ID"
+// @Param locale path string true "BCP 47 language tag, e.g. id or th"
+// @Param translation body TranslationRequest true "Translated text"
+// @Success 200 {object} models.PropertyTranslation
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /properties/{id}/translations/{locale} [put]
+func (h *PropertyHandler) PutPropertyTranslation(c *gin.Context) {
+	property, ok := findOwnedProperty(h.DB, c)
+	if !ok {
+		return
+	}
+
+	locale, err := i18n.ParseLocale(c.Param("locale"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if locale == property.Language {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The property is written in " + locale + "; update it directly instead"})
+		return
+	}
+
+	var req TranslationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	translation := models.PropertyTranslation{
+		PropertyID:  property.ID,
+		Locale:      locale,
+		Name:        strings.TrimSpace(req.Name),
+		Description: strings.TrimSpace(req.Description),
+		HouseRules:  strings.TrimSpace(req.HouseRules),
+	}
+	if err := h.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "property_id"}, {Name: "locale"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "description", "house_rules", "updated_at"}),
+	}).Create(&translation).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save translation"})
+		return
+	}
+	h.reindex(c, *property)
+
+	c.JSON(http.StatusOK, translation)
+}
+
+// DeletePropertyTranslation removes the translation of a property's listing text
+// @Summary Delete a property translation
+// @Description Remove a property's translation into a locale (owner only)
+// @Tags properties
+// @Produce json
+// @Param id path int true "Property ID"
+// @Param locale path string true "BCP 47 language tag, e.g. id or th"
+// @Success 204
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /properties/{id}/translations/{locale} [delete]
+func (h *PropertyHandler) DeletePropertyTranslation(c *gin.Context) {
+	property, ok := findOwnedProperty(h.DB, c)
+	if !ok {
+		return
+	}
+
+	locale, err := i18n.ParseLocale(c.Param("locale"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Translation not found"})
+		return
+	}
+
+	result := h.DB.Where("property_id = ? AND locale = ?", property.ID, locale).Delete(&models.PropertyTranslation{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete translation"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Translation not found"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// localize shows each property in the locale the client asked for with the
+// lang parameter or Accept-Language, among the property's own language and its
+// translations
+func localize(db *gorm.DB, c *gin.Context, properties []models.Property) error {
+	c.Header("Vary", "Accept-Language")
+	if len(properties) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(properties))
+	for i, p := range properties {
+		ids[i] = p.ID
+	}
+	var translations []models.PropertyTranslation
+	if err := db.Where("property_id IN ?", ids).Order("locale").Find(&translations).Error; err != nil {
+		return err
+	}
+	byProperty := map[uint][]models.PropertyTranslation{}
+	for _, t := range translations {
+		byProperty[t.PropertyID] = append(byProperty[t.PropertyID], t)
+	}
+
+	requested := i18n.Requested(c.Query("lang"), c.GetHeader("Accept-Language"))
+	for i := range properties {
+		p := &properties[i]
+		available := []string{p.Language}
+		for _, t := range byProperty[p.ID] {
+			available = append(available, t.Locale)
+		}
+
+		p.Locale = i18n.Best(requested, available)
+		for _, t := range byProperty[p.ID] {
+			if t.Locale != p.Locale {
+				continue
+			}
+			p.Name, p.Description = t.Name, t.Description
+			// Untranslated rules are still better shown than left out
+			if t.HouseRules != "" {
+				p.HouseRules.Other = t.HouseRules
+			}
+		}
+	}
+	return nil
+}
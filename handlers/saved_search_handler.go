@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/bookaroo/bookaroo-platform-be/alerts"
+	"github.com/bookaroo/bookaroo-platform-be/filters"
+	"github.com/bookaroo/bookaroo-platform-be/geo"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/notifications"
+	"github.com/bookaroo/bookaroo-platform-be/search"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type SavedSearchHandler struct {
+	DB     *gorm.DB
+	Alerts *alerts.Matcher
+}
+
+func NewSavedSearchHandler(db *gorm.DB, backend search.Backend, geocoder geo.Geocoder) *SavedSearchHandler {
+	return &SavedSearchHandler{
+		DB:     db,
+		Alerts: alerts.NewMatcher(db, geocoder, backend, notifications.NewDBNotifier(db)),
+	}
+}
+
+// SavedSearchRequest saves a property search
+type SavedSearchRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+	// Query parameters of GET /api/properties/search, e.g. location=Bali&max_price=150&guests=4
+	Query string `json:"query" binding:"required"`
+}
+
+// UpdateSavedSearchRequest changes a saved search; only the fields sent are changed
+type UpdateSavedSearchRequest struct {
+	Name          *string `json:"name" binding:"omitempty,min=1,max=100"`
+	Query         *string `json:"query"`
+	AlertsEnabled *bool   `json:"alerts_enabled"`
+}
+
+// ListSavedSearches returns the authenticated guest's saved searches
+// @Summary List saved searches
+// @Description Retrieve the authenticated user's saved searches, newest first
+// @Tags saved-searches
+// @Produce json
+// @Success 200 {array} models.SavedSearch
+// @Failure 401 {object} map[string]string
+// @Router /saved-searches [get]
+func (h *SavedSearchHandler) ListSavedSearches(c *gin.Context) {
+	searches := []models.SavedSearch{}
+	if err := h.DB.Where("user_id = ?", currentUserID(c)).Order("id DESC").Find(&searches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching saved searches"})
+		return
+	}
+
+	c.JSON(http.StatusOK, searches)
+}
+
+// CreateSavedSearch saves a property search to be alerted about
+// @Summary Save a search
+// @Description Save the filters of a property search. The guest is notified when properties start matching it and when matching properties get cheaper; properties matching when it is saved aren't announced. Sorting and paging parameters are dropped.
+// @Tags saved-searches
+// @Accept json
+// @Produce json
+// @Param search body SavedSearchRequest true "Name and search parameters"
+// @Success 201 {object} models.SavedSearch
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /saved-searches [post]
+func (h *SavedSearchHandler) CreateSavedSearch(c *gin.Context) {
+	var req SavedSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query, ok := h.searchQuery(c, req.Query)
+	if !ok {
+		return
+	}
+
+	saved := models.SavedSearch{
+		UserID:        currentUserID(c),
+		Name:          strings.TrimSpace(req.Name),
+		Query:         query,
+		AlertsEnabled: true,
+	}
+	if err := h.DB.Create(&saved).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save search"})
+		return
+	}
+	h.recordMatches(c.Request.Context(), &saved)
+
+	c.JSON(http.StatusCreated, saved)
+}
+
+// GetSavedSearch returns one of the authenticated guest's saved searches
+// @Summary Get a saved search
+// @Description Retrieve a saved search (owner of the search only)
+// @Tags saved-searches
+// @Produce json
+// @Param id path int true "Saved search ID"
+// @Success 200 {object} models.SavedSearch
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /saved-searches/{id} [get]
+func (h *SavedSearchHandler) GetSavedSearch(c *gin.Context) {
+	saved, ok := h.findOwnedSavedSearch(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, saved)
+}
+
+// UpdateSavedSearch renames a saved search, changes its filters or turns its alerts on or off
+// @Summary Update a saved search
+// @Description Change the name, search parameters or alerts of a saved search; only the fields sent are changed. New search parameters start over: properties matching them when they are saved aren't announced (owner of the search only)
+// @Tags saved-searches
+// @Accept json
+// @Produce json
+// @Param id path int true "Saved search ID"
+// @Param search body UpdateSavedSearchRequest true "Fields to change"
+// @Success 200 {object} models.SavedSearch
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /saved-searches/{id} [patch]
+func (h *SavedSearchHandler) UpdateSavedSearch(c *gin.Context) {
+	var req UpdateSavedSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	saved, ok := h.findOwnedSavedSearch(c)
+	if !ok {
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if req.Name != nil {
+		saved.Name = strings.TrimSpace(*req.Name)
+		updates["name"] = saved.Name
+	}
+	queryChanged := false
+	if req.Query != nil {
+		query, ok := h.searchQuery(c, *req.Query)
+		if !ok {
+			return
+		}
+		queryChanged = query != saved.Query
+		saved.Query = query
+		updates["query"] = query
+	}
+	if req.AlertsEnabled != nil {
+		// Searches turned off because they expired can't be turned back on unchanged
+		if *req.AlertsEnabled && req.Query == nil {
+			if _, ok := h.searchQuery(c, saved.Query); !ok {
+				return
+			}
+		}
+		saved.AlertsEnabled = *req.AlertsEnabled
+		updates["alerts_enabled"] = saved.AlertsEnabled
+	}
+	if queryChanged {
+		saved.LastCheckedAt = nil
+		updates["last_checked_at"] = nil
+	}
+
+	if len(updates) > 0 {
+		if err := h.DB.Transaction(func(tx *gorm.DB) error {
+			if queryChanged {
+				if err := tx.Where("saved_search_id = ?", saved.ID).Delete(&models.SavedSearchMatch{}).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Model(saved).Updates(updates).Error
+		}); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update saved search"})
+			return
+		}
+	}
+	if queryChanged {
+		h.recordMatches(c.Request.Context(), saved)
+	}
+
+	c.JSON(http.StatusOK, saved)
+}
+
+// DeleteSavedSearch removes a saved search and stops its alerts
+// @Summary Delete a saved search
+// @Description Delete a saved search; no more alerts are sent for it (owner of the search only)
+// @Tags saved-searches
+// @Param id path int true "Saved search ID"
+// @Success 204
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /saved-searches/{id} [delete]
+func (h *SavedSearchHandler) DeleteSavedSearch(c *gin.Context) {
+	saved, ok := h.findOwnedSavedSearch(c)
+	if !ok {
+		return
+	}
+
+	if err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("saved_search_id = ?", saved.ID).Delete(&models.SavedSearchMatch{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(saved).Error
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete saved search"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// searchQuery keeps the search filters of a query string sent by a client and
+// checks that they can be run. It writes the error response itself and returns
+// false when the caller should stop.
+func (h *SavedSearchHandler) searchQuery(c *gin.Context, raw string) (string, bool) {
+	params, err := url.ParseQuery(strings.TrimPrefix(strings.TrimSpace(raw), "?"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query must be a URL query string"})
+		return "", false
+	}
+	params = filters.Only(params)
+	if len(params) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query must have at least one of " + strings.Join(filters.Params, ", ")})
+		return "", false
+	}
+
+	query := params.Encode()
+	_, err = h.Alerts.Criteria(c.Request.Context(), query, currentUserID(c))
+	var invalid *filters.InvalidError
+	if errors.As(err, &invalid) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return "", false
+	}
+	if errors.Is(err, geo.ErrUnavailable) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Places can't be looked up right now, try again later"})
+		return "", false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check search"})
+		return "", false
+	}
+	return query, true
+}
+
+// recordMatches notes what a saved search matches now, so only later matches
+// are announced. Should it fail, the first scheduled check does it instead.
+func (h *SavedSearchHandler) recordMatches(ctx context.Context, saved *models.SavedSearch) {
+	if err := h.Alerts.Check(ctx, saved); err != nil {
+		log.Printf("Failed to record matches of saved search %d: %v", saved.ID, err)
+	}
+}
+
+// findOwnedSavedSearch loads the saved search from the :id path parameter if it
+// belongs to the authenticated user. It writes the error response itself and
+// returns false when the caller should stop.
+func (h *SavedSearchHandler) findOwnedSavedSearch(c *gin.Context) (*models.SavedSearch, bool) {
+	var saved models.SavedSearch
+	if err := h.DB.Where("user_id = ?", currentUserID(c)).First(&saved, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Saved search not found"})
+		return nil, false
+	}
+	return &saved, true
+}
@@ -5,6 +5,7 @@ import (
 	"regexp"
 
 	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/pagination"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
@@ -57,8 +58,18 @@ type LoginResponse struct {
 }
 
 // GetUserDashboard returns dashboard data based on user role
+// @Summary Get the user dashboard
+// @Description Owners get a page of their properties with bookings, guests a page of their bookings, newest first. Pass meta.next_cursor back as cursor to get the next page.
+// @Tags users
+// @Produce json
+// @Param limit query int false "Items per page (default 20, max 100)"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /dashboard [get]
 func (h *UserHandler) GetUserDashboard(c *gin.Context) {
-	userID := uint(1) // TODO: Replace with actual user ID from context
+	userID := currentUserID(c)
 	var user models.User
 
 	if err := h.DB.First(&user, userID).Error; err != nil {
@@ -66,29 +77,62 @@ func (h *UserHandler) GetUserDashboard(c *gin.Context) {
 		return
 	}
 
+	page, err := pagination.Parse(c.Query("limit"), c.Query("cursor"), "")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	if user.Role == "owner" {
 		// Get owner's properties and their bookings
-		var properties []models.Property
-		if err := h.DB.Preload("Bookings").Where("owner_id = ?", userID).Find(&properties).Error; err != nil {
+		query := h.DB.Model(&models.Property{}).Where("owner_id = ?", userID)
+		var total int64
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching properties"})
 			return
 		}
 
+		properties := []models.Property{}
+		if err := keysetPage(query.Session(&gorm.Session{}), "", nil, "properties.id", true, page).
+			Preload("Bookings").Find(&properties).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching properties"})
+			return
+		}
+		properties, meta := pagination.Trim(properties, page, total, propertyCursor(""))
+
 		c.JSON(http.StatusOK, gin.H{
 			"role":       "owner",
 			"properties": properties,
+			"meta":       meta,
 		})
 	} else {
 		// Get guest's bookings
-		var bookings []models.Booking
-		if err := h.DB.Preload("Property").Where("user_id = ?", userID).Find(&bookings).Error; err != nil {
+		query := h.DB.Model(&models.Booking{}).Where("user_id = ?", userID)
+		var total int64
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching bookings"})
 			return
 		}
 
+		bookings := []models.Booking{}
+		if err := keysetPage(query.Session(&gorm.Session{}), "", nil, "bookings.id", true, page).
+			Preload("Property").Find(&bookings).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching bookings"})
+			return
+		}
+		bookings, meta := pagination.Trim(bookings, page, total, func(b models.Booking) pagination.Cursor {
+			return pagination.Cursor{ID: b.ID}
+		})
+		for i := range bookings {
+			if bookings[i].Status != "confirmed" {
+				bookings[i].Property.Conceal()
+			}
+		}
+
 		c.JSON(http.StatusOK, gin.H{
 			"role":     "guest",
 			"bookings": bookings,
+			"meta":     meta,
 		})
 	}
 }
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/bookaroo/bookaroo-platform-be/availability"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/notifications"
+	"github.com/bookaroo/bookaroo-platform-be/waitlist"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type WaitlistHandler struct {
+	DB       *gorm.DB
+	Waitlist *waitlist.Manager
+}
+
+func NewWaitlistHandler(db *gorm.DB) *WaitlistHandler {
+	return &WaitlistHandler{
+		DB:       db,
+		Waitlist: waitlist.NewManager(db, notifications.NewDBNotifier(db)),
+	}
+}
+
+type JoinWaitlistRequest struct {
+	PropertyID uint      `json:"property_id" binding:"required"`
+	StartDate  time.Time `json:"start_date" binding:"required"`
+	EndDate    time.Time `json:"end_date" binding:"required"`
+}
+
+// JoinWaitlist registers interest in dates that are currently booked
+// @Summary Join a waitlist
+// @Description Register interest in a property for dates that are fully booked
+// @Tags waitlist
+// @Accept json
+// @Produce json
+// @Param entry body JoinWaitlistRequest true "Waitlist details"
+// @Success 201 {object} models.WaitlistEntry
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /waitlist [post]
+func (h *WaitlistHandler) JoinWaitlist(c *gin.Context) {
+	userID := currentUserID(c)
+
+	var req JoinWaitlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !req.EndDate.After(req.StartDate) || req.EndDate.Before(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must be in the future and after start_date"})
+		return
+	}
+
+	var property models.Property
+	if err := listedProperties(h.DB).First(&property, req.PropertyID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Property not found"})
+		return
+	}
+
+	conflict, err := availability.HasConflict(h.DB, property.ID, req.StartDate, req.EndDate, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check availability"})
+		return
+	}
+
+	if !conflict {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Property is available for these dates, book it directly"})
+		return
+	}
+
+	var existing models.WaitlistEntry
+	if err := h.DB.Where("property_id = ? AND user_id = ? AND start_date = ? AND end_date = ? AND status IN ('waiting', 'offered')",
+		property.ID, userID, req.StartDate, req.EndDate).First(&existing).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "You are already on the waitlist for these dates"})
+		return
+	}
+
+	entry := models.WaitlistEntry{
+		PropertyID: property.ID,
+		UserID:     userID,
+		StartDate:  req.StartDate,
+		EndDate:    req.EndDate,
+		Status:     "waiting",
+	}
+
+	if err := h.DB.Create(&entry).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to join waitlist"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, entry)
+}
+
+// ListWaitlist returns the authenticated user's waitlist entries
+// @Summary List my waitlist entries
+// @Description Retrieve the waitlist entries of the authenticated user
+// @Tags waitlist
+// @Produce json
+// @Success 200 {array} models.WaitlistEntry
+// @Failure 401 {object} models.ErrorResponse
+// @Router /waitlist [get]
+func (h *WaitlistHandler) ListWaitlist(c *gin.Context) {
+	var entries []models.WaitlistEntry
+	if err := h.DB.Where("user_id = ?", currentUserID(c)).Order("created_at DESC").Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching waitlist"})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// LeaveWaitlist removes the user from a waitlist
+// @Summary Leave a waitlist
+// @Description Cancel one of the authenticated user's waitlist entries. Dates offered to the user are passed on to the next guest in line.
+// @Tags waitlist
+// @Produce json
+// @Param id path int true "Waitlist entry ID"
+// @Success 204
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /waitlist/{id} [delete]
+func (h *WaitlistHandler) LeaveWaitlist(c *gin.Context) {
+	var entry models.WaitlistEntry
+	if err := h.DB.Where("user_id = ?", currentUserID(c)).First(&entry, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Waitlist entry not found"})
+		return
+	}
+
+	if err := h.DB.Model(&entry).Update("status", "cancelled").Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to leave waitlist"})
+		return
+	}
+
+	// Don't hold the dates for a guest who no longer wants them
+	if entry.Status == "offered" {
+		if err := h.Waitlist.OfferFreedDates(c.Request.Context(), entry.PropertyID, entry.StartDate, entry.EndDate); err != nil {
+			log.Printf("Failed to offer dates of waitlist entry %d to the next guest: %v", entry.ID, err)
+		}
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,414 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WishlistHandler struct {
+	DB *gorm.DB
+}
+
+func NewWishlistHandler(db *gorm.DB) *WishlistHandler {
+	return &WishlistHandler{DB: db}
+}
+
+// WishlistRequest names a wishlist
+type WishlistRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// WishlistItemRequest saves a property into a wishlist
+type WishlistItemRequest struct {
+	PropertyID uint   `json:"property_id" binding:"required"`
+	Note       string `json:"note" binding:"max=500"`
+}
+
+// WishlistShareResponse is the read-only link to a shared wishlist
+type WishlistShareResponse struct {
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
+
+// SharedWishlistResponse is a wishlist as seen through its shared link
+type SharedWishlistResponse struct {
+	Name  string                `json:"name"`
+	Items []models.WishlistItem `json:"items"`
+}
+
+// ListWishlists returns the authenticated guest's wishlists
+// @Summary List wishlists
+// @Description Retrieve the authenticated user's wishlists with the properties saved in them, newest first
+// @Tags wishlists
+// @Produce json
+// @Success 200 {array} models.Wishlist
+// @Failure 401 {object} map[string]string
+// @Router /wishlists [get]
+func (h *WishlistHandler) ListWishlists(c *gin.Context) {
+	wishlists := []models.Wishlist{}
+	if err := h.withItems(h.DB).Where("user_id = ?", currentUserID(c)).Order("id DESC").Find(&wishlists).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching wishlists"})
+		return
+	}
+	for i := range wishlists {
+		markSaved(&wishlists[i])
+		conceal(wishlistProperties(wishlists[i].Items))
+	}
+
+	c.JSON(http.StatusOK, wishlists)
+}
+
+// CreateWishlist creates an empty wishlist
+// @Summary Create a wishlist
+// @Description Create an empty, private wishlist for the authenticated user
+// @Tags wishlists
+// @Accept json
+// @Produce json
+// @Param wishlist body WishlistRequest true "Wishlist name"
+// @Success 201 {object} models.Wishlist
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /wishlists [post]
+func (h *WishlistHandler) CreateWishlist(c *gin.Context) {
+	var req WishlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	wishlist := models.Wishlist{UserID: currentUserID(c), Name: strings.TrimSpace(req.Name), Items: []models.WishlistItem{}}
+	if err := h.DB.Create(&wishlist).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create wishlist"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, wishlist)
+}
+
+// GetWishlist returns one of the authenticated guest's wishlists
+// @Summary Get a wishlist
+// @Description Retrieve a wishlist with the properties saved in it (owner of the wishlist only)
+// @Tags wishlists
+// @Produce json
+// @Param id path int true "Wishlist ID"
+// @Success 200 {object} models.Wishlist
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /wishlists/{id} [get]
+func (h *WishlistHandler) GetWishlist(c *gin.Context) {
+	wishlist, ok := h.findOwnedWishlist(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, wishlist)
+}
+
+// RenameWishlist changes the name of a wishlist
+// @Summary Rename a wishlist
+// @Description Change the name of a wishlist (owner of the wishlist only)
+// @Tags wishlists
+// @Accept json
+// @Produce json
+// @Param id path int true "Wishlist ID"
+// @Param wishlist body WishlistRequest true "New name"
+// @Success 200 {object} models.Wishlist
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /wishlists/{id} [patch]
+func (h *WishlistHandler) RenameWishlist(c *gin.Context) {
+	var req WishlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	wishlist, ok := h.findOwnedWishlist(c)
+	if !ok {
+		return
+	}
+
+	wishlist.Name = strings.TrimSpace(req.Name)
+	if err := h.DB.Model(wishlist).Update("name", wishlist.Name).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rename wishlist"})
+		return
+	}
+
+	c.JSON(http.StatusOK, wishlist)
+}
+
+// DeleteWishlist removes a wishlist and everything saved in it
+// @Summary Delete a wishlist
+// @Description Delete a wishlist; the properties in it are not affected (owner of the wishlist only)
+// @Tags wishlists
+// @Param id path int true "Wishlist ID"
+// @Success 204
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /wishlists/{id} [delete]
+func (h *WishlistHandler) DeleteWishlist(c *gin.Context) {
+	wishlist, ok := h.findOwnedWishlist(c)
+	if !ok {
+		return
+	}
+
+	if err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("wishlist_id = ?", wishlist.ID).Delete(&models.WishlistItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(wishlist).Error
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete wishlist"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// AddWishlistItem saves a property into a wishlist
+// @Summary Save a property into a wishlist
+// @Description Save a listed property into a wishlist, with an optional note. Saving a property that is already in the wishlist replaces its note (owner of the wishlist only)
+// @Tags wishlists
+// @Accept json
+// @Produce json
+// @Param id path int true "Wishlist ID"
+// @Param item body WishlistItemRequest true "Property to save"
+// @Success 200 {object} models.Wishlist
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /wishlists/{id}/items [post]
+func (h *WishlistHandler) AddWishlistItem(c *gin.Context) {
+	var req WishlistItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	wishlist, ok := h.findOwnedWishlist(c)
+	if !ok {
+		return
+	}
+
+	var property models.Property
+	if err := listedProperties(h.DB).Select("id").First(&property, req.PropertyID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Property not found"})
+		return
+	}
+
+	item := models.WishlistItem{WishlistID: wishlist.ID, PropertyID: property.ID, Note: strings.TrimSpace(req.Note)}
+	if err := h.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "wishlist_id"}, {Name: "property_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"note"}),
+	}).Create(&item).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save property"})
+		return
+	}
+
+	h.respondWithWishlist(c, wishlist.ID)
+}
+
+// RemoveWishlistItem takes a property out of a wishlist
+// @Summary Remove a property from a wishlist
+// @Description Take a saved property out of a wishlist (owner of the wishlist only)
+// @Tags wishlists
+// @Produce json
+// @Param id path int true "Wishlist ID"
+// @Param property_id path int true "Property ID"
+// @Success 200 {object} models.Wishlist
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /wishlists/{id}/items/{property_id} [delete]
+func (h *WishlistHandler) RemoveWishlistItem(c *gin.Context) {
+	wishlist, ok := h.findOwnedWishlist(c)
+	if !ok {
+		return
+	}
+
+	result := h.DB.Where("wishlist_id = ? AND property_id = ?", wishlist.ID, c.Param("property_id")).Delete(&models.WishlistItem{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove property"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Property is not in the wishlist"})
+		return
+	}
+
+	h.respondWithWishlist(c, wishlist.ID)
+}
+
+// ShareWishlist issues a read-only link to a wishlist, invalidating the previous one
+// @Summary Share a wishlist
+// @Description Issue a new read-only link to a wishlist that anyone can open without signing in. A previous link stops working (owner of the wishlist only)
+// @Tags wishlists
+// @Produce json
+// @Param id path int true "Wishlist ID"
+// @Success 200 {object} WishlistShareResponse
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /wishlists/{id}/share [post]
+func (h *WishlistHandler) ShareWishlist(c *gin.Context) {
+	wishlist, ok := h.findOwnedWishlist(c)
+	if !ok {
+		return
+	}
+
+	token, err := generateToken(24)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	if err := h.DB.Model(wishlist).Update("share_token", token).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to share wishlist"})
+		return
+	}
+
+	c.JSON(http.StatusOK, WishlistShareResponse{
+		Token: token,
+		URL:   fmt.Sprintf("/api/wishlists/shared/%s", token),
+	})
+}
+
+// UnshareWishlist stops sharing a wishlist
+// @Summary Stop sharing a wishlist
+// @Description Revoke the read-only link to a wishlist (owner of the wishlist only)
+// @Tags wishlists
+// @Param id path int true "Wishlist ID"
+// @Success 204
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /wishlists/{id}/share [delete]
+func (h *WishlistHandler) UnshareWishlist(c *gin.Context) {
+	wishlist, ok := h.findOwnedWishlist(c)
+	if !ok {
+		return
+	}
+
+	if err := h.DB.Model(wishlist).Update("share_token", "").Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to stop sharing wishlist"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetSharedWishlist returns a wishlist through its shared link
+// @Summary Get a shared wishlist
+// @Description Retrieve the name and saved properties of a wishlist through the token of its shared link. No sign-in is needed.
+// @Tags wishlists
+// @Produce json
+// @Param token path string true "Share token"
+// @Success 200 {object} SharedWishlistResponse
+// @Failure 404 {object} map[string]string
+// @Router /wishlists/shared/{token} [get]
+func (h *WishlistHandler) GetSharedWishlist(c *gin.Context) {
+	var wishlist models.Wishlist
+	if err := h.withItems(h.DB).Where("share_token = ? AND share_token <> ''", c.Param("token")).First(&wishlist).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Wishlist not found"})
+		return
+	}
+
+	// Favorites are those of whoever opened the link, not of the list's owner
+	if err := markFavorited(h.DB, c, wishlistProperties(wishlist.Items)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching wishlist"})
+		return
+	}
+	conceal(wishlistProperties(wishlist.Items))
+
+	c.JSON(http.StatusOK, SharedWishlistResponse{Name: wishlist.Name, Items: wishlist.Items})
+}
+
+// findOwnedWishlist loads the wishlist from the :id path parameter if it belongs
+// to the authenticated user. Other users' wishlists are answered as missing so
+// their existence isn't revealed. It writes the error response itself and
+// returns false when the caller should stop.
+func (h *WishlistHandler) findOwnedWishlist(c *gin.Context) (*models.Wishlist, bool) {
+	var wishlist models.Wishlist
+	if err := h.withItems(h.DB).Where("user_id = ?", currentUserID(c)).First(&wishlist, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Wishlist not found"})
+		return nil, false
+	}
+	markSaved(&wishlist)
+	conceal(wishlistProperties(wishlist.Items))
+	return &wishlist, true
+}
+
+// respondWithWishlist writes the wishlist as it is after a change
+func (h *WishlistHandler) respondWithWishlist(c *gin.Context, id uint) {
+	var wishlist models.Wishlist
+	if err := h.withItems(h.DB).First(&wishlist, id).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching wishlist"})
+		return
+	}
+	markSaved(&wishlist)
+	conceal(wishlistProperties(wishlist.Items))
+	c.JSON(http.StatusOK, wishlist)
+}
+
+// withItems preloads the saved properties of wishlists, oldest first, with their
+// cover image. Properties that are no longer listed are left out.
+func (h *WishlistHandler) withItems(db *gorm.DB) *gorm.DB {
+	return db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Joins("JOIN properties ON properties.id = wishlist_items.property_id").
+			Scopes(listedProperties).Order("wishlist_items.id")
+	}).Preload("Items.Property").Preload("Items.Property.Images", coverImage).Preload("Items.Property.AmenityList")
+}
+
+// markSaved flags every property in the authenticated user's own wishlists as a favorite
+func markSaved(wishlists ...*models.Wishlist) {
+	for _, w := range wishlists {
+		for _, p := range wishlistProperties(w.Items) {
+			p.IsFavorited = true
+		}
+	}
+}
+
+// wishlistProperties returns the properties saved in wishlist items
+func wishlistProperties(items []models.WishlistItem) []*models.Property {
+	properties := make([]*models.Property, 0, len(items))
+	for i := range items {
+		if items[i].Property != nil {
+			properties = append(properties, items[i].Property)
+		}
+	}
+	return properties
+}
+
+// markFavorited flags the properties the authenticated user saved into any of
+// their wishlists. Anonymous requests leave every property unflagged.
+func markFavorited(db *gorm.DB, c *gin.Context, properties []*models.Property) error {
+	userID := currentUserID(c)
+	if userID == 0 || len(properties) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(properties))
+	for i, p := range properties {
+		ids[i] = p.ID
+	}
+	var favorited []uint
+	if err := db.Model(&models.WishlistItem{}).
+		Joins("JOIN wishlists ON wishlists.id = wishlist_items.wishlist_id").
+		Where("wishlists.user_id = ? AND wishlist_items.property_id IN ?", userID, ids).
+		Distinct().Pluck("wishlist_items.property_id", &favorited).Error; err != nil {
+		return err
+	}
+
+	saved := make(map[uint]bool, len(favorited))
+	for _, id := range favorited {
+		saved[id] = true
+	}
+	for _, p := range properties {
+		p.IsFavorited = saved[p.ID]
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package i18n
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// DefaultLocale is shown when none of the locales a guest asked for is available
+const DefaultLocale = "en"
+
+var ErrInvalidLocale = errors.New("locale must be a language tag such as en, id or th")
+
+// ParseLocale returns the canonical form of a BCP 47 language tag, e.g. "pt-br" becomes "pt-BR"
+func ParseLocale(s string) (string, error) {
+	tag, err := language.Parse(strings.TrimSpace(s))
+	if err != nil || tag == language.Und {
+		return "", ErrInvalidLocale
+	}
+	return tag.String(), nil
+}
+
+// Requested returns the locales a client asked for, best first: the lang
+// parameter when given, otherwise the Accept-Language header. Malformed values
+// are ignored.
+func Requested(lang, acceptLanguage string) []language.Tag {
+	if lang != "" {
+		if tag, err := language.Parse(lang); err == nil {
+			return []language.Tag{tag}
+		}
+	}
+	tags, _, _ := language.ParseAcceptLanguage(acceptLanguage)
+	return tags
+}
+
+// Best picks the available locale closest to the requested ones. Without a
+// good enough match it falls back to DefaultLocale when available, and
+// otherwise to the first available locale.
+func Best(requested []language.Tag, available []string) string {
+	if len(available) == 0 {
+		return ""
+	}
+
+	fallback := 0
+	for i, locale := range available {
+		if locale == DefaultLocale {
+			fallback = i
+			break
+		}
+	}
+	if len(requested) == 0 {
+		return available[fallback]
+	}
+
+	// The matcher falls back to the first tag it is given
+	tags := make([]language.Tag, 0, len(available))
+	tags = append(tags, language.Make(available[fallback]))
+	index := []int{fallback}
+	for i, locale := range available {
+		if i != fallback {
+			tags = append(tags, language.Make(locale))
+			index = append(index, i)
+		}
+	}
+
+	_, i, confidence := language.NewMatcher(tags).Match(requested...)
+	if confidence == language.No {
+		return available[fallback]
+	}
+	return available[index[i]]
+}
@@ -0,0 +1,114 @@
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	dateFormat     = "20060102"
+	dateTimeFormat = "20060102T150405Z"
+	maxLineOctets  = 75
+)
+
+// Event represents an all-day VEVENT spanning the nights from Start up to (but excluding) End
+type Event struct {
+	UID         string
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Description string
+	Status      string
+	Stamp       time.Time
+}
+
+// Calendar represents a VCALENDAR object with its events
+type Calendar struct {
+	ProdID string
+	Name   string
+	Events []Event
+}
+
+// Write serializes the calendar as RFC 5545 text with CRLF line endings and folded lines
+func (cal *Calendar) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	writeLine(bw, "BEGIN:VCALENDAR")
+	writeLine(bw, "VERSION:2.0")
+	writeLine(bw, "PRODID:"+cal.ProdID)
+	writeLine(bw, "CALSCALE:GREGORIAN")
+	writeLine(bw, "METHOD:PUBLISH")
+	if cal.Name != "" {
+		writeLine(bw, "X-WR-CALNAME:"+escapeText(cal.Name))
+	}
+
+	for _, event := range cal.Events {
+		writeLine(bw, "BEGIN:VEVENT")
+		writeLine(bw, "UID:"+event.UID)
+		writeLine(bw, "DTSTAMP:"+event.Stamp.UTC().Format(dateTimeFormat))
+		writeLine(bw, "DTSTART;VALUE=DATE:"+event.Start.Format(dateFormat))
+		writeLine(bw, "DTEND;VALUE=DATE:"+event.End.Format(dateFormat))
+		writeLine(bw, "SUMMARY:"+escapeText(event.Summary))
+		if event.Description != "" {
+			writeLine(bw, "DESCRIPTION:"+escapeText(event.Description))
+		}
+		if event.Status != "" {
+			writeLine(bw, "STATUS:"+event.Status)
+		}
+		writeLine(bw, "TRANSP:OPAQUE")
+		writeLine(bw, "END:VEVENT")
+	}
+
+	writeLine(bw, "END:VCALENDAR")
+
+	return bw.Flush()
+}
+
+// writeLine writes a content line, folding it so no physical line exceeds 75 octets
+func writeLine(w *bufio.Writer, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		// Never split a multi-byte UTF-8 sequence across lines
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		w.WriteString(line[:cut])
+		w.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space, which counts towards the limit
+		limit = maxLineOctets - 1
+	}
+	w.WriteString(line)
+	w.WriteString("\r\n")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+// escapeText escapes a TEXT property value as described in RFC 5545 section 3.3.11
+func escapeText(s string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	)
+	return replacer.Replace(s)
+}
+
+// DateOnly truncates a timestamp to its calendar date in UTC
+func DateOnly(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// UID builds a stable event identifier for a record of the given kind
+func UID(kind string, id uint, domain string) string {
+	return fmt.Sprintf("%s-%d@%s", kind, id, domain)
+}
@@ -0,0 +1,201 @@
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// contentLine is a single unfolded "NAME;PARAM=VALUE:value" line
+type contentLine struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// Parse reads an iCalendar stream and returns its VEVENTs. Timed events are
+// converted to UTC; DTEND defaults to the day after DTSTART when it is missing.
+// A stream that ends inside a VEVENT or before END:VCALENDAR is rejected, so a
+// truncated feed is never mistaken for a complete one.
+func Parse(r io.Reader) ([]Event, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	var current *Event
+	sawCalendar := false
+	endedCalendar := false
+
+	for i, raw := range lines {
+		if raw == "" {
+			continue
+		}
+
+		line, err := parseContentLine(raw)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+
+		switch {
+		case line.Name == "BEGIN" && strings.EqualFold(line.Value, "VCALENDAR"):
+			sawCalendar = true
+		case line.Name == "END" && strings.EqualFold(line.Value, "VCALENDAR"):
+			if current != nil {
+				return nil, fmt.Errorf("line %d: END:VCALENDAR inside an open VEVENT", i+1)
+			}
+			endedCalendar = true
+		case line.Name == "BEGIN" && strings.EqualFold(line.Value, "VEVENT"):
+			if current != nil {
+				return nil, fmt.Errorf("line %d: BEGIN:VEVENT inside an open VEVENT", i+1)
+			}
+			current = &Event{}
+		case line.Name == "END" && strings.EqualFold(line.Value, "VEVENT"):
+			if current == nil {
+				return nil, fmt.Errorf("line %d: END:VEVENT without BEGIN:VEVENT", i+1)
+			}
+			if current.Start.IsZero() {
+				return nil, fmt.Errorf("line %d: event %q has no DTSTART", i+1, current.UID)
+			}
+			if current.End.IsZero() {
+				current.End = current.Start.AddDate(0, 0, 1)
+			}
+			events = append(events, *current)
+			current = nil
+		case current != nil:
+			if err := applyProperty(current, line); err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+		}
+	}
+
+	if !sawCalendar {
+		return nil, fmt.Errorf("not an iCalendar document")
+	}
+	if current != nil {
+		return nil, fmt.Errorf("event %q is not terminated by END:VEVENT", current.UID)
+	}
+	if !endedCalendar {
+		return nil, fmt.Errorf("missing END:VCALENDAR")
+	}
+
+	return events, nil
+}
+
+func applyProperty(event *Event, line contentLine) error {
+	var err error
+	switch line.Name {
+	case "UID":
+		event.UID = line.Value
+	case "SUMMARY":
+		event.Summary = unescapeText(line.Value)
+	case "DESCRIPTION":
+		event.Description = unescapeText(line.Value)
+	case "STATUS":
+		event.Status = strings.ToUpper(line.Value)
+	case "DTSTART":
+		event.Start, err = parseTime(line)
+	case "DTEND":
+		event.End, err = parseTime(line)
+	case "DTSTAMP":
+		event.Stamp, err = parseTime(line)
+	}
+	return err
+}
+
+// unfold joins continuation lines (those starting with a space or tab) onto the previous line
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, scanner.Err()
+}
+
+func parseContentLine(raw string) (contentLine, error) {
+	// The value starts at the first colon that is not inside a quoted parameter value
+	inQuotes := false
+	colon := -1
+	for i, r := range raw {
+		if r == '"' {
+			inQuotes = !inQuotes
+		} else if r == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return contentLine{}, fmt.Errorf("malformed content line %q", raw)
+	}
+
+	parts := strings.Split(raw[:colon], ";")
+	line := contentLine{
+		Name:   strings.ToUpper(parts[0]),
+		Params: make(map[string]string),
+		Value:  raw[colon+1:],
+	}
+	for _, param := range parts[1:] {
+		if key, value, ok := strings.Cut(param, "="); ok {
+			line.Params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+		}
+	}
+
+	return line, nil
+}
+
+func parseTime(line contentLine) (time.Time, error) {
+	value := line.Value
+
+	if line.Params["VALUE"] == "DATE" || len(value) == len(dateFormat) {
+		t, err := time.ParseInLocation(dateFormat, value, time.UTC)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid %s date %q", line.Name, value)
+		}
+		return t, nil
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(dateTimeFormat, value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid %s time %q", line.Name, value)
+		}
+		return t, nil
+	}
+
+	// Floating or TZID-qualified local time
+	loc := time.UTC
+	if tzid := line.Params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s time %q", line.Name, value)
+	}
+	return t.UTC(), nil
+}
+
+// unescapeText reverses the TEXT escaping applied by escapeText
+func unescapeText(s string) string {
+	replacer := strings.NewReplacer(
+		`\\`, `\`,
+		`\;`, ";",
+		`\,`, ",",
+		`\n`, "\n",
+		`\N`, "\n",
+	)
+	return replacer.Replace(s)
+}
@@ -0,0 +1,266 @@
+package locations
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/bookaroo/bookaroo-platform-be/geo"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Kinds of places, from the top of the hierarchy down
+const (
+	KindCountry = "country"
+	KindRegion  = "region"
+	KindCity    = "city"
+)
+
+// ErrBlankQuery is returned for autocomplete text without letters or digits
+var ErrBlankQuery = errors.New("q must contain letters or digits")
+
+var gazetteer = geo.NewGazetteer()
+
+// Parts of a location; any of them may be empty
+type Parts struct {
+	City    string
+	Region  string
+	Country string
+}
+
+// Parse splits a free-form location such as "Villa Sunset, Ubud" into its
+// parts. Places the gazetteer knows are completed with their region and
+// country; other locations are read as "city, region, country".
+func Parse(location string) Parts {
+	var parts []string
+	for _, part := range strings.Split(location, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	if place, i, ok := gazetteer.Lookup(location); ok {
+		if !gazetteer.IsRegion(place.Name) {
+			return Parts{City: place.Name, Region: place.Region, Country: place.Country}
+		}
+		// A region such as "Jimbaran, Bali": the part before it is the city
+		result := Parts{Region: place.Name, Country: place.Country}
+		if i > 0 {
+			result.City = strings.TrimSpace(strings.Split(location, ",")[i-1])
+		}
+		return result
+	}
+
+	var result Parts
+	if n := len(parts); n > 1 && gazetteer.IsCountry(parts[n-1]) {
+		result.Country = parts[n-1]
+		parts = parts[:n-1]
+	}
+	switch n := len(parts); {
+	case n == 1:
+		result.City = parts[0]
+	case n == 2 || result.Country != "":
+		result.City, result.Region = parts[n-2], parts[n-1]
+	case n > 2:
+		result.City, result.Region, result.Country = parts[n-3], parts[n-2], parts[n-1]
+	}
+	return result
+}
+
+// Resolve files location in the place hierarchy, adding the places it names
+// that don't exist yet, and returns the most specific one. Blank locations
+// resolve to nil.
+func Resolve(db *gorm.DB, location string) (*models.Place, error) {
+	parts := Parse(location)
+
+	var place *models.Place
+	levels := []struct{ name, kind string }{
+		{parts.Country, KindCountry},
+		{parts.Region, KindRegion},
+		{parts.City, KindCity},
+	}
+	for _, level := range levels {
+		if geo.NormalizeName(level.name) == "" {
+			continue
+		}
+		var err error
+		if place, err = ensure(db, level.name, level.kind, place); err != nil {
+			return nil, err
+		}
+	}
+	return place, nil
+}
+
+// Address reads the public part of an address from a free-form location: its
+// city, region and, for countries the gazetteer knows, country code
+func Address(location string) models.Address {
+	parts := Parse(location)
+	return models.Address{City: parts.City, Region: parts.Region, CountryCode: geo.CountryCode(parts.Country)}
+}
+
+// ResolveProperty files a property by the public part of its address or,
+// without a city, by its location
+func ResolveProperty(db *gorm.DB, property models.Property) (*models.Place, error) {
+	if property.City != "" {
+		return Resolve(db, AddressText(property.Address()))
+	}
+	return Resolve(db, property.Location)
+}
+
+// AddressText writes the public part of an address as a location Resolve
+// understands, e.g. "Ubud, Bali, Indonesia"
+func AddressText(a models.Address) string {
+	var parts []string
+	for _, part := range []string{a.City, a.Region, geo.CountryName(a.CountryCode)} {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ensure returns the named place under parent, creating it when missing
+func ensure(db *gorm.DB, name, kind string, parent *models.Place) (*models.Place, error) {
+	searchName := geo.NormalizeName(name)
+	place := models.Place{Name: strings.TrimSpace(name), Kind: kind, Path: searchName, SearchName: searchName}
+	if parent != nil {
+		place.ParentID = &parent.ID
+		place.Path = parent.Path + "/" + searchName
+	}
+
+	if err := db.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "path"}}, DoNothing: true}).
+		Create(&place).Error; err != nil {
+		return nil, err
+	}
+	if place.ID == 0 {
+		// Created before, possibly by a concurrent request
+		if err := db.Where("path = ?", place.Path).First(&place).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &place, nil
+}
+
+// Backfill files the properties saved before the place hierarchy existed
+func Backfill(db *gorm.DB) error {
+	var properties []models.Property
+	if err := db.Select("id, location").Where("place_id IS NULL AND location <> ''").
+		Find(&properties).Error; err != nil {
+		return err
+	}
+
+	for _, p := range properties {
+		place, err := Resolve(db, p.Location)
+		if err != nil {
+			return err
+		}
+		if place == nil {
+			continue
+		}
+		if err := db.Model(&models.Property{}).Where("id = ?", p.ID).UpdateColumn("place_id", place.ID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BackfillAddresses fills in the city, region and country of properties saved
+// before addresses were structured, read from their location
+func BackfillAddresses(db *gorm.DB) error {
+	var properties []models.Property
+	if err := db.Select("id, location").Where("COALESCE(city, '') = '' AND COALESCE(region, '') = '' AND location <> ''").
+		Find(&properties).Error; err != nil {
+		return err
+	}
+
+	for _, p := range properties {
+		address := Address(p.Location)
+		if err := db.Model(&models.Property{}).Where("id = ?", p.ID).UpdateColumns(map[string]interface{}{
+			"city":         address.City,
+			"region":       address.Region,
+			"country_code": address.CountryCode,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Suggestion is a place completing the text typed into a location search box
+type Suggestion struct {
+	ID       uint   `json:"id"`
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`
+	Label    string `json:"label"`    // With the region and country, e.g. "Ubud, Bali, Indonesia"
+	Listings int64  `json:"listings"` // Listed properties in the place or within it
+}
+
+// Autocomplete returns the places with listed properties whose name, or a word
+// of it, starts with text, those with the most listings first
+func Autocomplete(db *gorm.DB, text string, limit int) ([]Suggestion, error) {
+	prefix := geo.NormalizeName(text)
+	if prefix == "" {
+		return nil, ErrBlankQuery
+	}
+
+	var counts []struct {
+		ID       uint
+		Listings int64
+	}
+	matches := db.Table("places").
+		Select(`places.id, places.name, (
+			SELECT COUNT(*) FROM properties JOIN places filed ON filed.id = properties.place_id
+			WHERE `+models.ListedSQL+`
+			AND (filed.path = places.path OR filed.path LIKE places.path || '/%')) AS listings`).
+		Where("places.search_name LIKE ? OR places.search_name LIKE ?", prefix+"%", "% "+prefix+"%")
+	if err := db.Table("(?) AS matches", matches).Select("id, listings").
+		Where("listings > 0").Order("listings DESC, name, id").Limit(limit).
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	if len(counts) == 0 {
+		return []Suggestion{}, nil
+	}
+
+	ids := make([]uint, len(counts))
+	for i, c := range counts {
+		ids[i] = c.ID
+	}
+	var places []models.Place
+	if err := db.Preload("Parent.Parent").Where("id IN ?", ids).Find(&places).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]models.Place, len(places))
+	for _, p := range places {
+		byID[p.ID] = p
+	}
+
+	suggestions := make([]Suggestion, 0, len(counts))
+	for _, c := range counts {
+		p, ok := byID[c.ID]
+		if !ok {
+			continue
+		}
+		suggestions = append(suggestions, Suggestion{ID: p.ID, Name: p.Name, Kind: p.Kind, Label: Label(p), Listings: c.Listings})
+	}
+	return suggestions, nil
+}
+
+// Label names a place with its preloaded parents, e.g. "Ubud, Bali, Indonesia"
+func Label(place models.Place) string {
+	names := []string{place.Name}
+	for p := place.Parent; p != nil; p = p.Parent {
+		names = append(names, p.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// Within scopes properties to those filed under the place or anywhere within it
+func Within(placeID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(`properties.place_id IN (
+			SELECT filed.id FROM places filed JOIN places root ON root.id = ?
+			WHERE filed.path = root.path OR filed.path LIKE root.path || '/%')`, placeID)
+	}
+}
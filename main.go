@@ -1,11 +1,23 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"time"
 
+	"github.com/bookaroo/bookaroo-platform-be/alerts"
+	"github.com/bookaroo/bookaroo-platform-be/billing"
+	"github.com/bookaroo/bookaroo-platform-be/calendarsync"
+	"github.com/bookaroo/bookaroo-platform-be/channel"
 	"github.com/bookaroo/bookaroo-platform-be/config"
+	"github.com/bookaroo/bookaroo-platform-be/geo"
+	"github.com/bookaroo/bookaroo-platform-be/notifications"
+	"github.com/bookaroo/bookaroo-platform-be/portfolio"
 	"github.com/bookaroo/bookaroo-platform-be/routes"
+	"github.com/bookaroo/bookaroo-platform-be/scheduler"
+	"github.com/bookaroo/bookaroo-platform-be/search"
+	"github.com/bookaroo/bookaroo-platform-be/waitlist"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
@@ -19,11 +31,32 @@ func main() {
 	// Initialize database
 	db := config.InitDB()
 
+	// The geocoder and search backend are shared by the routes and the
+	// background jobs, so Nominatim's cache and request limit cover them all
+	geocoder, err := geo.NewFromEnv()
+	if err != nil {
+		log.Fatal("Failed to configure geocoder:", err)
+	}
+	backend, err := search.NewFromEnv(db)
+	if err != nil {
+		log.Fatal("Failed to configure search:", err)
+	}
+
+	// Start background jobs
+	jobs := scheduler.New()
+	jobs.Every(config.GetDuration("CALENDAR_SYNC_INTERVAL", 30*time.Minute), "calendar-sync", calendarsync.NewSyncer(db).SyncAll)
+	jobs.Every(config.GetDuration("CHANNEL_PUSH_INTERVAL", 15*time.Minute), "channel-push", channel.NewPusher(db).PushAll)
+	jobs.Every(config.GetDuration("WAITLIST_CHECK_INTERVAL", 5*time.Minute), "waitlist-expiry", waitlist.NewManager(db, notifications.NewDBNotifier(db)).ExpireOffers)
+	jobs.Every(config.GetDuration("BILLING_CHECK_INTERVAL", time.Hour), "installments-due", billing.NewBiller(db, notifications.NewDBNotifier(db)).MarkDue)
+	jobs.Every(config.GetDuration("IMPORT_CHECK_INTERVAL", 5*time.Second), "property-imports", portfolio.NewImporter(db, geocoder).RunQueued)
+	jobs.Every(config.GetDuration("SAVED_SEARCH_CHECK_INTERVAL", time.Hour), "saved-search-alerts", alerts.NewMatcher(db, geocoder, backend, notifications.NewDBNotifier(db)).CheckAll)
+	jobs.Start(context.Background())
+
 	// Create a new Gin router
 	r := gin.Default()
 
 	// Initialize routes
-	routes.SetupRoutes(r, db)
+	routes.SetupRoutes(r, db, backend, geocoder)
 
 	// Get port from environment variable or use default
 	port := os.Getenv("PORT")
@@ -0,0 +1,27 @@
+package media
+
+import (
+	"image"
+
+	"golang.org/x/image/draw"
+)
+
+// DifferenceHash is a perceptual hash of an image: it shrinks the image to 9x8
+// grey pixels and sets one bit per pair of neighbouring pixels when the left
+// one is brighter. Resized, recompressed or slightly retouched copies of a
+// photo hash to values a few bits apart.
+func DifferenceHash(img image.Image) uint64 {
+	small := image.NewGray(image.Rect(0, 0, 9, 8))
+	draw.BiLinear.Scale(small, small.Bounds(), flatten(img), img.Bounds(), draw.Src, nil)
+
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			hash <<= 1
+			if small.GrayAt(x, y).Y > small.GrayAt(x+1, y).Y {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
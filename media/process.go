@@ -0,0 +1,151 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png" // Register the PNG decoder for uploads
+	"net/http"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // Register the WebP decoder for uploads
+)
+
+const (
+	MaxUploadSize = 10 << 20 // Bytes
+	maxPixels     = 40_000_000
+	jpegQuality   = 85
+)
+
+var (
+	ErrTooLarge        = fmt.Errorf("image exceeds %d MB", MaxUploadSize>>20)
+	ErrUnsupportedType = errors.New("only JPEG, PNG and WebP images are accepted")
+)
+
+// allowedTypes are the sniffed content types accepted for upload
+var allowedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/webp": true,
+}
+
+// Size is a generated rendition of an uploaded image
+type Size struct {
+	Name     string
+	MaxWidth int
+	WebP     bool // Also produce a WebP copy when an encoder is configured
+}
+
+// Sizes are generated for every upload, largest first
+var Sizes = []Size{
+	{Name: "large", MaxWidth: 1600},
+	{Name: "medium", MaxWidth: 800, WebP: true},
+	{Name: "thumb", MaxWidth: 320, WebP: true},
+}
+
+// Variant is one encoded file produced from an upload
+type Variant struct {
+	Name        string // e.g. "thumb.webp"
+	ContentType string
+	Data        []byte
+}
+
+// Result holds the variants of a processed upload
+type Result struct {
+	Width    int // Of the upright original
+	Height   int
+	Variants []Variant
+	// DifferenceHash of the upright original, to spot copies of the photo
+	PerceptualHash uint64
+}
+
+// Process validates an uploaded image and renders every size as JPEG, plus
+// WebP where configured when webp is not nil. Images are decoded and re-encoded,
+// so EXIF and other metadata never reach storage; the EXIF orientation is
+// applied first so photos keep displaying upright.
+func Process(data []byte, webp WebPEncoder) (*Result, error) {
+	if len(data) > MaxUploadSize {
+		return nil, ErrTooLarge
+	}
+	if !allowedTypes[http.DetectContentType(data)] {
+		return nil, ErrUnsupportedType
+	}
+
+	// Check dimensions before decoding so oversized images can't exhaust memory
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedType
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, fmt.Errorf("image exceeds %d megapixels", maxPixels/1_000_000)
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedType
+	}
+	if format == "jpeg" {
+		img = applyOrientation(img, jpegOrientation(data))
+	}
+
+	bounds := img.Bounds()
+	result := &Result{Width: bounds.Dx(), Height: bounds.Dy(), PerceptualHash: DifferenceHash(img)}
+	for _, size := range Sizes {
+		resized := resize(img, size.MaxWidth)
+
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, flatten(resized), &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, err
+		}
+		result.Variants = append(result.Variants, Variant{Name: size.Name + ".jpg", ContentType: "image/jpeg", Data: buf.Bytes()})
+
+		if size.WebP && webp != nil {
+			data, err := webp.EncodeWebP(resized)
+			if err != nil {
+				return nil, err
+			}
+			result.Variants = append(result.Variants, Variant{Name: size.Name + ".webp", ContentType: "image/webp", Data: data})
+		}
+	}
+
+	return result, nil
+}
+
+// VariantNames lists the file names Process produces, for cleaning up stored images
+func VariantNames() []string {
+	var names []string
+	for _, size := range Sizes {
+		names = append(names, size.Name+".jpg")
+		if size.WebP {
+			names = append(names, size.Name+".webp")
+		}
+	}
+	return names
+}
+
+// resize scales img down to maxWidth, keeping its aspect ratio; smaller images are left as they are
+func resize(img image.Image, maxWidth int) image.Image {
+	b := img.Bounds()
+	if b.Dx() <= maxWidth {
+		return img
+	}
+
+	height := b.Dy() * maxWidth / b.Dx()
+	if height < 1 {
+		height = 1
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, maxWidth, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
+
+// flatten composites transparent images onto white, since JPEG has no alpha channel
+func flatten(img image.Image) image.Image {
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Over)
+	return dst
+}
@@ -0,0 +1,96 @@
+package media
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const (
+	webpQuality = 80
+	webpTimeout = 30 * time.Second
+)
+
+// WebPEncoder encodes images as WebP. The Go libraries only decode WebP, so
+// implementations wrap libwebp.
+type WebPEncoder interface {
+	EncodeWebP(img image.Image) ([]byte, error)
+}
+
+// CWebP encodes with cwebp, the command line encoder shipped with libwebp
+type CWebP struct {
+	Path    string // Of the cwebp binary
+	Quality int    // 0 to 100
+}
+
+func NewCWebP(path string) *CWebP {
+	return &CWebP{Path: path, Quality: webpQuality}
+}
+
+func (e *CWebP) EncodeWebP(img image.Image) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "webp")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	// PNG keeps the input lossless, so cwebp does the only lossy step
+	in, out := filepath.Join(dir, "in.png"), filepath.Join(dir, "out.webp")
+	f, err := os.Create(in)
+	if err != nil {
+		return nil, err
+	}
+	err = png.Encode(f, img)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), webpTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, e.Path, "-quiet", "-q", strconv.Itoa(e.Quality), "-metadata", "none", in, "-o", out)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("cwebp: %w: %s", err, output)
+	}
+	return os.ReadFile(out)
+}
+
+// NewWebPEncoderFromEnv builds the encoder selected by WEBP_ENCODER: "cwebp"
+// runs the binary at CWEBP_PATH (default: cwebp on the PATH) and "none" skips
+// WebP copies. Unset, cwebp is used when it is installed. A nil encoder means
+// uploads are only stored as JPEG.
+func NewWebPEncoderFromEnv() (WebPEncoder, error) {
+	path := os.Getenv("CWEBP_PATH")
+	if path == "" {
+		path = "cwebp"
+	}
+
+	switch name := os.Getenv("WEBP_ENCODER"); name {
+	case "":
+		resolved, err := exec.LookPath(path)
+		if err != nil {
+			log.Printf("cwebp not found, uploads are stored without WebP copies")
+			return nil, nil
+		}
+		return NewCWebP(resolved), nil
+	case "cwebp":
+		resolved, err := exec.LookPath(path)
+		if err != nil {
+			return nil, fmt.Errorf("cwebp not found: %w", err)
+		}
+		return NewCWebP(resolved), nil
+	case "none":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown WebP encoder %q", name)
+	}
+}
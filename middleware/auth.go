@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -30,38 +31,59 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// Extract token from "Bearer <token>"
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header format"})
+		claims, err := parseToken(authHeader)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			c.Abort()
 			return
 		}
 
-		tokenString := parts[1]
-		claims := &Claims{}
-
-		// Parse and validate token
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte(os.Getenv("JWT_SECRET")), nil
-		})
+		setUser(c, claims)
+		c.Next()
+	}
+}
 
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
-			c.Abort()
-			return
+// OptionalAuth sets user info in context when the request carries a valid
+// token, for public endpoints that personalize their response. Requests
+// without one, or with an invalid one, are served anonymously.
+func OptionalAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if claims, err := parseToken(c.GetHeader("Authorization")); err == nil {
+			setUser(c, claims)
 		}
+		c.Next()
+	}
+}
 
-		// Set user info in context
-		c.Set("user_id", claims.UserID)
-		c.Set("email", claims.Email)
-		c.Set("role", claims.Role)
+// parseToken validates the JWT in an Authorization header of the form "Bearer <token>"
+func parseToken(authHeader string) (*Claims, error) {
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return nil, errors.New("Invalid authorization header format")
+	}
 
-		c.Next()
+	tokenString := parts[1]
+	claims := &Claims{}
+
+	// Parse and validate token
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(os.Getenv("JWT_SECRET")), nil
+	})
+
+	if err != nil || !token.Valid {
+		return nil, errors.New("Invalid or expired token")
 	}
+	return claims, nil
+}
+
+// setUser stores the authenticated user's info in context
+func setUser(c *gin.Context, claims *Claims) {
+	c.Set("user_id", claims.UserID)
+	c.Set("email", claims.Email)
+	c.Set("role", claims.Role)
 }
 
 // RoleAuth middleware checks if the user has the required role
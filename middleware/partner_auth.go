@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// HashAPIKey returns the value stored for a partner API key
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// PartnerAuth verifies the X-API-Key header against active partners and sets partner info in context
+func PartnerAuth(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		apiKey := c.GetHeader("X-API-Key")
+		if apiKey == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "X-API-Key header required"})
+			c.Abort()
+			return
+		}
+
+		var partner models.Partner
+		if err := db.Where("api_key_hash = ? AND active = ?", HashAPIKey(apiKey), true).First(&partner).Error; err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+			c.Abort()
+			return
+		}
+
+		// Set partner info in context
+		c.Set("partner_id", partner.ID)
+		c.Set("partner_code", partner.Code)
+
+		c.Next()
+	}
+}
@@ -0,0 +1,10 @@
+package models
+
+// Amenity is an entry in the catalog of features a property can offer
+type Amenity struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	Code     string `json:"code" gorm:"uniqueIndex;not null"` // Stable identifier used in filters, e.g. "pool"
+	Name     string `json:"name"`
+	Category string `json:"category" gorm:"index"` // e.g. "essentials", "facilities", "safety"
+	IconKey  string `json:"icon_key"`              // Icon name for clients to render
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// PropertyBlock represents a date range during which a property cannot be booked
+// @Description Property block model
+type PropertyBlock struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	PropertyID uint      `json:"property_id" gorm:"index"` // Foreign key for the property
+	StartDate  time.Time `json:"start_date" gorm:"index"`
+	EndDate    time.Time `json:"end_date" gorm:"index"`
+	Note       string    `json:"note"`
+	Source     string    `json:"source" gorm:"default:'owner'"` // Who created the block
+	// Set for blocks imported from an external calendar
+	CalendarSourceID *uint  `json:"calendar_source_id" gorm:"index"`
+	ExternalUID      string `json:"external_uid" gorm:"index"`
+}
@@ -10,10 +10,36 @@ type Booking struct {
 	ID         uint      `json:"id" gorm:"primaryKey"`
 	PropertyID uint      `json:"property_id" gorm:"index"`
 	Property   Property  `gorm:"foreignKey:PropertyID"`
-	UserID     uint      `json:"user_id" gorm:"index"`
+	UserID     *uint     `json:"user_id" gorm:"index"` // Guest's account; nil for partner reservations
 	User       User      `gorm:"foreignKey:UserID"`
 	StartDate  time.Time `json:"start_date" gorm:"index"`
 	EndDate    time.Time `json:"end_date" gorm:"index"`
 	TotalPrice float64   `json:"total_price"`
 	Status     string    `json:"status" gorm:"default:'pending'"`
+	// Where the reservation came from: "direct" or the partner's code
+	Source      string `json:"source" gorm:"default:'direct'"`
+	PartnerID   *uint  `json:"partner_id" gorm:"index;uniqueIndex:idx_bookings_partner_ref"`
+	ExternalRef string `json:"external_ref" gorm:"uniqueIndex:idx_bookings_partner_ref"` // The partner's reservation ID
+	// Contact details of a partner reservation's guest, who has no account with us
+	GuestName  string `json:"guest_name,omitempty"`
+	GuestEmail string `json:"guest_email,omitempty"`
+	// Monthly stays are billed in installments instead of up front
+	StayType      string        `json:"stay_type" gorm:"default:'nightly'"` // nightly or monthly
+	Months        int           `json:"months"`
+	NoticeGivenAt *time.Time    `json:"notice_given_at"` // When early termination was requested
+	RefundDue     float64       `json:"refund_due"`      // Paid ahead for nights after an early termination
+	Installments  []Installment `json:"installments,omitempty" gorm:"foreignKey:BookingID"`
+}
+
+// IsGuest reports whether userID is the account that made the booking
+func (b Booking) IsGuest(userID uint) bool {
+	return b.UserID != nil && *b.UserID == userID
+}
+
+// Guest is the name of the booking's guest, from their account or the partner reservation
+func (b Booking) Guest() string {
+	if b.UserID == nil {
+		return b.GuestName
+	}
+	return b.User.Name
 }
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// CalendarSource represents an external iCalendar feed whose events block a property's dates
+// @Description Calendar source model
+type CalendarSource struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	PropertyID   uint       `json:"property_id" gorm:"index"` // Foreign key for the property
+	Name         string     `json:"name"`
+	URL          string     `json:"url"`
+	SyncStatus   string     `json:"sync_status" gorm:"default:'pending'"` // pending, ok or error
+	LastSyncedAt *time.Time `json:"last_synced_at"`
+	LastError    string     `json:"last_error"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+// Review statuses of a duplicate flag
+const (
+	DuplicateOpen      = "open"
+	DuplicateConfirmed = "confirmed"
+	DuplicateDismissed = "dismissed"
+)
+
+// DuplicateFlag marks two properties that are probably the same place listed
+// twice, for an admin to review. Reviewed pairs aren't flagged again.
+// @Description Duplicate flag model
+type DuplicateFlag struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	PropertyID    uint       `json:"property_id" gorm:"uniqueIndex:idx_duplicate_flags_pair"` // The newer of the two
+	DuplicateOfID uint       `json:"duplicate_of_id" gorm:"uniqueIndex:idx_duplicate_flags_pair;index"`
+	Score         float64    `json:"score"`                              // Similarity from 0 to 1
+	Reasons       []string   `json:"reasons" gorm:"serializer:json"`     // What the two have in common, e.g. "3 matching photos"
+	Status        string     `json:"status" gorm:"index;default:'open'"` // open, confirmed or dismissed
+	ReviewedByID  *uint      `json:"reviewed_by_id"`
+	ReviewedAt    *time.Time `json:"reviewed_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
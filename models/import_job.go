@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+)
+
+// ImportJob is a file of properties uploaded by an owner and created in the background
+// @Description Import job model
+type ImportJob struct {
+	ID            uint        `json:"id" gorm:"primaryKey"`
+	OwnerID       uint        `json:"owner_id" gorm:"index"`
+	Format        string      `json:"format"`                               // csv or jsonl
+	DryRun        bool        `json:"dry_run"`                              // Only validate the rows
+	Status        string      `json:"status" gorm:"index;default:'queued'"` // queued, running, done or failed
+	Input         string      `json:"-"`                                    // The uploaded file, cleared once processed
+	TotalRows     int         `json:"total_rows"`
+	ProcessedRows int         `json:"processed_rows"`
+	CreatedRows   int         `json:"created_rows"`
+	FailedRows    int         `json:"failed_rows"`
+	Error         string      `json:"error,omitempty"` // Why a failed job stopped
+	Rows          []ImportRow `json:"rows,omitempty" gorm:"foreignKey:ImportJobID"`
+	CreatedAt     time.Time   `json:"created_at"`
+	StartedAt     *time.Time  `json:"started_at"`
+	HeartbeatAt   *time.Time  `json:"-"` // Last sign of life from the worker running the job
+	FinishedAt    *time.Time  `json:"finished_at"`
+}
+
+// ImportRow is the outcome of one row of an import
+type ImportRow struct {
+	ID          uint     `json:"-" gorm:"primaryKey"`
+	ImportJobID uint     `json:"-" gorm:"uniqueIndex:idx_import_rows_line"`
+	Line        int      `json:"line" gorm:"uniqueIndex:idx_import_rows_line"` // Line of the row in the uploaded file
+	Name        string   `json:"name"`                                         // Of the property in the row
+	Status      string   `json:"status"`                                       // valid (in a dry run), created, invalid or failed
+	PropertyID  *uint    `json:"property_id,omitempty"`                        // The created property
+	Errors      []string `json:"errors,omitempty" gorm:"serializer:json"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+// Installment represents one monthly charge of a long-term stay
+// @Description Installment model
+type Installment struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	BookingID   uint       `json:"booking_id" gorm:"index"` // Foreign key for the booking
+	Sequence    int        `json:"sequence"`                // 1 for the first month
+	PeriodStart time.Time  `json:"period_start"`
+	PeriodEnd   time.Time  `json:"period_end"`
+	DueDate     time.Time  `json:"due_date" gorm:"index"`
+	Amount      float64    `json:"amount"`
+	Status      string     `json:"status" gorm:"index;default:'scheduled'"` // scheduled, due, paid or void
+	PaidAt      *time.Time `json:"paid_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
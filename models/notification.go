@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// Notification represents a message delivered to a user's inbox
+// @Description Notification model
+type Notification struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"index"`
+	Type      string     `json:"type" gorm:"index"` // e.g. waitlist_offer
+	Title     string     `json:"title"`
+	Message   string     `json:"message"`
+	Link      string     `json:"link"` // API path of the resource the notification is about
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// Partner represents an external distribution channel such as an online travel agency
+// @Description Partner model
+type Partner struct {
+	ID         uint             `json:"id" gorm:"primaryKey"`
+	Name       string           `json:"name"`
+	Code       string           `json:"code" gorm:"uniqueIndex"` // Short identifier recorded as the booking source
+	APIKeyHash string           `json:"-" gorm:"uniqueIndex"`    // SHA-256 of the partner's API key
+	WebhookURL string           `json:"webhook_url"`             // Where availability and rate updates are pushed
+	Active     bool             `json:"active" gorm:"default:true"`
+	Listings   []PartnerListing `json:"listings,omitempty" gorm:"foreignKey:PartnerID"`
+	CreatedAt  time.Time        `json:"created_at"`
+}
+
+// PartnerListing maps one of our properties to the partner's listing
+type PartnerListing struct {
+	ID                uint   `json:"id" gorm:"primaryKey"`
+	PartnerID         uint   `json:"partner_id" gorm:"uniqueIndex:idx_partner_property"`
+	PropertyID        uint   `json:"property_id" gorm:"uniqueIndex:idx_partner_property"`
+	ExternalListingID string `json:"external_listing_id"`
+}
@@ -0,0 +1,15 @@
+package models
+
+// Place is a node of the location hierarchy properties are filed under: a
+// country, a region within it or a city
+type Place struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	Name     string `json:"name"`
+	Kind     string `json:"kind"` // country, region or city
+	ParentID *uint  `json:"parent_id" gorm:"index"`
+	Parent   *Place `json:"parent,omitempty"`
+	// Normalized names from the root down, e.g. "indonesia/bali/ubud"
+	Path string `json:"-" gorm:"uniqueIndex"`
+	// Normalized name matched by autocomplete
+	SearchName string `json:"-" gorm:"index"`
+}
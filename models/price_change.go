@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// PriceChange records a change to one of the prices of a property
+// @Description Price change model
+type PriceChange struct {
+	ID         uint   `json:"id" gorm:"primaryKey"`
+	PropertyID uint   `json:"property_id" gorm:"index:idx_price_changes_property"`
+	Field      string `json:"field"` // price or monthly_price
+	// Nil when the price was first set, or was already set when history started
+	OldValue    *float64  `json:"old_value"`
+	NewValue    float64   `json:"new_value"`
+	ChangedByID *uint     `json:"changed_by_id"` // User who made the change; nil for prices found when history started
+	Source      string    `json:"source"`        // owner, import or backfill
+	ChangedAt   time.Time `json:"changed_at" gorm:"index:idx_price_changes_property"`
+}
@@ -1,5 +1,26 @@
 package models
 
+import (
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Listing statuses of a property
+const (
+	ListingDraft         = "draft"
+	ListingPendingReview = "pending_review"
+	ListingPublished     = "published"
+	ListingSuspended     = "suspended"
+)
+
+// PropertyTypes and SpaceTypes are the accepted values of Property.PropertyType and Property.SpaceType
+var (
+	PropertyTypes = []string{"apartment", "house", "villa", "cabin", "bungalow", "guesthouse", "hotel", "hostel", "other"}
+	SpaceTypes    = []string{"entire_place", "private_room", "shared_room"}
+)
+
 // Property represents a property in the system
 // @Description Property model
 type Property struct {
@@ -8,11 +29,61 @@ type Property struct {
 	Description string          `json:"description"`
 	Location    string          `json:"location"`
 	Price       float64         `json:"price"`
+	MaxGuests   int             `json:"max_guests"`                          // 0 when the owner hasn't set a limit
 	Images      []PropertyImage `json:"images" gorm:"foreignKey:PropertyID"` // Associated images
 	Amenities   string          `json:"amenities"`
 	OwnerID     uint            `json:"owner_id" gorm:"index"` // Foreign key for the owner
 	Owner       User            `gorm:"foreignKey:OwnerID"`
 	Bookings    []Booking
+	// Monthly stays are offered when MonthlyPrice is set
+	MonthlyPrice     float64 `json:"monthly_price"`
+	NoticePeriodDays int     `json:"notice_period_days" gorm:"default:30"` // Notice required to end a monthly stay early
+	// Secret token granting read access to the iCalendar export feed
+	CalendarToken string `json:"-" gorm:"index"`
+	// Coordinates for map and radius search; nil until set by the owner or geocoded.
+	// Guests see them rounded until their booking is confirmed, see Conceal.
+	Latitude  *float64 `json:"latitude" gorm:"index:idx_properties_coordinates"`
+	Longitude *float64 `json:"longitude" gorm:"index:idx_properties_coordinates"`
+	// Where Location falls in the place hierarchy, resolved when it is saved
+	PlaceID *uint  `json:"place_id" gorm:"index"`
+	Place   *Place `json:"place,omitempty"`
+	// Structured address; Location is what listings display. The city, region
+	// and country are public and searched by, while the street and postal code
+	// are only shown to the owner and to guests with a confirmed booking.
+	Street      string `json:"-"`
+	City        string `json:"city" gorm:"index"`
+	Region      string `json:"region" gorm:"index"`
+	PostalCode  string `json:"-"`
+	CountryCode string `json:"country_code"` // ISO 3166-1 alpha-2, e.g. ID
+	// What kind of place it is and how much of it guests get
+	PropertyType string     `json:"property_type" gorm:"index"`                     // apartment, house, villa, cabin, bungalow, guesthouse, hotel, hostel or other
+	SpaceType    string     `json:"space_type" gorm:"index;default:'entire_place'"` // entire_place, private_room or shared_room
+	HouseRules   HouseRules `json:"house_rules" gorm:"embedded;embeddedPrefix:rules_"`
+	// Only shown to guests with a confirmed booking, through the booking details
+	CheckInInstructions string `json:"-"`
+	// Language of the listing text, used to stem it for full-text search
+	Language string `json:"language" gorm:"default:en"`
+	// Locale of the name, description and house rules in responses to guests:
+	// Language or one of the owner's translations
+	Locale string `json:"locale,omitempty" gorm:"-"`
+	// Whether the authenticated guest saved the property into one of their wishlists
+	IsFavorited bool `json:"is_favorited" gorm:"-"`
+	// Amenities from the catalog, used for filtering and facets
+	AmenityList []Amenity `json:"amenity_list" gorm:"many2many:property_amenities;"`
+	// Moderation state; only published listings are shown to guests. Listings
+	// from before moderation default to published, new ones start as drafts.
+	Status       string     `json:"status" gorm:"index;default:'published'"` // draft, pending_review, published or suspended
+	ReviewNote   string     `json:"review_note"`                             // Reason given for the last rejection or suspension
+	SubmittedAt  *time.Time `json:"submitted_at"`
+	ReviewedAt   *time.Time `json:"reviewed_at"`
+	ReviewedByID *uint      `json:"reviewed_by_id"`
+	// Average of the guest reviews, kept up to date as they are left
+	RatingAverage float64 `json:"rating_average" gorm:"index"`
+	RatingCount   int     `json:"rating_count"`
+	// Archived listings are hidden from guests but keep their bookings
+	ArchivedAt *time.Time `json:"archived_at"`
+	// Plain timestamp rather than gorm.DeletedAt so booking history still preloads the property
+	DeletedAt *time.Time `json:"deleted_at,omitempty" gorm:"index"`
 }
 
 // PropertyImage represents an image associated with a property
@@ -20,4 +91,99 @@ type PropertyImage struct {
 	ID         uint   `json:"id" gorm:"primaryKey"`
 	PropertyID uint   `json:"property_id" gorm:"index"` // Foreign key for the property
 	ImageURL   string `json:"image_url"`
+	Position   int    `json:"position" gorm:"default:0"` // Display order, lowest first
+	Caption    string `json:"caption"`
+	AltText    string `json:"alt_text"`
+	IsCover    bool   `json:"is_cover" gorm:"default:false"` // Shown first and in listings; one per property
+	// Set for uploaded images; linked images only have ImageURL
+	StorageKey       string `json:"-"` // Storage prefix holding the generated variants
+	ThumbnailURL     string `json:"thumbnail_url,omitempty"`
+	MediumURL        string `json:"medium_url,omitempty"`
+	ThumbnailWebPURL string `json:"thumbnail_webp_url,omitempty"` // Set when a WebP encoder is configured
+	MediumWebPURL    string `json:"medium_webp_url,omitempty"`
+	Width            int    `json:"width,omitempty"`
+	Height           int    `json:"height,omitempty"`
+	// Perceptual hash of an uploaded image, the bits of media.DifferenceHash
+	// stored as a signed Postgres bigint, for spotting duplicate listings
+	PerceptualHash *int64 `json:"-" gorm:"index"`
+}
+
+// ListedSQL is the SQL condition matching the properties Listed reports, for
+// queries that can't use ListedScope such as raw subqueries
+const ListedSQL = "properties.status = '" + ListingPublished + "' AND properties.archived_at IS NULL AND properties.deleted_at IS NULL"
+
+// Listed reports whether guests can book the property: it is published and
+// neither archived nor deleted. ListedScope selects the same properties.
+func (p Property) Listed() bool {
+	return p.Status == ListingPublished && p.ArchivedAt == nil && p.DeletedAt == nil
+}
+
+// ListedScope limits a query on properties to those guests can see and book
+func ListedScope(db *gorm.DB) *gorm.DB {
+	return db.Where(ListedSQL)
+}
+
+// Address is the full address of a property
+type Address struct {
+	Street      string `json:"street"`
+	City        string `json:"city"`
+	Region      string `json:"region"`
+	PostalCode  string `json:"postal_code"`
+	CountryCode string `json:"country_code"`
+}
+
+// Address returns the full address of the property, including its private parts
+func (p Property) Address() Address {
+	return Address{Street: p.Street, City: p.City, Region: p.Region, PostalCode: p.PostalCode, CountryCode: p.CountryCode}
+}
+
+// SetAddress replaces the address of the property
+func (p *Property) SetAddress(a Address) {
+	p.Street, p.City, p.Region, p.PostalCode, p.CountryCode = a.Street, a.City, a.Region, a.PostalCode, a.CountryCode
+}
+
+// PublicLocation is where guests are told the property is: its city and
+// region, or its free-form location when the city isn't known
+func (p Property) PublicLocation() string {
+	switch {
+	case p.City == "":
+		return p.Location
+	case p.Region == "" || p.Region == p.City:
+		return p.City
+	}
+	return p.City + ", " + p.Region
+}
+
+// publicCoordinateScale rounds coordinates to two decimals, about a kilometre
+const publicCoordinateScale = 100
+
+// PublicLatitudeSQL and PublicLongitudeSQL are the coordinates rounded as
+// Conceal rounds them. Searches filter and measure distances on these, since
+// results computed from the exact ones would give the hidden location away.
+const (
+	PublicLatitudeSQL  = "CAST(ROUND(CAST(properties.latitude AS numeric), 2) AS double precision)"
+	PublicLongitudeSQL = "CAST(ROUND(CAST(properties.longitude AS numeric), 2) AS double precision)"
+)
+
+// Conceal hides what only the owner and guests with a confirmed booking may
+// see of the property: its coordinates are rounded and its free-form
+// location, which may hold the street, gives way to the public one
+func (p *Property) Conceal() {
+	p.Location = p.PublicLocation()
+	for _, c := range []**float64{&p.Latitude, &p.Longitude} {
+		if *c != nil {
+			rounded := math.Round(**c*publicCoordinateScale) / publicCoordinateScale
+			*c = &rounded
+		}
+	}
+}
+
+// HouseRules are the rules guests agree to when they book
+type HouseRules struct {
+	SmokingAllowed  bool   `json:"smoking_allowed"`
+	PartiesAllowed  bool   `json:"parties_allowed"`
+	PetsAllowed     bool   `json:"pets_allowed"`
+	QuietHoursStart string `json:"quiet_hours_start"` // HH:MM, empty when there are no quiet hours
+	QuietHoursEnd   string `json:"quiet_hours_end"`
+	Other           string `json:"other"` // Further rules in the listing's language
 }
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// PropertyTranslation holds the listing text of a property in another locale
+// @Description Property translation model
+type PropertyTranslation struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	PropertyID  uint      `json:"property_id" gorm:"uniqueIndex:idx_property_translations_locale"`
+	Locale      string    `json:"locale" gorm:"uniqueIndex:idx_property_translations_locale"` // BCP 47 tag, e.g. id or th
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	HouseRules  string    `json:"house_rules"` // Translation of the other house rules
+	UpdatedAt   time.Time `json:"updated_at"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+// Review is a guest's rating of a stay, left once the stay is over
+// @Description Review model
+type Review struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	BookingID  uint      `json:"booking_id" gorm:"uniqueIndex"` // One review per stay
+	PropertyID uint      `json:"property_id" gorm:"index"`
+	UserID     uint      `json:"user_id" gorm:"index"`
+	Rating     int       `json:"rating"` // 1 to 5 stars
+	Comment    string    `json:"comment"`
+	CreatedAt  time.Time `json:"created_at"`
+}
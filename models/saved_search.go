@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// SavedSearch is a property search a guest is alerted about when new
+// properties match it or matching properties get cheaper
+// @Description Saved search model
+type SavedSearch struct {
+	ID     uint   `json:"id" gorm:"primaryKey"`
+	UserID uint   `json:"user_id" gorm:"index"`
+	Name   string `json:"name"`
+	// Search parameters as a URL query string, e.g. location=Bali&max_price=150&guests=4
+	Query         string     `json:"query"`
+	AlertsEnabled bool       `json:"alerts_enabled" gorm:"index;default:true"`
+	LastCheckedAt *time.Time `json:"last_checked_at"` // Last time matches were compared; nil until the first check
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// SavedSearchMatch is a property seen matching a saved search, with its price
+// when it was last seen, to detect new matches and price drops
+type SavedSearchMatch struct {
+	SavedSearchID uint `gorm:"primaryKey;autoIncrement:false"`
+	PropertyID    uint `gorm:"primaryKey;autoIncrement:false"`
+	Price         float64
+	SeenAt        time.Time
+}
@@ -5,7 +5,7 @@ package models
 type User struct {
 	ID           uint    `json:"id" gorm:"primaryKey"`
 	Email        string  `json:"email" gorm:"unique"`
-	Password     string  `json:"password"`
+	Password     string  `json:"-"`
 	Name         string  `json:"name"`
 	Role         string  `json:"role"`
 	Phone        string  `json:"phone"`         // Added
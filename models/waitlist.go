@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// WaitlistEntry represents a guest's interest in dates that were fully booked
+// @Description Waitlist entry model
+type WaitlistEntry struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	PropertyID     uint       `json:"property_id" gorm:"index"`
+	UserID         uint       `json:"user_id" gorm:"index"`
+	StartDate      time.Time  `json:"start_date" gorm:"index"`
+	EndDate        time.Time  `json:"end_date" gorm:"index"`
+	Status         string     `json:"status" gorm:"index;default:'waiting'"` // waiting, offered, booked, expired or cancelled
+	OfferedAt      *time.Time `json:"offered_at"`
+	OfferExpiresAt *time.Time `json:"offer_expires_at"` // End of the priority window to book
+	CreatedAt      time.Time  `json:"created_at"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+// Wishlist is a named list of properties a guest saved to compare later
+// @Description Wishlist model
+type Wishlist struct {
+	ID     uint   `json:"id" gorm:"primaryKey"`
+	UserID uint   `json:"user_id" gorm:"index"`
+	Name   string `json:"name"`
+	// Secret token granting read access to the list through a shared link; empty when not shared
+	ShareToken string         `json:"-" gorm:"index"`
+	Items      []WishlistItem `json:"items"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
+
+// WishlistItem is a property saved into a wishlist
+type WishlistItem struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	WishlistID uint      `json:"wishlist_id" gorm:"uniqueIndex:idx_wishlist_items_property"`
+	PropertyID uint      `json:"property_id" gorm:"uniqueIndex:idx_wishlist_items_property;index"`
+	Property   *Property `json:"property,omitempty"`
+	Note       string    `json:"note"` // The guest's own note, e.g. "closest to the beach"
+	CreatedAt  time.Time `json:"created_at"`
+}
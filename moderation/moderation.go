@@ -0,0 +1,163 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/notifications"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidTransition = errors.New("the listing's status doesn't allow this")
+	ErrIncomplete        = errors.New("the listing is incomplete")
+	ErrReasonRequired    = errors.New("a reason is required")
+)
+
+// Moderator moves listings through review and tells owners what was decided.
+// Owners submit drafts for review; admins approve them, which publishes them,
+// or reject them back to drafts. Published listings can be suspended and are
+// submitted again once fixed, and go back to review when their owner edits
+// what guests are shown.
+type Moderator struct {
+	DB       *gorm.DB
+	Notifier notifications.Notifier
+}
+
+func NewModerator(db *gorm.DB, notifier notifications.Notifier) *Moderator {
+	return &Moderator{DB: db, Notifier: notifier}
+}
+
+// Missing lists the fields a listing needs before it can be reviewed
+func Missing(property models.Property) []string {
+	var missing []string
+	if strings.TrimSpace(property.Name) == "" {
+		missing = append(missing, "name")
+	}
+	if strings.TrimSpace(property.Description) == "" {
+		missing = append(missing, "description")
+	}
+	if strings.TrimSpace(property.Location) == "" {
+		missing = append(missing, "location")
+	}
+	if property.Price <= 0 {
+		missing = append(missing, "price")
+	}
+	return missing
+}
+
+// Submit asks for a draft or suspended listing to be reviewed
+func (m *Moderator) Submit(ctx context.Context, property *models.Property) error {
+	if missing := Missing(*property); len(missing) > 0 {
+		return fmt.Errorf("%w: %s missing", ErrIncomplete, strings.Join(missing, ", "))
+	}
+	return m.move(ctx, property, []string{models.ListingDraft, models.ListingSuspended}, map[string]interface{}{
+		"status":       models.ListingPendingReview,
+		"submitted_at": time.Now(),
+	})
+}
+
+// Approve publishes a listing waiting for review
+func (m *Moderator) Approve(ctx context.Context, property *models.Property, reviewerID uint) error {
+	if err := m.review(ctx, property, models.ListingPendingReview, models.ListingPublished, reviewerID, ""); err != nil {
+		return err
+	}
+	m.notify(ctx, property, "listing_approved", "Your listing is live",
+		fmt.Sprintf("%s was approved and can now be found and booked by guests.", property.Name))
+	return nil
+}
+
+// Reject sends a listing waiting for review back to its owner as a draft
+func (m *Moderator) Reject(ctx context.Context, property *models.Property, reviewerID uint, reason string) error {
+	if err := m.review(ctx, property, models.ListingPendingReview, models.ListingDraft, reviewerID, reason); err != nil {
+		return err
+	}
+	m.notify(ctx, property, "listing_rejected", "Your listing needs changes",
+		fmt.Sprintf("%s was not approved: %s. Update it and submit it for review again.", property.Name, property.ReviewNote))
+	return nil
+}
+
+// Suspend takes a published listing down until its owner resubmits it
+func (m *Moderator) Suspend(ctx context.Context, property *models.Property, reviewerID uint, reason string) error {
+	if err := m.review(ctx, property, models.ListingPublished, models.ListingSuspended, reviewerID, reason); err != nil {
+		return err
+	}
+	m.notify(ctx, property, "listing_suspended", "Your listing was suspended",
+		fmt.Sprintf("%s is hidden from guests: %s. Existing bookings are kept. Fix it and submit it for review again.", property.Name, property.ReviewNote))
+	return nil
+}
+
+// ContentChanged reports whether an edit changed what a listing could mislead
+// guests with: its name, description, location or address. Other edits, such
+// as prices, rules, amenities, photo captions, the cover photo or
+// translations, go live right away; new photos are reviewed when added.
+func ContentChanged(before, after models.Property) bool {
+	return before.Name != after.Name || before.Description != after.Description || before.Location != after.Location ||
+		before.Address() != after.Address()
+}
+
+// Edited sends a published listing back to review after its owner made a
+// change that needs it, see ContentChanged, so none is public unapproved. db is the
+// transaction saving the change. Listings that aren't published are left alone.
+func (m *Moderator) Edited(ctx context.Context, db *gorm.DB, property *models.Property) error {
+	if property.Status != models.ListingPublished {
+		return nil
+	}
+	now := time.Now()
+	if err := db.WithContext(ctx).Model(&models.Property{}).
+		Where("id = ? AND status = ?", property.ID, models.ListingPublished).
+		Updates(map[string]interface{}{"status": models.ListingPendingReview, "submitted_at": now}).Error; err != nil {
+		return err
+	}
+	property.Status, property.SubmittedAt = models.ListingPendingReview, &now
+	return nil
+}
+
+// review records an admin's decision; rejections and suspensions need a reason
+func (m *Moderator) review(ctx context.Context, property *models.Property, from, to string, reviewerID uint, reason string) error {
+	reason = strings.TrimSpace(reason)
+	if to != models.ListingPublished && reason == "" {
+		return ErrReasonRequired
+	}
+	return m.move(ctx, property, []string{from}, map[string]interface{}{
+		"status":         to,
+		"review_note":    reason,
+		"reviewed_at":    time.Now(),
+		"reviewed_by_id": reviewerID,
+	})
+}
+
+// move updates the listing if it is still in one of the from statuses, so
+// concurrent decisions can't both apply, then reloads it
+func (m *Moderator) move(ctx context.Context, property *models.Property, from []string, updates map[string]interface{}) error {
+	result := m.DB.WithContext(ctx).Model(&models.Property{}).
+		Where("id = ? AND status IN ?", property.ID, from).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: a %s listing can't become %s", ErrInvalidTransition,
+			strings.ReplaceAll(property.Status, "_", " "), strings.ReplaceAll(updates["status"].(string), "_", " "))
+	}
+	return m.DB.WithContext(ctx).First(property, property.ID).Error
+}
+
+// notify tells the owner about a decision. The decision stands if that fails,
+// so failures are only logged.
+func (m *Moderator) notify(ctx context.Context, property *models.Property, kind, title, message string) {
+	if err := m.Notifier.Notify(ctx, &models.Notification{
+		UserID:  property.OwnerID,
+		Type:    kind,
+		Title:   title,
+		Message: message,
+		Link:    fmt.Sprintf("/api/properties/%d/owner-details", property.ID),
+	}); err != nil {
+		log.Printf("Failed to notify owner of property %d: %v", property.ID, err)
+	}
+}
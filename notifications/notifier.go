@@ -0,0 +1,31 @@
+package notifications
+
+import (
+	"context"
+	"log"
+
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"gorm.io/gorm"
+)
+
+// Notifier delivers notifications to users
+type Notifier interface {
+	Notify(ctx context.Context, notification *models.Notification) error
+}
+
+// DBNotifier stores notifications in the user's in-app inbox
+type DBNotifier struct {
+	DB *gorm.DB
+}
+
+func NewDBNotifier(db *gorm.DB) *DBNotifier {
+	return &DBNotifier{DB: db}
+}
+
+func (n *DBNotifier) Notify(ctx context.Context, notification *models.Notification) error {
+	if err := n.DB.WithContext(ctx).Create(notification).Error; err != nil {
+		return err
+	}
+	log.Printf("Notified user %d: %s", notification.UserID, notification.Title)
+	return nil
+}
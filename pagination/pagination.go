@@ -0,0 +1,106 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+var (
+	ErrInvalidLimit  = fmt.Errorf("limit must be a number between 1 and %d", MaxLimit)
+	ErrInvalidCursor = errors.New("cursor is invalid or belongs to a different sort")
+)
+
+// Cursor marks the last row of a page. Clients get it encoded and pass it back
+// unchanged to fetch the next page.
+type Cursor struct {
+	Sort string  `json:"s,omitempty"`
+	Key  float64 `json:"k,omitempty"` // Sort key of the last row, for sorts not by id alone
+	ID   uint    `json:"id"`
+}
+
+// Encode returns the opaque form of the cursor handed to clients
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Decode reads a cursor produced by Encode
+func Decode(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// Page is the slice of a list a client asked for
+type Page struct {
+	Sort  string
+	Limit int
+	After *Cursor // Nil for the first page
+}
+
+// Parse reads the limit and cursor query values for a list ordered by sort.
+// Limits above MaxLimit are capped, and cursors issued for another sort are rejected.
+func Parse(limit, cursor, sort string) (Page, error) {
+	page := Page{Sort: sort, Limit: DefaultLimit}
+	if limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			return Page{}, ErrInvalidLimit
+		}
+		page.Limit = min(n, MaxLimit)
+	}
+	if cursor != "" {
+		after, err := Decode(cursor)
+		if err != nil {
+			return Page{}, err
+		}
+		if after.Sort != sort {
+			return Page{}, ErrInvalidCursor
+		}
+		page.After = after
+	}
+	return page, nil
+}
+
+// Fetch is the number of rows to load for the page: one more than the limit
+// tells whether another page follows
+func (p Page) Fetch() int {
+	return p.Limit + 1
+}
+
+// Meta describes a page in list responses
+type Meta struct {
+	Limit         int    `json:"limit"`
+	HasMore       bool   `json:"has_more"`
+	NextCursor    string `json:"next_cursor,omitempty"`
+	TotalEstimate int64  `json:"total_estimate"` // Rows matching the whole list; may drift while paging
+}
+
+// Trim cuts rows loaded with Fetch down to the page and returns its meta.
+// cursor builds the cursor for a row, and is only called for the last row kept.
+func Trim[T any](rows []T, page Page, total int64, cursor func(T) Cursor) ([]T, Meta) {
+	meta := Meta{Limit: page.Limit, TotalEstimate: total}
+	if len(rows) <= page.Limit {
+		return rows, meta
+	}
+	rows = rows[:page.Limit]
+	next := cursor(rows[len(rows)-1])
+	next.Sort = page.Sort
+	meta.HasMore = true
+	meta.NextCursor = next.Encode()
+	return rows, meta
+}
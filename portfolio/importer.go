@@ -0,0 +1,208 @@
+package portfolio
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bookaroo/bookaroo-platform-be/amenities"
+	"github.com/bookaroo/bookaroo-platform-be/duplicates"
+	"github.com/bookaroo/bookaroo-platform-be/geo"
+	"github.com/bookaroo/bookaroo-platform-be/locations"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/pricing"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MaxRows is the largest number of properties one import may hold
+const MaxRows = 1000
+
+// progressEvery is how many rows are processed between progress updates
+const progressEvery = 10
+
+// leaseTimeout is how long a running job may go without a progress update
+// before another worker takes it over, e.g. after its instance was stopped
+const leaseTimeout = 5 * time.Minute
+
+// Importer creates the properties of uploaded files in the background
+type Importer struct {
+	DB         *gorm.DB
+	Geocoder   geo.Geocoder
+	Duplicates *duplicates.Detector
+}
+
+func NewImporter(db *gorm.DB, geocoder geo.Geocoder) *Importer {
+	return &Importer{DB: db, Geocoder: geocoder, Duplicates: duplicates.NewDetector(db)}
+}
+
+// RunQueued processes the queued imports, oldest first. Each is claimed before
+// it is processed, so several API instances can share the queue. Running jobs
+// whose worker stopped sending progress are claimed again and resumed.
+func (im *Importer) RunQueued(ctx context.Context) error {
+	for ctx.Err() == nil {
+		var job models.ImportJob
+		now := time.Now()
+		if err := im.DB.WithContext(ctx).Raw(`UPDATE import_jobs SET status = 'running', started_at = COALESCE(started_at, ?), heartbeat_at = ?
+			WHERE id = (SELECT id FROM import_jobs
+				WHERE status = 'queued' OR (status = 'running' AND COALESCE(heartbeat_at, started_at) < ?)
+				ORDER BY id LIMIT 1 FOR UPDATE SKIP LOCKED)
+			RETURNING *`, now, now, now.Add(-leaseTimeout)).Scan(&job).Error; err != nil {
+			return err
+		}
+		if job.ID == 0 {
+			return nil
+		}
+		if err := im.Process(ctx, &job); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// Process validates every row of a claimed job and, unless it is a dry run,
+// creates the properties of the valid rows as drafts. Rows are independent:
+// an invalid row doesn't stop the others. Each row is recorded once, in the
+// same transaction as its property, so a resumed job skips the rows already
+// recorded and never creates a property twice.
+func (im *Importer) Process(ctx context.Context, job *models.ImportJob) error {
+	db := im.DB.WithContext(ctx)
+
+	rows, err := Read(job.Format, []byte(job.Input))
+	if err != nil {
+		return im.finish(db, job, "failed", err.Error())
+	}
+	job.TotalRows = len(rows)
+
+	var recorded []models.ImportRow
+	if err := db.Select("line", "status").Where("import_job_id = ?", job.ID).Find(&recorded).Error; err != nil {
+		return err
+	}
+	done := make(map[int]bool, len(recorded))
+	job.ProcessedRows, job.CreatedRows, job.FailedRows = 0, 0, 0
+	for _, row := range recorded {
+		done[row.Line] = true
+		tally(job, row.Status)
+	}
+
+	for _, row := range rows {
+		if done[row.Line] {
+			continue
+		}
+		result := im.importRow(ctx, job, row)
+		if result.Status != "created" {
+			// Rows recorded meanwhile by a worker whose lease ran out are kept
+			if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&result).Error; err != nil {
+				return err
+			}
+		}
+
+		tally(job, result.Status)
+		if job.ProcessedRows%progressEvery == 0 {
+			now := time.Now()
+			job.HeartbeatAt = &now
+			if err := db.Model(job).Select("total_rows", "processed_rows", "created_rows", "failed_rows", "heartbeat_at").Updates(job).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	return im.finish(db, job, "done", "")
+}
+
+// importRow validates one row and creates its property
+func (im *Importer) importRow(ctx context.Context, job *models.ImportJob, row Row) models.ImportRow {
+	result := models.ImportRow{ImportJobID: job.ID, Line: row.Line, Name: row.Record.Name, Errors: row.Errors}
+	property, problems := row.Record.Validate(job.OwnerID)
+	result.Errors = append(result.Errors, problems...)
+
+	codes := amenities.ParseCodes(strings.Join(row.Record.AmenityCodes, ","))
+	amenityList, err := amenities.Lookup(im.DB.WithContext(ctx), codes)
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+	}
+	// Like the API, rows without codes link the catalog entries named in their free-form amenities
+	if len(codes) == 0 && err == nil {
+		if amenityList, err = amenities.Named(im.DB.WithContext(ctx), row.Record.Amenities); err != nil {
+			result.Errors = append(result.Errors, "the amenities couldn't be looked up")
+		}
+	}
+
+	if len(result.Errors) > 0 {
+		result.Status = "invalid"
+		return result
+	}
+	if job.DryRun {
+		result.Status = "valid"
+		return result
+	}
+
+	property.AmenityList = amenityList
+	if err := im.create(ctx, &property, &result); err != nil {
+		log.Printf("Failed to import line %d of import %d: %v", row.Line, job.ID, err)
+		result.Status = "failed"
+		result.PropertyID = nil
+		result.Errors = []string{"the property couldn't be saved"}
+		return result
+	}
+
+	// Imports are where the same place most easily ends up listed twice
+	if _, err := im.Duplicates.Check(ctx, property.ID); err != nil {
+		log.Printf("Failed to check property %d for duplicates: %v", property.ID, err)
+	}
+	return result
+}
+
+// create geocodes the property unless it has coordinates, files it under its
+// place and saves it along with the row it came from
+func (im *Importer) create(ctx context.Context, property *models.Property, result *models.ImportRow) error {
+	if property.Latitude == nil {
+		point, err := im.Geocoder.Geocode(ctx, property.Location)
+		if err == nil {
+			property.Latitude, property.Longitude = &point.Lat, &point.Lng
+		} else if !errors.Is(err, geo.ErrNotFound) {
+			log.Printf("Failed to geocode %q: %v", property.Location, err)
+		}
+	}
+
+	db := im.DB.WithContext(ctx)
+	place, err := locations.ResolveProperty(db, *property)
+	if err != nil {
+		return err
+	}
+	if place != nil {
+		property.PlaceID = &place.ID
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(property).Error; err != nil {
+			return err
+		}
+		if err := pricing.Record(tx, nil, *property, property.OwnerID, pricing.SourceImport); err != nil {
+			return err
+		}
+		result.Status, result.PropertyID = "created", &property.ID
+		return tx.Create(result).Error
+	})
+}
+
+// tally counts a processed row of the job by its outcome
+func tally(job *models.ImportJob, status string) {
+	job.ProcessedRows++
+	switch status {
+	case "created":
+		job.CreatedRows++
+	case "invalid", "failed":
+		job.FailedRows++
+	}
+}
+
+// finish records the outcome of a job and drops its uploaded file
+func (im *Importer) finish(db *gorm.DB, job *models.ImportJob, status, reason string) error {
+	now := time.Now()
+	job.Status, job.Error, job.FinishedAt, job.Input = status, reason, &now, ""
+	return db.Model(job).
+		Select("status", "error", "finished_at", "input", "total_rows", "processed_rows", "created_rows", "failed_rows").
+		Updates(job).Error
+}
@@ -0,0 +1,492 @@
+package portfolio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bookaroo/bookaroo-platform-be/geo"
+	"github.com/bookaroo/bookaroo-platform-be/i18n"
+	"github.com/bookaroo/bookaroo-platform-be/locations"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/search"
+)
+
+// Formats of imported and exported files
+const (
+	FormatCSV        = "csv"
+	FormatJSONLines  = "jsonl"
+	listSeparator    = "|" // Between the values of a list in a CSV cell
+	quietHoursLayout = "15:04"
+)
+
+var (
+	ErrUnknownFormat = errors.New("format must be csv or jsonl")
+	ErrNoRows        = errors.New("the file has no properties")
+)
+
+// Record is a property as one row of an imported or exported file. CSV files
+// have a header naming the columns, which are the JSON field names; lists are
+// separated by "|" within a cell.
+type Record struct {
+	ID                  uint     `json:"id,omitempty"`     // Exported for reference; ignored on import
+	Status              string   `json:"status,omitempty"` // Exported for reference; imported properties start as drafts
+	Name                string   `json:"name"`
+	Description         string   `json:"description"`
+	Location            string   `json:"location"`
+	Street              string   `json:"street"`
+	City                string   `json:"city"` // Read from location when omitted
+	Region              string   `json:"region"`
+	PostalCode          string   `json:"postal_code"`
+	CountryCode         string   `json:"country_code"`
+	Price               float64  `json:"price"`
+	MonthlyPrice        float64  `json:"monthly_price"`
+	MaxGuests           int      `json:"max_guests"`
+	NoticePeriodDays    int      `json:"notice_period_days"`
+	Amenities           string   `json:"amenities"`
+	AmenityCodes        []string `json:"amenity_codes"`
+	Language            string   `json:"language"`
+	Latitude            *float64 `json:"latitude"`
+	Longitude           *float64 `json:"longitude"`
+	PropertyType        string   `json:"property_type"`
+	SpaceType           string   `json:"space_type"`
+	SmokingAllowed      bool     `json:"smoking_allowed"`
+	PartiesAllowed      bool     `json:"parties_allowed"`
+	PetsAllowed         bool     `json:"pets_allowed"`
+	QuietHoursStart     string   `json:"quiet_hours_start"`
+	QuietHoursEnd       string   `json:"quiet_hours_end"`
+	HouseRules          string   `json:"house_rules"`
+	CheckInInstructions string   `json:"check_in_instructions"`
+	ImageURLs           []string `json:"image_urls"`
+}
+
+// Columns of a CSV file, in the order they are exported
+var Columns = []string{
+	"id", "status", "name", "description", "location", "street", "city", "region", "postal_code",
+	"country_code", "price", "monthly_price", "max_guests",
+	"notice_period_days", "amenities", "amenity_codes", "language", "latitude", "longitude",
+	"property_type", "space_type", "smoking_allowed", "parties_allowed", "pets_allowed",
+	"quiet_hours_start", "quiet_hours_end", "house_rules", "check_in_instructions", "image_urls",
+}
+
+// Row is a record read from a file, with the problems found reading it
+type Row struct {
+	Line   int
+	Record Record
+	Errors []string
+}
+
+// Read parses a file in the given format. Problems with single rows are
+// reported on the row; an error is returned when the file can't be read at all.
+func Read(format string, data []byte) ([]Row, error) {
+	var rows []Row
+	var err error
+	switch format {
+	case FormatCSV:
+		rows, err = readCSV(data)
+	case FormatJSONLines:
+		rows, err = readJSONLines(data)
+	default:
+		return nil, ErrUnknownFormat
+	}
+	if err == nil && len(rows) == 0 {
+		err = ErrNoRows
+	}
+	return rows, err
+}
+
+func readCSV(data []byte) ([]Row, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.TrimLeadingSpace = true
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading the CSV header: %w", err)
+	}
+	for i, column := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+		if !slices.Contains(Columns, header[i]) {
+			return nil, fmt.Errorf("unknown column %q; columns are %s", column, strings.Join(Columns, ", "))
+		}
+	}
+	r.FieldsPerRecord = len(header)
+
+	var rows []Row
+	for {
+		fields, err := r.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) && errors.Is(parseErr.Err, csv.ErrFieldCount) {
+			rows = append(rows, Row{Line: parseErr.StartLine, Errors: []string{fmt.Sprintf("expected %d columns, found %d", len(header), len(fields))}})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := r.FieldPos(0)
+		row := Row{Line: line}
+		for i, value := range fields {
+			if err := row.Record.set(header[i], strings.TrimSpace(value)); err != nil {
+				row.Errors = append(row.Errors, fmt.Sprintf("%s: %v", header[i], err))
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+// set stores the value of a CSV column
+func (r *Record) set(column, value string) error {
+	var err error
+	switch column {
+	case "id", "status":
+		// Only exported
+	case "name":
+		r.Name = value
+	case "description":
+		r.Description = value
+	case "location":
+		r.Location = value
+	case "street":
+		r.Street = value
+	case "city":
+		r.City = value
+	case "region":
+		r.Region = value
+	case "postal_code":
+		r.PostalCode = value
+	case "country_code":
+		r.CountryCode = value
+	case "price":
+		r.Price, err = parseFloat(value)
+	case "monthly_price":
+		r.MonthlyPrice, err = parseFloat(value)
+	case "max_guests":
+		r.MaxGuests, err = parseInt(value)
+	case "notice_period_days":
+		r.NoticePeriodDays, err = parseInt(value)
+	case "amenities":
+		r.Amenities = value
+	case "amenity_codes":
+		r.AmenityCodes = splitList(value)
+	case "language":
+		r.Language = value
+	case "latitude":
+		r.Latitude, err = parseOptionalFloat(value)
+	case "longitude":
+		r.Longitude, err = parseOptionalFloat(value)
+	case "property_type":
+		r.PropertyType = value
+	case "space_type":
+		r.SpaceType = value
+	case "smoking_allowed":
+		r.SmokingAllowed, err = parseBool(value)
+	case "parties_allowed":
+		r.PartiesAllowed, err = parseBool(value)
+	case "pets_allowed":
+		r.PetsAllowed, err = parseBool(value)
+	case "quiet_hours_start":
+		r.QuietHoursStart = value
+	case "quiet_hours_end":
+		r.QuietHoursEnd = value
+	case "house_rules":
+		r.HouseRules = value
+	case "check_in_instructions":
+		r.CheckInInstructions = value
+	case "image_urls":
+		r.ImageURLs = splitList(value)
+	}
+	return err
+}
+
+func readJSONLines(data []byte) ([]Row, error) {
+	var rows []Row
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		row := Row{Line: line}
+		decoder := json.NewDecoder(bytes.NewReader(text))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&row.Record); err != nil {
+			row.Errors = []string{"invalid JSON: " + err.Error()}
+		}
+		rows = append(rows, row)
+	}
+	return rows, scanner.Err()
+}
+
+// Validate checks a record and returns the property to create for owner,
+// without its amenities, which are looked up by code in the catalog
+func (r Record) Validate(ownerID uint) (models.Property, []string) {
+	var problems []string
+	required := map[string]string{"name": r.Name, "description": r.Description, "location": r.Location}
+	for _, field := range []string{"name", "description", "location"} {
+		if strings.TrimSpace(required[field]) == "" {
+			problems = append(problems, field+" is required")
+		}
+	}
+	if r.Price <= 0 {
+		problems = append(problems, "price must be greater than 0")
+	}
+	if r.MonthlyPrice < 0 || r.MaxGuests < 0 || r.NoticePeriodDays < 0 {
+		problems = append(problems, "monthly_price, max_guests and notice_period_days can't be negative")
+	}
+
+	language := r.Language
+	if language == "" {
+		language = search.DefaultLanguage
+	}
+	if locale, err := i18n.ParseLocale(language); err != nil {
+		problems = append(problems, "language must be a language tag such as en, id or th")
+	} else {
+		language = locale
+	}
+	if r.PropertyType != "" && !slices.Contains(models.PropertyTypes, r.PropertyType) {
+		problems = append(problems, "property_type must be one of "+strings.Join(models.PropertyTypes, ", "))
+	}
+	if r.SpaceType != "" && !slices.Contains(models.SpaceTypes, r.SpaceType) {
+		problems = append(problems, "space_type must be one of "+strings.Join(models.SpaceTypes, ", "))
+	}
+	if (r.QuietHoursStart == "") != (r.QuietHoursEnd == "") {
+		problems = append(problems, "quiet_hours_start and quiet_hours_end must be set together")
+	} else if r.QuietHoursStart != "" && (!validTime(r.QuietHoursStart) || !validTime(r.QuietHoursEnd)) {
+		problems = append(problems, "quiet hours must be given as HH:MM")
+	}
+	if r.City == "" && (r.Street != "" || r.Region != "" || r.PostalCode != "" || r.CountryCode != "") {
+		problems = append(problems, "city is required with the other address fields")
+	}
+	if r.CountryCode != "" && !validCountryCode(r.CountryCode) {
+		problems = append(problems, "country_code must be a two-letter ISO 3166-1 code, e.g. ID")
+	}
+	if (r.Latitude == nil) != (r.Longitude == nil) ||
+		r.Latitude != nil && !(geo.Point{Lat: *r.Latitude, Lng: *r.Longitude}).Valid() {
+		problems = append(problems, "latitude and longitude must both be valid coordinates")
+	}
+	for _, u := range r.ImageURLs {
+		if parsed, err := url.Parse(u); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			problems = append(problems, fmt.Sprintf("image URL %q must be an http or https URL", u))
+		}
+	}
+
+	property := models.Property{
+		Name:             strings.TrimSpace(r.Name),
+		Description:      strings.TrimSpace(r.Description),
+		Location:         strings.TrimSpace(r.Location),
+		Price:            r.Price,
+		MonthlyPrice:     r.MonthlyPrice,
+		MaxGuests:        r.MaxGuests,
+		NoticePeriodDays: r.NoticePeriodDays,
+		Amenities:        r.Amenities,
+		Language:         language,
+		Latitude:         r.Latitude,
+		Longitude:        r.Longitude,
+		PropertyType:     r.PropertyType,
+		SpaceType:        r.SpaceType,
+		HouseRules: models.HouseRules{
+			SmokingAllowed:  r.SmokingAllowed,
+			PartiesAllowed:  r.PartiesAllowed,
+			PetsAllowed:     r.PetsAllowed,
+			QuietHoursStart: r.QuietHoursStart,
+			QuietHoursEnd:   r.QuietHoursEnd,
+			Other:           strings.TrimSpace(r.HouseRules),
+		},
+		CheckInInstructions: strings.TrimSpace(r.CheckInInstructions),
+		Status:              models.ListingDraft,
+		OwnerID:             ownerID,
+	}
+	if r.City != "" {
+		property.SetAddress(models.Address{
+			Street:      strings.TrimSpace(r.Street),
+			City:        strings.TrimSpace(r.City),
+			Region:      strings.TrimSpace(r.Region),
+			PostalCode:  strings.TrimSpace(r.PostalCode),
+			CountryCode: strings.ToUpper(r.CountryCode),
+		})
+	} else {
+		property.SetAddress(locations.Address(property.Location))
+	}
+	for i, u := range r.ImageURLs {
+		property.Images = append(property.Images, models.PropertyImage{ImageURL: u, Position: i, IsCover: i == 0})
+	}
+	return property, problems
+}
+
+// FromProperty returns the record exporting a property with its images and amenities loaded
+func FromProperty(p models.Property) Record {
+	r := Record{
+		ID:                  p.ID,
+		Status:              p.Status,
+		Name:                p.Name,
+		Description:         p.Description,
+		Location:            p.Location,
+		Street:              p.Street,
+		City:                p.City,
+		Region:              p.Region,
+		PostalCode:          p.PostalCode,
+		CountryCode:         p.CountryCode,
+		Price:               p.Price,
+		MonthlyPrice:        p.MonthlyPrice,
+		MaxGuests:           p.MaxGuests,
+		NoticePeriodDays:    p.NoticePeriodDays,
+		Amenities:           p.Amenities,
+		AmenityCodes:        []string{},
+		Language:            p.Language,
+		Latitude:            p.Latitude,
+		Longitude:           p.Longitude,
+		PropertyType:        p.PropertyType,
+		SpaceType:           p.SpaceType,
+		SmokingAllowed:      p.HouseRules.SmokingAllowed,
+		PartiesAllowed:      p.HouseRules.PartiesAllowed,
+		PetsAllowed:         p.HouseRules.PetsAllowed,
+		QuietHoursStart:     p.HouseRules.QuietHoursStart,
+		QuietHoursEnd:       p.HouseRules.QuietHoursEnd,
+		HouseRules:          p.HouseRules.Other,
+		CheckInInstructions: p.CheckInInstructions,
+		ImageURLs:           []string{},
+	}
+	for _, a := range p.AmenityList {
+		r.AmenityCodes = append(r.AmenityCodes, a.Code)
+	}
+	for _, img := range p.Images {
+		r.ImageURLs = append(r.ImageURLs, img.ImageURL)
+	}
+	return r
+}
+
+// Write exports records in the given format
+func Write(w io.Writer, format string, records []Record) error {
+	switch format {
+	case FormatCSV:
+		return writeCSV(w, records)
+	case FormatJSONLines:
+		encoder := json.NewEncoder(w)
+		for _, r := range records {
+			if err := encoder.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return ErrUnknownFormat
+	}
+}
+
+func writeCSV(w io.Writer, records []Record) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(Columns); err != nil {
+		return err
+	}
+	for _, r := range records {
+		if err := cw.Write([]string{
+			strconv.FormatUint(uint64(r.ID), 10), r.Status, r.Name, r.Description, r.Location,
+			r.Street, r.City, r.Region, r.PostalCode, r.CountryCode,
+			formatFloat(r.Price), formatFloat(r.MonthlyPrice), strconv.Itoa(r.MaxGuests),
+			strconv.Itoa(r.NoticePeriodDays), r.Amenities, strings.Join(r.AmenityCodes, listSeparator),
+			r.Language, formatOptionalFloat(r.Latitude), formatOptionalFloat(r.Longitude),
+			r.PropertyType, r.SpaceType, strconv.FormatBool(r.SmokingAllowed),
+			strconv.FormatBool(r.PartiesAllowed), strconv.FormatBool(r.PetsAllowed),
+			r.QuietHoursStart, r.QuietHoursEnd, r.HouseRules, r.CheckInInstructions,
+			strings.Join(r.ImageURLs, listSeparator),
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func parseFloat(s string) (float64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, errors.New("must be a number")
+	}
+	return f, nil
+}
+
+func parseOptionalFloat(s string) (*float64, error) {
+	if s == "" {
+		return nil, nil
+	}
+	f, err := parseFloat(s)
+	return &f, err
+}
+
+func parseInt(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errors.New("must be a whole number")
+	}
+	return n, nil
+}
+
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "", "false", "no", "0":
+		return false, nil
+	case "true", "yes", "1":
+		return true, nil
+	}
+	return false, errors.New("must be true or false")
+}
+
+func splitList(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, listSeparator) {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func validTime(s string) bool {
+	_, err := time.Parse(quietHoursLayout, s)
+	return err == nil
+}
+
+// validCountryCode reports whether s looks like an ISO 3166-1 alpha-2 code
+func validCountryCode(s string) bool {
+	if len(s) != 2 {
+		return false
+	}
+	for _, r := range strings.ToUpper(s) {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func formatOptionalFloat(f *float64) string {
+	if f == nil {
+		return ""
+	}
+	return formatFloat(*f)
+}
@@ -0,0 +1,78 @@
+package pricing
+
+import (
+	"time"
+
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"gorm.io/gorm"
+)
+
+// Fields of a property whose changes are recorded
+const (
+	FieldPrice        = "price"         // Nightly rate
+	FieldMonthlyPrice = "monthly_price" // Rate of monthly stays; 0 when they aren't offered
+)
+
+// Sources of a price change
+const (
+	SourceOwner    = "owner"    // Set by the owner through the API
+	SourceImport   = "import"   // Set by a bulk import
+	SourceBackfill = "backfill" // Already set when history started
+)
+
+// Changes lists the prices of after that differ from before. A nil before
+// stands for a property being created, whose prices that are set are listed.
+func Changes(before *models.Property, after models.Property) []models.PriceChange {
+	var changes []models.PriceChange
+	add := func(field string, old, new float64) {
+		change := models.PriceChange{PropertyID: after.ID, Field: field, NewValue: new}
+		if before != nil {
+			if old == new {
+				return
+			}
+			change.OldValue = &old
+		} else if new == 0 {
+			return
+		}
+		changes = append(changes, change)
+	}
+
+	var old models.Property
+	if before != nil {
+		old = *before
+	}
+	add(FieldPrice, old.Price, after.Price)
+	add(FieldMonthlyPrice, old.MonthlyPrice, after.MonthlyPrice)
+	return changes
+}
+
+// Record stores the changes from before to after, made by actorID through
+// source. It should run in the transaction saving the property.
+func Record(db *gorm.DB, before *models.Property, after models.Property, actorID uint, source string) error {
+	changes := Changes(before, after)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	for i := range changes {
+		changes[i].ChangedByID = &actorID
+		changes[i].Source = source
+		changes[i].ChangedAt = now
+	}
+	return db.Create(&changes).Error
+}
+
+// Backfill starts the history of properties created before it was recorded
+// from the prices they have now
+func Backfill(db *gorm.DB) error {
+	for _, field := range []string{FieldPrice, FieldMonthlyPrice} {
+		if err := db.Exec(`INSERT INTO price_changes (property_id, field, new_value, source, changed_at)
+			SELECT p.id, ?, p.`+field+`, ?, NOW() FROM properties p
+			WHERE p.`+field+` > 0 AND NOT EXISTS (SELECT 1 FROM price_changes pc WHERE pc.property_id = p.id AND pc.field = ?)`,
+			field, SourceBackfill, field).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package recommend
+
+import (
+	"math"
+	"sort"
+
+	"github.com/bookaroo/bookaroo-platform-be/geo"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+)
+
+// Scorer rates how similar a candidate is to the property a guest is viewing,
+// from 0 (nothing alike) to 1 (alike in every way it looks at). Scorers must
+// be deterministic: the same pair always gets the same score.
+type Scorer interface {
+	Score(viewed, candidate models.Property) float64
+}
+
+// ScorerFunc lets an ordinary function be used as a Scorer
+type ScorerFunc func(viewed, candidate models.Property) float64
+
+func (f ScorerFunc) Score(viewed, candidate models.Property) float64 {
+	return f(viewed, candidate)
+}
+
+// Factor is a scorer with the weight of its score in a Weighted combination
+type Factor struct {
+	Scorer Scorer
+	Weight float64
+}
+
+// Weighted scores by the weighted average of its factors
+type Weighted []Factor
+
+func (w Weighted) Score(viewed, candidate models.Property) float64 {
+	total, weights := 0.0, 0.0
+	for _, f := range w {
+		total += f.Weight * f.Scorer.Score(viewed, candidate)
+		weights += f.Weight
+	}
+	if weights == 0 {
+		return 0
+	}
+	return total / weights
+}
+
+// Default weighs location the most, then price, amenities and capacity
+func Default() Scorer {
+	return Weighted{
+		{Scorer: Proximity{ScaleKm: 10}, Weight: 0.4},
+		{Scorer: ScorerFunc(PriceBand), Weight: 0.25},
+		{Scorer: ScorerFunc(AmenityOverlap), Weight: 0.2},
+		{Scorer: ScorerFunc(Capacity), Weight: 0.15},
+	}
+}
+
+// Proximity favours nearby properties: a candidate ScaleKm away scores 0.5 and
+// the score halves again with every further ScaleKm. Without coordinates on
+// both sides, properties in the same place score 1.
+type Proximity struct {
+	ScaleKm float64
+}
+
+func (p Proximity) Score(viewed, candidate models.Property) float64 {
+	if viewed.Latitude == nil || viewed.Longitude == nil || candidate.Latitude == nil || candidate.Longitude == nil {
+		if viewed.PlaceID != nil && candidate.PlaceID != nil && *viewed.PlaceID == *candidate.PlaceID {
+			return 1
+		}
+		return 0
+	}
+	km := geo.DistanceKm(
+		geo.Point{Lat: *viewed.Latitude, Lng: *viewed.Longitude},
+		geo.Point{Lat: *candidate.Latitude, Lng: *candidate.Longitude},
+	)
+	return math.Pow(0.5, km/p.ScaleKm)
+}
+
+// PriceBand scores the ratio of the lower nightly price to the higher one, so
+// properties twice as expensive, or half as, score 0.5
+func PriceBand(viewed, candidate models.Property) float64 {
+	return ratio(viewed.Price, candidate.Price)
+}
+
+// AmenityOverlap is the share of the amenities of either property that both offer
+func AmenityOverlap(viewed, candidate models.Property) float64 {
+	offered := make(map[uint]bool, len(viewed.AmenityList))
+	for _, a := range viewed.AmenityList {
+		offered[a.ID] = true
+	}
+	both, union := 0, len(offered)
+	for _, a := range candidate.AmenityList {
+		if offered[a.ID] {
+			both++
+		} else {
+			union++
+		}
+	}
+	if union == 0 {
+		return 0
+	}
+	return float64(both) / float64(union)
+}
+
+// Capacity scores the ratio of the smaller guest limit to the larger one.
+// Properties without a limit are taken to fit any group, half as surely.
+func Capacity(viewed, candidate models.Property) float64 {
+	if viewed.MaxGuests == 0 || candidate.MaxGuests == 0 {
+		return 0.5
+	}
+	return ratio(float64(viewed.MaxGuests), float64(candidate.MaxGuests))
+}
+
+// Scored is a candidate with its similarity to the viewed property
+type Scored struct {
+	Property models.Property
+	Score    float64
+}
+
+// Rank scores the candidates against the viewed property and returns the limit
+// most similar, best first. Ties go to the lower ID so the order is stable.
+// The viewed property itself is never recommended.
+func Rank(viewed models.Property, candidates []models.Property, scorer Scorer, limit int) []Scored {
+	ranked := make([]Scored, 0, len(candidates))
+	for _, c := range candidates {
+		if c.ID == viewed.ID {
+			continue
+		}
+		ranked = append(ranked, Scored{Property: c, Score: scorer.Score(viewed, c)})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].Property.ID < ranked[j].Property.ID
+	})
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	return ranked
+}
+
+func ratio(a, b float64) float64 {
+	if a <= 0 || b <= 0 {
+		return 0
+	}
+	return math.Min(a, b) / math.Max(a, b)
+}
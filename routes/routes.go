@@ -1,20 +1,39 @@
 package routes
 
 import (
+	"strings"
+
 	_ "github.com/bookaroo/bookaroo-platform-be/docs"
+	"github.com/bookaroo/bookaroo-platform-be/geo"
 	"github.com/bookaroo/bookaroo-platform-be/handlers"
 	"github.com/bookaroo/bookaroo-platform-be/middleware"
+	"github.com/bookaroo/bookaroo-platform-be/search"
+	"github.com/bookaroo/bookaroo-platform-be/storage"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 	"gorm.io/gorm"
 )
 
-func SetupRoutes(r *gin.Engine, db *gorm.DB) {
+func SetupRoutes(r *gin.Engine, db *gorm.DB, backend search.Backend, geocoder geo.Geocoder) {
 	// Initialize handlers
-	propertyHandler := handlers.NewPropertyHandler(db)
+	propertyHandler := handlers.NewPropertyHandler(db, backend, geocoder)
 	bookingHandler := handlers.NewBookingHandler(db)
 	userHandler := handlers.NewUserHandler(db)
+	calendarHandler := handlers.NewCalendarHandler(db)
+	partnerHandler := handlers.NewPartnerHandler(db)
+	waitlistHandler := handlers.NewWaitlistHandler(db)
+	notificationHandler := handlers.NewNotificationHandler(db)
+	amenityHandler := handlers.NewAmenityHandler(db)
+	locationHandler := handlers.NewLocationHandler(db)
+	portfolioHandler := handlers.NewPortfolioHandler(db)
+	wishlistHandler := handlers.NewWishlistHandler(db)
+	savedSearchHandler := handlers.NewSavedSearchHandler(db, backend, geocoder)
+
+	// Uploaded images are served by the API itself when stored locally
+	if local, ok := propertyHandler.Storage.(*storage.Local); ok && strings.HasPrefix(local.BaseURL, "/") {
+		r.Static(local.BaseURL, local.Dir)
+	}
 
 	// API routes
 	api := r.Group("/api")
@@ -22,16 +41,102 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB) {
 		// Property routes
 		properties := api.Group("/properties")
 		{
-			properties.GET("", propertyHandler.ListProperties)
-			properties.GET("/:id", propertyHandler.GetProperty)
-			properties.GET("/search", propertyHandler.SearchProperties)
+			properties.GET("", middleware.OptionalAuth(), propertyHandler.ListProperties)
+			properties.GET("/:id", middleware.OptionalAuth(), propertyHandler.GetProperty)
+			properties.GET("/search", middleware.OptionalAuth(), propertyHandler.SearchProperties)
+			properties.GET("/suggest", propertyHandler.SuggestProperties)
+			properties.GET("/:id/similar", middleware.OptionalAuth(), propertyHandler.SimilarProperties)
+			properties.GET("/:id/reviews", propertyHandler.ListReviews)
 			properties.POST("", middleware.AuthMiddleware(), propertyHandler.CreateProperty)
+			properties.PATCH("/:id", middleware.AuthMiddleware(), propertyHandler.UpdateProperty)
+			properties.DELETE("/:id", middleware.AuthMiddleware(), propertyHandler.DeleteProperty)
+			properties.POST("/:id/archive", middleware.AuthMiddleware(), propertyHandler.ArchiveProperty)
+			properties.POST("/:id/restore", middleware.AuthMiddleware(), propertyHandler.RestoreProperty)
+			properties.POST("/:id/submit", middleware.AuthMiddleware(), propertyHandler.SubmitProperty)
+			properties.GET("/:id/translations", middleware.AuthMiddleware(), propertyHandler.ListPropertyTranslations)
+			properties.PUT("/:id/translations/:locale", middleware.AuthMiddleware(), propertyHandler.PutPropertyTranslation)
+			properties.DELETE("/:id/translations/:locale", middleware.AuthMiddleware(), propertyHandler.DeletePropertyTranslation)
+			properties.GET("/:id/owner-details", middleware.AuthMiddleware(), propertyHandler.GetPropertyDetailsForOwner)
+			properties.GET("/:id/price-history", middleware.AuthMiddleware(), propertyHandler.GetPriceHistory)
+			properties.POST("/:id/images", middleware.AuthMiddleware(), propertyHandler.UploadPropertyImages)
+			properties.PUT("/:id/images/order", middleware.AuthMiddleware(), propertyHandler.ReorderPropertyImages)
+			properties.PATCH("/:id/images/:image_id", middleware.AuthMiddleware(), propertyHandler.UpdatePropertyImage)
+			properties.DELETE("/:id/images/:image_id", middleware.AuthMiddleware(), propertyHandler.DeletePropertyImage)
+
+			// Calendar routes
+			properties.GET("/:id/calendar.ics", calendarHandler.ExportCalendar)
+			properties.POST("/:id/calendar/token", middleware.AuthMiddleware(), calendarHandler.RotateCalendarToken)
+			properties.GET("/:id/blocks", middleware.AuthMiddleware(), calendarHandler.ListBlocks)
+			properties.POST("/:id/blocks", middleware.AuthMiddleware(), calendarHandler.CreateBlock)
+			properties.DELETE("/:id/blocks/:block_id", middleware.AuthMiddleware(), calendarHandler.DeleteBlock)
+			properties.GET("/:id/calendar/sources", middleware.AuthMiddleware(), calendarHandler.ListCalendarSources)
+			properties.POST("/:id/calendar/sources", middleware.AuthMiddleware(), calendarHandler.CreateCalendarSource)
+			properties.POST("/:id/calendar/sources/:source_id/sync", middleware.AuthMiddleware(), calendarHandler.SyncCalendarSource)
+			properties.DELETE("/:id/calendar/sources/:source_id", middleware.AuthMiddleware(), calendarHandler.DeleteCalendarSource)
 		}
 
 		// Booking routes
 		bookings := api.Group("/bookings")
 		{
+			bookings.GET("", middleware.AuthMiddleware(), bookingHandler.GetGuestBookings)
+			// Deprecated alias of GET /bookings
+			bookings.GET("/guest/:guest_id", middleware.AuthMiddleware(), bookingHandler.GetGuestBookingsByID)
 			bookings.POST("", middleware.AuthMiddleware(), bookingHandler.CreateBooking)
+			bookings.GET("/:id", middleware.AuthMiddleware(), bookingHandler.GetBooking)
+			bookings.POST("/:id/confirm", middleware.AuthMiddleware(), bookingHandler.ConfirmBooking)
+			bookings.POST("/:id/cancel", middleware.AuthMiddleware(), bookingHandler.CancelBooking)
+			bookings.POST("/:id/review", middleware.AuthMiddleware(), bookingHandler.CreateReview)
+			bookings.POST("/:id/terminate", middleware.AuthMiddleware(), bookingHandler.TerminateStay)
+			bookings.GET("/:id/installments", middleware.AuthMiddleware(), bookingHandler.GetInstallments)
+			bookings.POST("/:id/installments/:installment_id/paid", middleware.AuthMiddleware(), bookingHandler.MarkInstallmentPaid)
+		}
+
+		// Waitlist routes
+		waitlist := api.Group("/waitlist", middleware.AuthMiddleware())
+		{
+			waitlist.GET("", waitlistHandler.ListWaitlist)
+			waitlist.POST("", waitlistHandler.JoinWaitlist)
+			waitlist.DELETE("/:id", waitlistHandler.LeaveWaitlist)
+		}
+
+		// Wishlist routes; shared lists are readable by anyone with the link
+		api.GET("/wishlists/shared/:token", middleware.OptionalAuth(), wishlistHandler.GetSharedWishlist)
+		wishlists := api.Group("/wishlists", middleware.AuthMiddleware())
+		{
+			wishlists.GET("", wishlistHandler.ListWishlists)
+			wishlists.POST("", wishlistHandler.CreateWishlist)
+			wishlists.GET("/:id", wishlistHandler.GetWishlist)
+			wishlists.PATCH("/:id", wishlistHandler.RenameWishlist)
+			wishlists.DELETE("/:id", wishlistHandler.DeleteWishlist)
+			wishlists.POST("/:id/items", wishlistHandler.AddWishlistItem)
+			wishlists.DELETE("/:id/items/:property_id", wishlistHandler.RemoveWishlistItem)
+			wishlists.POST("/:id/share", wishlistHandler.ShareWishlist)
+			wishlists.DELETE("/:id/share", wishlistHandler.UnshareWishlist)
+		}
+
+		// Saved search routes
+		savedSearches := api.Group("/saved-searches", middleware.AuthMiddleware())
+		{
+			savedSearches.GET("", savedSearchHandler.ListSavedSearches)
+			savedSearches.POST("", savedSearchHandler.CreateSavedSearch)
+			savedSearches.GET("/:id", savedSearchHandler.GetSavedSearch)
+			savedSearches.PATCH("/:id", savedSearchHandler.UpdateSavedSearch)
+			savedSearches.DELETE("/:id", savedSearchHandler.DeleteSavedSearch)
+		}
+
+		// Notification routes
+		notifications := api.Group("/notifications", middleware.AuthMiddleware())
+		{
+			notifications.GET("", notificationHandler.ListNotifications)
+			notifications.POST("/:id/read", notificationHandler.MarkNotificationRead)
+		}
+
+		// Bulk import and export of an owner's properties
+		portfolio := api.Group("/portfolio", middleware.AuthMiddleware())
+		{
+			portfolio.POST("/imports", portfolioHandler.ImportProperties)
+			portfolio.GET("/imports/:id", portfolioHandler.GetImport)
+			portfolio.GET("/export", portfolioHandler.ExportProperties)
 		}
 
 		// User routes
@@ -40,7 +145,38 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB) {
 		api.POST("/login", userHandler.Login)
 
 		// User dashboard
-		api.GET("/dashboard", userHandler.GetUserDashboard)
+		api.GET("/dashboard", middleware.AuthMiddleware(), userHandler.GetUserDashboard)
+
+		// Amenities catalog
+		api.GET("/amenities", amenityHandler.ListAmenities)
+
+		// Location search box suggestions
+		api.GET("/locations/autocomplete", locationHandler.AutocompleteLocations)
+
+		// Channel manager routes for distribution partners
+		partner := api.Group("/partner", middleware.PartnerAuth(db))
+		{
+			partner.GET("/properties/:id/ari", partnerHandler.GetARI)
+			partner.POST("/reservations", partnerHandler.CreateReservation)
+			partner.GET("/reservations/:external_ref", partnerHandler.GetReservation)
+			partner.PATCH("/reservations/:external_ref", partnerHandler.ModifyReservation)
+			partner.DELETE("/reservations/:external_ref", partnerHandler.CancelReservation)
+		}
+
+		// Admin routes
+		admin := api.Group("/admin", middleware.AuthMiddleware(), middleware.RoleAuth("admin"))
+		{
+			admin.POST("/amenities", amenityHandler.CreateAmenity)
+			admin.GET("/properties", propertyHandler.ListPropertiesForReview)
+			admin.POST("/properties/:id/approve", propertyHandler.ApproveProperty)
+			admin.POST("/properties/:id/reject", propertyHandler.RejectProperty)
+			admin.POST("/properties/:id/suspend", propertyHandler.SuspendProperty)
+			admin.GET("/duplicates", propertyHandler.ListDuplicateClusters)
+			admin.POST("/duplicates/:id/confirm", propertyHandler.ConfirmDuplicate)
+			admin.POST("/duplicates/:id/dismiss", propertyHandler.DismissDuplicate)
+			admin.POST("/partners", partnerHandler.CreatePartner)
+			admin.POST("/partners/:id/listings", partnerHandler.CreatePartnerListing)
+		}
 	}
 
 	// Swagger
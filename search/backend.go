@@ -0,0 +1,71 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"gorm.io/gorm"
+)
+
+// Match restricts a property query to the matches for free text and ranks them
+type Match struct {
+	Condition     string // SQL condition on properties
+	ConditionArgs []interface{}
+	Rank          string // SQL expression scoring the matches, higher is better
+	RankArgs      []interface{}
+}
+
+// Backend finds properties by free text. Filters other than the text, and
+// whether a property is listed, are left to the SQL query the match is applied to.
+type Backend interface {
+	// Match finds the properties matching text written in lang
+	Match(ctx context.Context, text, lang string) (Match, error)
+	// Snippets returns HTML excerpts of the descriptions of the given
+	// properties, with the words matching text in <mark>
+	Snippets(ctx context.Context, ids []uint, text, lang string) (map[uint]string, error)
+	// Suggest returns the IDs of properties completing text as it is typed, best first
+	Suggest(ctx context.Context, text string, limit int) ([]uint, error)
+	// Index adds or refreshes properties after they are saved
+	Index(ctx context.Context, properties ...models.Property) error
+	// Remove drops a deleted property
+	Remove(ctx context.Context, id uint) error
+	// Rebuild indexes every property from scratch
+	Rebuild(ctx context.Context) error
+}
+
+// NewFromEnv returns the backend selected by SEARCH_BACKEND: postgres (the
+// default) or local, an embedded index stored at SEARCH_INDEX_PATH. Build it
+// once per process and share it, so the local index has a single copy in memory.
+func NewFromEnv(db *gorm.DB) (Backend, error) {
+	switch name := os.Getenv("SEARCH_BACKEND"); name {
+	case "", "postgres":
+		return NewPostgres(db), nil
+	case "local":
+		path := os.Getenv("SEARCH_INDEX_PATH")
+		if path == "" {
+			path = "data/search.idx"
+		}
+		return openLocal(db, path)
+	default:
+		return nil, fmt.Errorf("unknown search backend %q", name)
+	}
+}
+
+// openLocal opens the local index at path, building it on first use
+func openLocal(db *gorm.DB, path string) (*Local, error) {
+	_, err := os.Stat(path)
+	firstUse := errors.Is(err, os.ErrNotExist)
+	index, err := OpenLocal(db, path)
+	if err != nil {
+		return nil, err
+	}
+	if firstUse {
+		if err := index.Rebuild(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+	return index, nil
+}
@@ -0,0 +1,121 @@
+package search
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// DefaultLanguage is the language of listings and queries that don't name one
+const DefaultLanguage = "en"
+
+// Languages maps the listing languages Postgres can stem to their text search
+// configuration. Listings and queries in any other language, such as id or th,
+// are indexed and matched word for word.
+var Languages = map[string]string{
+	"en": "english",
+	"fr": "french",
+	"de": "german",
+	"es": "spanish",
+	"it": "italian",
+	"pt": "portuguese",
+	"nl": "dutch",
+}
+
+// Highlight markers around matched words in snippets, swapped for HTML once the
+// rest of the snippet is escaped. Private-use characters don't occur in listings.
+const (
+	markStart = "\uE000"
+	markStop  = "\uE001"
+)
+
+// LanguageCodes lists the languages that are stemmed, in a stable order
+func LanguageCodes() []string {
+	codes := make([]string, 0, len(Languages))
+	for code := range Languages {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// baseLanguage returns the language subtag of a BCP 47 tag, e.g. "pt" for "pt-BR"
+func baseLanguage(lang string) string {
+	base, _, _ := strings.Cut(lang, "-")
+	return strings.ToLower(base)
+}
+
+// config returns the text search configuration for lang
+func config(lang string) string {
+	if cfg, ok := Languages[baseLanguage(lang)]; ok {
+		return cfg
+	}
+	return "simple"
+}
+
+// Migrate adds the weighted search vector to properties and keeps it up to date
+// with a trigger: the name weighs most, then the city and region, then the
+// description. The free-form location is only indexed while the city isn't
+// known, as it may hold the street. Each field is indexed stemmed in the
+// listing's language and word for word, so queries in another language still
+// find exact words. Every property is indexed again when the trigger changes.
+func Migrate(db *gorm.DB) error {
+	var cases strings.Builder
+	for _, code := range LanguageCodes() {
+		fmt.Fprintf(&cases, " WHEN '%s' THEN '%s'", code, Languages[code])
+	}
+	body := `
+		DECLARE
+			cfg regconfig := CASE lower(split_part(NEW.language, '-', 1))` + cases.String() + ` ELSE 'simple' END;
+			place text := CASE WHEN coalesce(NEW.city, '') = '' THEN coalesce(NEW.location, '')
+				ELSE concat_ws(' ', NEW.city, NEW.region) END;
+		BEGIN
+			NEW.search_vector :=
+				setweight(to_tsvector(cfg, coalesce(NEW.name, '')), 'A') ||
+				setweight(to_tsvector('simple', coalesce(NEW.name, '')), 'A') ||
+				setweight(to_tsvector(cfg, place), 'B') ||
+				setweight(to_tsvector('simple', place), 'B') ||
+				setweight(to_tsvector(cfg, coalesce(NEW.description, '')), 'C') ||
+				setweight(to_tsvector('simple', coalesce(NEW.description, '')), 'C');
+			RETURN NEW;
+		END
+		`
+
+	var current string
+	if err := db.Raw(`SELECT coalesce((SELECT prosrc FROM pg_proc WHERE proname = 'properties_search_vector'), '')`).
+		Scan(&current).Error; err != nil {
+		return err
+	}
+	// Index properties created before full-text search, or all of them when
+	// what is indexed changed
+	reindex := `UPDATE properties SET name = name WHERE search_vector IS NULL`
+	if current != body {
+		reindex = `UPDATE properties SET name = name`
+	}
+
+	statements := []string{
+		`ALTER TABLE properties ADD COLUMN IF NOT EXISTS search_vector tsvector`,
+		`CREATE INDEX IF NOT EXISTS idx_properties_search ON properties USING gin (search_vector)`,
+		`CREATE OR REPLACE FUNCTION properties_search_vector() RETURNS trigger AS $$` + body + `$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS properties_search_vector ON properties`,
+		`CREATE TRIGGER properties_search_vector BEFORE INSERT OR UPDATE OF name, location, city, region, description, language
+			ON properties FOR EACH ROW EXECUTE FUNCTION properties_search_vector()`,
+		reindex,
+	}
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Highlight escapes a snippet as HTML, wrapping the words marked with
+// markStart and markStop, by ts_headline or excerpt, in <mark>
+func Highlight(snippet string) string {
+	escaped := html.EscapeString(snippet)
+	return strings.NewReplacer(markStart, "<mark>", markStop, "</mark>").Replace(escaped)
+}
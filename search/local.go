@@ -0,0 +1,468 @@
+package search
+
+import (
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"gorm.io/gorm"
+)
+
+const (
+	snippetWords = 30 // Length of description excerpts
+
+	// Matches in the name count most, then the city and region, then the description
+	nameWeight        = 3.0
+	locationWeight    = 2.0
+	descriptionWeight = 1.0
+)
+
+// document is what the local index keeps of a listed property
+type document struct {
+	Name        string
+	Location    string // The public one, see models.Property.PublicLocation
+	Description string
+}
+
+// Local is an embedded index held in memory and saved to a file after every
+// change. Unlike Postgres it tolerates typos and completes words as they are
+// typed. Each API process keeps its own copy, and a copy notices when another
+// process, such as cmd/reindex, saved the file and reloads it before answering
+// or changing anything. Changes lock the file at path + ".lock" from the
+// reload to the save, so processes on one machine never overwrite each
+// other's changes. Every change rewrites the whole file, so it suits
+// single-machine deployments with up to some tens of thousands of listings.
+// Only listed properties are indexed.
+type Local struct {
+	db   *gorm.DB
+	path string
+
+	mu       sync.RWMutex
+	file     os.FileInfo // File the index was last loaded from or saved to, nil when there was none
+	docs     map[uint]document
+	postings map[string]map[uint]float64 // Term to the weight of each property containing it
+}
+
+// OpenLocal loads the index saved at path, or starts an empty one when there is
+// none yet. db is only used by Rebuild.
+func OpenLocal(db *gorm.DB, path string) (*Local, error) {
+	l := &Local{db: db, path: path}
+	l.load(nil)
+	if err := l.reload(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *Local) Match(ctx context.Context, text, lang string) (Match, error) {
+	if err := l.refresh(); err != nil {
+		return Match{}, err
+	}
+	l.mu.RLock()
+	hits := best(l.score(queryTerms(text, lang), false), len(l.docs))
+	l.mu.RUnlock()
+
+	// Every match travels to the query, as JSON scores keyed by property ID, so
+	// the SQL filters and the total see all of them. One argument also keeps
+	// large result sets clear of the limit on query parameters.
+	scores := make(map[string]float64, len(hits))
+	for _, h := range hits {
+		scores[strconv.FormatUint(uint64(h.id), 10)] = h.score
+	}
+	scoresJSON, err := json.Marshal(scores)
+	if err != nil {
+		return Match{}, err
+	}
+
+	return Match{
+		Condition:     "(?::jsonb ->> properties.id::text) IS NOT NULL",
+		ConditionArgs: []interface{}{string(scoresJSON)},
+		Rank:          "(?::jsonb ->> properties.id::text)::float8",
+		RankArgs:      []interface{}{string(scoresJSON)},
+	}, nil
+}
+
+func (l *Local) Snippets(ctx context.Context, ids []uint, text, lang string) (map[uint]string, error) {
+	if err := l.refresh(); err != nil {
+		return nil, err
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	matching := map[string]bool{}
+	for _, term := range queryTerms(text, lang) {
+		for t := range l.expand(term, false) {
+			matching[t] = true
+		}
+	}
+
+	snippets := make(map[uint]string, len(ids))
+	for _, id := range ids {
+		if doc, ok := l.docs[id]; ok {
+			snippets[id] = Highlight(excerpt(doc.Description, matching))
+		}
+	}
+	return snippets, nil
+}
+
+func (l *Local) Suggest(ctx context.Context, text string, limit int) ([]uint, error) {
+	if err := l.refresh(); err != nil {
+		return nil, err
+	}
+	l.mu.RLock()
+	hits := best(l.score(terms(text), true), limit)
+	l.mu.RUnlock()
+
+	ids := make([]uint, len(hits))
+	for i, h := range hits {
+		ids[i] = h.id
+	}
+	return ids, nil
+}
+
+func (l *Local) Index(ctx context.Context, properties ...models.Property) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	unlock, err := lockFile(l.lockPath())
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := l.reload(); err != nil {
+		return err
+	}
+
+	for _, p := range properties {
+		l.drop(p.ID)
+		if p.Listed() {
+			doc := document{Name: p.Name, Location: p.PublicLocation(), Description: p.Description}
+			l.docs[p.ID] = doc
+			l.add(p.ID, doc)
+		}
+	}
+	return l.save()
+}
+
+func (l *Local) Remove(ctx context.Context, id uint) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	unlock, err := lockFile(l.lockPath())
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := l.reload(); err != nil {
+		return err
+	}
+
+	l.drop(id)
+	return l.save()
+}
+
+func (l *Local) Rebuild(ctx context.Context) error {
+	docs := map[uint]document{}
+	var batch []models.Property
+	if err := l.db.WithContext(ctx).Model(&models.Property{}).
+		Select("id, name, location, city, region, description").
+		Scopes(models.ListedScope).
+		FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+			for _, p := range batch {
+				docs[p.ID] = document{Name: p.Name, Location: p.PublicLocation(), Description: p.Description}
+			}
+			return nil
+		}).Error; err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	unlock, err := lockFile(l.lockPath())
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	l.load(docs)
+	return l.save()
+}
+
+// Len returns the number of indexed properties
+func (l *Local) Len() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return len(l.docs)
+}
+
+// refresh reloads the index if the file changed since it was loaded or saved here
+func (l *Local) refresh() error {
+	l.mu.RLock()
+	known := l.file
+	l.mu.RUnlock()
+	if info, err := os.Stat(l.path); err == nil && sameFile(info, known) {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.reload()
+}
+
+// reload loads the file at path when it isn't the one last loaded or saved.
+// Saves replace the file rather than write into it, so a new file means
+// another process saved the index. The caller holds the write lock.
+func (l *Local) reload() error {
+	f, err := os.Open(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if sameFile(info, l.file) {
+		return nil
+	}
+
+	var docs map[uint]document
+	if err := gob.NewDecoder(f).Decode(&docs); err != nil {
+		return fmt.Errorf("reading search index %s: %w", l.path, err)
+	}
+	l.load(docs)
+	l.file = info
+	return nil
+}
+
+// sameFile reports whether info describes the file known was taken from,
+// unchanged. Inodes of replaced files get reused, so the modification time
+// and size are compared too.
+func sameFile(info, known os.FileInfo) bool {
+	return known != nil && os.SameFile(info, known) && info.ModTime().Equal(known.ModTime()) && info.Size() == known.Size()
+}
+
+func (l *Local) load(docs map[uint]document) {
+	if docs == nil {
+		docs = map[uint]document{}
+	}
+	l.docs = docs
+	l.postings = map[string]map[uint]float64{}
+	for id, doc := range docs {
+		l.add(id, doc)
+	}
+}
+
+func (l *Local) add(id uint, doc document) {
+	fields := []struct {
+		text   string
+		weight float64
+	}{{doc.Name, nameWeight}, {doc.Location, locationWeight}, {doc.Description, descriptionWeight}}
+
+	for _, f := range fields {
+		for _, t := range terms(f.text) {
+			if l.postings[t] == nil {
+				l.postings[t] = map[uint]float64{}
+			}
+			l.postings[t][id] += f.weight
+		}
+	}
+}
+
+func (l *Local) drop(id uint) {
+	doc, ok := l.docs[id]
+	if !ok {
+		return
+	}
+	for _, t := range terms(doc.Name + " " + doc.Location + " " + doc.Description) {
+		delete(l.postings[t], id)
+		if len(l.postings[t]) == 0 {
+			delete(l.postings, t)
+		}
+	}
+	delete(l.docs, id)
+}
+
+// lockPath is the file locked while the index is changed
+func (l *Local) lockPath() string {
+	return l.path + ".lock"
+}
+
+// save writes the index to a temporary file renamed over the old one, so a
+// crash never leaves a partial index behind
+func (l *Local) save() error {
+	dir := filepath.Dir(l.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(l.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := gob.NewEncoder(tmp).Encode(l.docs); err != nil {
+		tmp.Close()
+		return err
+	}
+	info, err := tmp.Stat()
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), l.path); err != nil {
+		return err
+	}
+	l.file = info
+	return nil
+}
+
+// expand returns the indexed terms a query term matches, with the share of the
+// score each earns: exact matches in full, completions and typos less
+func (l *Local) expand(term string, prefix bool) map[string]float64 {
+	matches := map[string]float64{}
+	if _, ok := l.postings[term]; ok {
+		matches[term] = 1
+	}
+
+	edits := maxEdits(term)
+	if edits == 0 && !prefix {
+		return matches
+	}
+	first, _ := utf8.DecodeRuneInString(term)
+	for t := range l.postings {
+		if t == term {
+			continue
+		}
+		if prefix && strings.HasPrefix(t, term) {
+			matches[t] = 0.9
+			continue
+		}
+		// Typos rarely hit the first letter, and requiring it keeps the scan cheap
+		if r, _ := utf8.DecodeRuneInString(t); edits > 0 && r == first {
+			if d := editDistance(term, t, edits); d <= edits {
+				matches[t] = 1 / float64(1+d)
+			}
+		}
+	}
+	return matches
+}
+
+// score rates the properties matching every query term, optionally completing the last one
+func (l *Local) score(query []string, completeLast bool) map[uint]float64 {
+	if len(query) == 0 {
+		return nil
+	}
+
+	var scores map[uint]float64
+	n := float64(len(l.docs))
+	for i, term := range query {
+		termScores := map[uint]float64{}
+		for t, share := range l.expand(term, completeLast && i == len(query)-1) {
+			// Rare terms say more about a property than common ones
+			idf := math.Log(1 + n/float64(len(l.postings[t])))
+			for id, weight := range l.postings[t] {
+				termScores[id] = max(termScores[id], weight*idf*share)
+			}
+		}
+
+		if scores == nil {
+			scores = termScores
+			continue
+		}
+		for id, s := range scores {
+			if ts, ok := termScores[id]; ok {
+				scores[id] = s + ts
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+	return scores
+}
+
+type hit struct {
+	id    uint
+	score float64
+}
+
+// best returns the highest scores first, at most limit of them
+func best(scores map[uint]float64, limit int) []hit {
+	hits := make([]hit, 0, len(scores))
+	for id, s := range scores {
+		hits = append(hits, hit{id, s})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].score != hits[j].score {
+			return hits[i].score > hits[j].score
+		}
+		return hits[i].id < hits[j].id
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// excerpt cuts the text around its first matching word, marking every match
+// for Highlight
+func excerpt(text string, matching map[string]bool) string {
+	spans := wordSpans(text)
+	if len(spans) == 0 {
+		return ""
+	}
+
+	first := -1
+	matched := make([]bool, len(spans))
+	for i, s := range spans {
+		if matching[fold(text[s.start:s.end])] {
+			matched[i] = true
+			if first < 0 {
+				first = i
+			}
+		}
+	}
+
+	from := 0
+	if first > snippetWords/3 {
+		from = first - snippetWords/3
+	}
+	to := min(len(spans), from+snippetWords)
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("… ")
+	}
+	pos := spans[from].start
+	for i := from; i < to; i++ {
+		s := spans[i]
+		b.WriteString(text[pos:s.start])
+		if matched[i] {
+			b.WriteString(markStart + text[s.start:s.end] + markStop)
+		} else {
+			b.WriteString(text[s.start:s.end])
+		}
+		pos = s.end
+	}
+	if to < len(spans) {
+		b.WriteString(" …")
+	} else {
+		b.WriteString(text[pos:])
+	}
+	return b.String()
+}
@@ -0,0 +1,100 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Postgres searches the weighted tsvector kept on properties by the trigger
+// from Migrate. It needs no syncing, but doesn't tolerate typos.
+type Postgres struct {
+	DB *gorm.DB
+}
+
+func NewPostgres(db *gorm.DB) *Postgres {
+	return &Postgres{DB: db}
+}
+
+// tsQuery returns an SQL tsquery for free text such as "beach villa" -pool,
+// stemmed for lang and word for word, with its arguments
+func tsQuery(text, lang string) (string, []interface{}) {
+	return "(websearch_to_tsquery(?::regconfig, ?) || websearch_to_tsquery('simple', ?))",
+		[]interface{}{config(lang), text, text}
+}
+
+func (p *Postgres) Match(ctx context.Context, text, lang string) (Match, error) {
+	tsquery, args := tsQuery(text, lang)
+	return Match{
+		Condition:     "properties.search_vector @@ " + tsquery,
+		ConditionArgs: args,
+		Rank:          "ts_rank(properties.search_vector, " + tsquery + ")",
+		RankArgs:      args,
+	}, nil
+}
+
+func (p *Postgres) Snippets(ctx context.Context, ids []uint, text, lang string) (map[uint]string, error) {
+	tsquery, args := tsQuery(text, lang)
+	headline := fmt.Sprintf(`ts_headline(?::regconfig, properties.description, %s,
+		'StartSel=%s, StopSel=%s, MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=" … "')`,
+		tsquery, markStart, markStop)
+
+	var rows []struct {
+		ID      uint
+		Snippet string
+	}
+	if err := p.DB.WithContext(ctx).Model(&models.Property{}).
+		Select("properties.id, "+headline+" AS snippet", append([]interface{}{config(lang)}, args...)...).
+		Where("properties.id IN ?", ids).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	snippets := make(map[uint]string, len(rows))
+	for _, row := range rows {
+		snippets[row.ID] = Highlight(row.Snippet)
+	}
+	return snippets, nil
+}
+
+func (p *Postgres) Suggest(ctx context.Context, text string, limit int) ([]uint, error) {
+	ws := words(text)
+	if len(ws) == 0 {
+		return nil, nil
+	}
+	// The last word is still being typed
+	ws[len(ws)-1] += ":*"
+	tsquery := "to_tsquery('simple', ?)"
+	arg := strings.Join(ws, " & ")
+
+	var ids []uint
+	err := p.DB.WithContext(ctx).Model(&models.Property{}).
+		Scopes(models.ListedScope).
+		Where("search_vector @@ "+tsquery, arg).
+		Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "ts_rank(search_vector, " + tsquery + ") DESC, id",
+			Vars:               []interface{}{arg},
+			WithoutParentheses: true,
+		}}).
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// Index does nothing: the trigger keeps the search vector up to date
+func (p *Postgres) Index(ctx context.Context, properties ...models.Property) error {
+	return nil
+}
+
+// Remove does nothing: deleted properties are filtered out by the search query
+func (p *Postgres) Remove(ctx context.Context, id uint) error {
+	return nil
+}
+
+// Rebuild recomputes every search vector, e.g. after the weighting changed
+func (p *Postgres) Rebuild(ctx context.Context) error {
+	return p.DB.WithContext(ctx).Exec("UPDATE properties SET name = name").Error
+}
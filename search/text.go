@@ -0,0 +1,147 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// stopwords are left out of queries to the local index, so "villa with pool"
+// doesn't require listings to contain "with"
+var stopwords = map[string]map[string]bool{
+	"en": set("a an and at by for from in is of on or the to with near"),
+	"fr": set("a au aux avec de des du en et la le les ou pour pres sur un une"),
+	"de": set("am an auf bei das der die ein eine im in mit nahe und von zu zum zur"),
+	"es": set("a al cerca con de del el en la las los o para por un una y"),
+	"it": set("a al con da del della di e il in la le per un una vicino"),
+	"pt": set("a ao com da de do e em na no o os para perto por um uma"),
+	"nl": set("aan bij de een en het in met of op te van voor"),
+}
+
+func set(words string) map[string]bool {
+	m := map[string]bool{}
+	for _, w := range strings.Fields(words) {
+		m[w] = true
+	}
+	return m
+}
+
+// wordSpan is the position of a word in a text, in bytes
+type wordSpan struct {
+	start, end int
+}
+
+// wordSpans finds the runs of letters and digits in s
+func wordSpans(s string) []wordSpan {
+	var spans []wordSpan
+	start := -1
+	for i, r := range s {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case isWord && start < 0:
+			start = i
+		case !isWord && start >= 0:
+			spans = append(spans, wordSpan{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, wordSpan{start, len(s)})
+	}
+	return spans
+}
+
+// words splits s into lowercase words
+func words(s string) []string {
+	spans := wordSpans(s)
+	out := make([]string, len(spans))
+	for i, span := range spans {
+		out[i] = strings.ToLower(s[span.start:span.end])
+	}
+	return out
+}
+
+var stripMarks = transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+// fold lowercases a word and strips its accents, so "Château" and "chateau" match
+func fold(word string) string {
+	folded, _, err := transform.String(stripMarks, strings.ToLower(word))
+	if err != nil {
+		return strings.ToLower(word)
+	}
+	return folded
+}
+
+// terms returns the folded words of s, as indexed by the local index
+func terms(s string) []string {
+	ws := words(s)
+	for i, w := range ws {
+		ws[i] = fold(w)
+	}
+	return ws
+}
+
+// queryTerms returns the terms of a query in lang without stopwords, unless
+// the query is nothing but stopwords
+func queryTerms(text, lang string) []string {
+	all := terms(text)
+	var kept []string
+	for _, t := range all {
+		if !stopwords[baseLanguage(lang)][t] {
+			kept = append(kept, t)
+		}
+	}
+	if len(kept) == 0 {
+		return all
+	}
+	return kept
+}
+
+// maxEdits is the number of typos tolerated in a query term: none in short
+// words, where a typo often makes another real word
+func maxEdits(term string) int {
+	switch n := utf8.RuneCountInString(term); {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// editDistance returns the Levenshtein distance between a and b, giving up
+// with limit+1 once the distance exceeds limit
+func editDistance(a, b string, limit int) int {
+	ra, rb := []rune(a), []rune(b)
+	if d := len(ra) - len(rb); d > limit || -d > limit {
+		return limit + 1
+	}
+
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		best := cur[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			best = min(best, cur[j])
+		}
+		if best > limit {
+			return limit + 1
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
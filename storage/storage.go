@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Storage persists uploaded files and tells clients where to fetch them
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+// NewFromEnv builds the backend selected by STORAGE_DRIVER. Only "local" is
+// available today; "s3" is reserved for an S3-compatible backend.
+func NewFromEnv() (Storage, error) {
+	driver := os.Getenv("STORAGE_DRIVER")
+	switch driver {
+	case "", "local":
+		dir := os.Getenv("UPLOAD_DIR")
+		if dir == "" {
+			dir = "uploads"
+		}
+		baseURL := os.Getenv("UPLOAD_BASE_URL")
+		if baseURL == "" {
+			baseURL = "/uploads"
+		}
+		return NewLocal(dir, baseURL), nil
+	case "s3":
+		return nil, fmt.Errorf("storage driver %q is not implemented yet", driver)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", driver)
+	}
+}
@@ -0,0 +1,145 @@
+package alerts_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bookaroo/bookaroo-platform-be/alerts"
+	"github.com/bookaroo/bookaroo-platform-be/geo"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/search"
+	"github.com/bookaroo/bookaroo-platform-be/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// recorder keeps the notifications sent instead of delivering them
+type recorder struct {
+	sent []models.Notification
+}
+
+func (r *recorder) Notify(ctx context.Context, notification *models.Notification) error {
+	r.sent = append(r.sent, *notification)
+	return nil
+}
+
+type MatcherTestSuite struct {
+	suite.Suite
+	db       *gorm.DB
+	notifier *recorder
+	matcher  *alerts.Matcher
+	guest    models.User
+	owner    models.User
+	saved    models.SavedSearch
+}
+
+func (suite *MatcherTestSuite) SetupSuite() {
+	suite.db = tests.SetupTestDB(suite.T())
+}
+
+func (suite *MatcherTestSuite) SetupTest() {
+	suite.db.Exec("DELETE FROM saved_search_matches")
+	suite.db.Exec("DELETE FROM saved_searches")
+	suite.db.Exec("DELETE FROM property_amenities")
+	suite.db.Exec("DELETE FROM property_images")
+	suite.db.Exec("DELETE FROM properties")
+	suite.db.Exec("DELETE FROM users")
+
+	suite.notifier = &recorder{}
+	suite.matcher = alerts.NewMatcher(suite.db, geo.NewGazetteer(), search.NewPostgres(suite.db), suite.notifier)
+
+	suite.owner = models.User{Email: "owner@example.com", Name: "Owner", Role: "owner"}
+	suite.db.Create(&suite.owner)
+	suite.guest = models.User{Email: "guest@example.com", Name: "Guest", Role: "guest"}
+	suite.db.Create(&suite.guest)
+
+	suite.saved = models.SavedSearch{UserID: suite.guest.ID, Name: "Bali for four", Query: "guests=4&location=Bali&max_price=150", AlertsEnabled: true}
+	suite.db.Create(&suite.saved)
+}
+
+func (suite *MatcherTestSuite) property(name string, price float64) models.Property {
+	p := models.Property{Name: name, Description: "Nice", Location: "Ubud, Bali", Price: price, MaxGuests: 4, OwnerID: suite.owner.ID}
+	suite.Require().NoError(suite.db.Create(&p).Error)
+	return p
+}
+
+func (suite *MatcherTestSuite) check() {
+	suite.Require().NoError(suite.matcher.Check(context.Background(), &suite.saved))
+}
+
+func (suite *MatcherTestSuite) TestFirstCheckOnlyRecordsMatches() {
+	suite.property("Rice Field Villa", 120)
+
+	suite.check()
+	assert.Empty(suite.T(), suite.notifier.sent)
+	assert.NotNil(suite.T(), suite.saved.LastCheckedAt)
+
+	var matches int64
+	suite.db.Model(&models.SavedSearchMatch{}).Where("saved_search_id = ?", suite.saved.ID).Count(&matches)
+	assert.Equal(suite.T(), int64(1), matches)
+}
+
+func (suite *MatcherTestSuite) TestNewMatchesAreAnnounced() {
+	suite.property("Rice Field Villa", 120)
+	suite.check()
+
+	hut := suite.property("Beach Hut", 80)
+	suite.property("Too Expensive", 400)
+	draft := suite.property("Unpublished", 90)
+	suite.db.Model(&draft).Update("status", models.ListingDraft)
+
+	suite.check()
+	suite.Require().Len(suite.notifier.sent, 1)
+	sent := suite.notifier.sent[0]
+	assert.Equal(suite.T(), suite.guest.ID, sent.UserID)
+	assert.Equal(suite.T(), "saved_search_match", sent.Type)
+	assert.Contains(suite.T(), sent.Message, "Beach Hut")
+	assert.Equal(suite.T(), fmt.Sprintf("/api/properties/%d", hut.ID), sent.Link)
+
+	// Nothing changed since
+	suite.check()
+	assert.Len(suite.T(), suite.notifier.sent, 1)
+}
+
+func (suite *MatcherTestSuite) TestPriceDropsAreAnnounced() {
+	villa := suite.property("Rice Field Villa", 140)
+	hut := suite.property("Beach Hut", 100)
+	suite.check()
+
+	suite.db.Model(&villa).Update("price", 110)
+	suite.db.Model(&hut).Update("price", 120)
+	suite.check()
+
+	suite.Require().Len(suite.notifier.sent, 1)
+	assert.Equal(suite.T(), "saved_search_price_drop", suite.notifier.sent[0].Type)
+	assert.Contains(suite.T(), suite.notifier.sent[0].Message, "Rice Field Villa is now 110.00 a night, down from 140.00")
+
+	// A property that stops matching and comes back cheaper is a price drop, not a new match
+	suite.db.Model(&hut).Update("price", 200)
+	suite.check()
+	suite.db.Model(&hut).Update("price", 90)
+	suite.check()
+	suite.Require().Len(suite.notifier.sent, 2)
+	assert.Equal(suite.T(), "saved_search_price_drop", suite.notifier.sent[1].Type)
+	assert.Contains(suite.T(), suite.notifier.sent[1].Message, "down from 120.00")
+}
+
+func (suite *MatcherTestSuite) TestExpiredSearchesAreTurnedOff() {
+	past := time.Now().AddDate(0, 0, -10)
+	suite.saved.Query = "check_in=" + past.Format("2006-01-02") + "&check_out=" + past.AddDate(0, 0, 2).Format("2006-01-02")
+	suite.db.Save(&suite.saved)
+
+	suite.Require().NoError(suite.matcher.CheckAll(context.Background()))
+
+	var saved models.SavedSearch
+	suite.db.First(&saved, suite.saved.ID)
+	assert.False(suite.T(), saved.AlertsEnabled)
+	assert.Empty(suite.T(), suite.notifier.sent)
+}
+
+func TestMatcherSuite(t *testing.T) {
+	suite.Run(t, new(MatcherTestSuite))
+}
@@ -0,0 +1,38 @@
+package amenities_test
+
+import (
+	"testing"
+
+	"github.com/bookaroo/bookaroo-platform-be/amenities"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := map[string]string{
+		"Wi-Fi":            "wifi",
+		" WiFi ":           "wifi",
+		"Swimming Pool":    "pool",
+		"Air Conditioning": "air_conditioning",
+		"A/C":              "air_conditioning",
+		"Beach Access":     "beach_access",
+		"Rooftop terrace":  "rooftop_terrace",
+		"":                 "",
+	}
+	for in, want := range cases {
+		assert.Equal(t, want, amenities.Normalize(in), in)
+	}
+}
+
+func TestParseCodes(t *testing.T) {
+	assert.Equal(t, []string{"wifi", "pool", "beach_access"}, amenities.ParseCodes("WiFi, Pool, Beach Access, wi-fi,"))
+	assert.Empty(t, amenities.ParseCodes(""))
+}
+
+func TestDefaultsUseNormalizedCodes(t *testing.T) {
+	seen := map[string]bool{}
+	for _, a := range amenities.Defaults {
+		assert.Equal(t, a.Code, amenities.Normalize(a.Code))
+		assert.False(t, seen[a.Code], a.Code)
+		seen[a.Code] = true
+	}
+}
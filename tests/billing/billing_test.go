@@ -0,0 +1,65 @@
+package billing_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bookaroo/bookaroo-platform-be/billing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchedule(t *testing.T) {
+	start := time.Date(2030, 1, 15, 0, 0, 0, 0, time.UTC)
+	installments := billing.Schedule(start, 3, 1200.0)
+
+	assert.Len(t, installments, 3)
+	assert.Equal(t, "due", installments[0].Status)
+	assert.Equal(t, "scheduled", installments[1].Status)
+	assert.Equal(t, time.Date(2030, 2, 15, 0, 0, 0, 0, time.UTC), installments[1].DueDate)
+	assert.Equal(t, time.Date(2030, 4, 15, 0, 0, 0, 0, time.UTC), installments[2].PeriodEnd)
+	for i, installment := range installments {
+		assert.Equal(t, i+1, installment.Sequence)
+		assert.Equal(t, 1200.0, installment.Amount)
+	}
+}
+
+func TestTerminateProratesAndVoids(t *testing.T) {
+	start := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	installments := billing.Schedule(start, 4, 3100.0)
+
+	// Leave halfway through March (10 of 31 nights used)
+	installments, total, refund := billing.Terminate(installments, time.Date(2030, 3, 11, 0, 0, 0, 0, time.UTC))
+
+	assert.Equal(t, 3100.0, installments[0].Amount)
+	assert.Equal(t, 3100.0, installments[1].Amount)
+	assert.Equal(t, 1000.0, installments[2].Amount)
+	assert.Equal(t, time.Date(2030, 3, 11, 0, 0, 0, 0, time.UTC), installments[2].PeriodEnd)
+	assert.Equal(t, "void", installments[3].Status)
+	assert.Equal(t, 7200.0, total)
+	assert.Zero(t, refund)
+}
+
+func TestTerminateKeepsPaidInstallments(t *testing.T) {
+	start := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	installments := billing.Schedule(start, 4, 3100.0)
+
+	// The guest paid ahead for March and April
+	for i := range installments {
+		installments[i].Status = "paid"
+	}
+
+	installments, total, refund := billing.Terminate(installments, time.Date(2030, 3, 11, 0, 0, 0, 0, time.UTC))
+
+	for _, installment := range installments {
+		assert.Equal(t, "paid", installment.Status)
+		assert.Equal(t, 3100.0, installment.Amount)
+	}
+	assert.Equal(t, time.Date(2030, 4, 1, 0, 0, 0, 0, time.UTC), installments[2].PeriodEnd)
+	assert.Equal(t, 7200.0, total)
+	assert.Equal(t, 5200.0, refund) // 21 nights of March and all of April
+}
+
+func TestEarliestEnd(t *testing.T) {
+	notice := time.Date(2030, 1, 10, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2030, 2, 9, 12, 0, 0, 0, time.UTC), billing.EarliestEnd(notice, 30))
+}
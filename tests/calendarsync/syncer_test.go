@@ -0,0 +1,183 @@
+package calendarsync_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bookaroo/bookaroo-platform-be/calendarsync"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type SyncerTestSuite struct {
+	suite.Suite
+	db     *gorm.DB
+	syncer *calendarsync.Syncer
+	feed   string
+	server *httptest.Server
+}
+
+func (suite *SyncerTestSuite) SetupSuite() {
+	suite.db = tests.SetupTestDB(suite.T())
+	suite.syncer = calendarsync.NewSyncer(suite.db)
+
+	// Local stand-in for an external calendar provider
+	suite.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if suite.feed == "" {
+			http.Error(w, "gone", http.StatusInternalServerError)
+			return
+		}
+		if suite.feed == "secret" {
+			w.Write([]byte("aws_secret_access_key=abc123\r\n"))
+			return
+		}
+		w.Header().Set("Content-Type", "text/calendar")
+		w.Write([]byte(suite.feed))
+	}))
+	// The stand-in listens on loopback, which the default client refuses
+	suite.syncer.Client = suite.server.Client()
+}
+
+func (suite *SyncerTestSuite) TearDownSuite() {
+	suite.server.Close()
+}
+
+func (suite *SyncerTestSuite) SetupTest() {
+	// Clear the database before each test
+	suite.db.Exec("DELETE FROM property_blocks")
+	suite.db.Exec("DELETE FROM calendar_sources")
+	suite.db.Exec("DELETE FROM properties")
+	suite.db.Exec("DELETE FROM users")
+}
+
+func (suite *SyncerTestSuite) createSource() models.CalendarSource {
+	owner := models.User{Email: "owner@example.com", Name: "Test Owner", Role: "owner"}
+	suite.db.Create(&owner)
+
+	property := models.Property{Name: "Beach House", Location: "Bali", Price: 200.0, OwnerID: owner.ID}
+	suite.db.Create(&property)
+
+	source := models.CalendarSource{PropertyID: property.ID, Name: "Airbnb", URL: suite.server.URL}
+	suite.db.Create(&source)
+
+	return source
+}
+
+func feed(events ...string) string {
+	out := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"
+	for _, event := range events {
+		out += event
+	}
+	return out + "END:VCALENDAR\r\n"
+}
+
+func event(uid, start, end string) string {
+	return "BEGIN:VEVENT\r\nUID:" + uid + "\r\nDTSTART;VALUE=DATE:" + start + "\r\nDTEND;VALUE=DATE:" + end + "\r\nSUMMARY:Reserved\r\nEND:VEVENT\r\n"
+}
+
+func (suite *SyncerTestSuite) TestSyncUpsertsAndRemovesBlocks() {
+	source := suite.createSource()
+
+	suite.feed = feed(event("a@airbnb.com", "20300310", "20300314"), event("b@airbnb.com", "20300401", "20300403"))
+	assert.NoError(suite.T(), suite.syncer.SyncSource(context.Background(), &source))
+
+	var blocks []models.PropertyBlock
+	suite.db.Where("calendar_source_id = ?", source.ID).Order("start_date").Find(&blocks)
+	assert.Len(suite.T(), blocks, 2)
+	firstID := blocks[0].ID
+
+	// Event a moves, event b disappears
+	suite.feed = feed(event("a@airbnb.com", "20300311", "20300315"))
+	assert.NoError(suite.T(), suite.syncer.SyncSource(context.Background(), &source))
+
+	blocks = nil
+	suite.db.Where("calendar_source_id = ?", source.ID).Find(&blocks)
+	assert.Len(suite.T(), blocks, 1)
+	assert.Equal(suite.T(), firstID, blocks[0].ID)
+	assert.Equal(suite.T(), 11, blocks[0].StartDate.Day())
+
+	var stored models.CalendarSource
+	suite.db.First(&stored, source.ID)
+	assert.Equal(suite.T(), "ok", stored.SyncStatus)
+	assert.NotNil(suite.T(), stored.LastSyncedAt)
+}
+
+func (suite *SyncerTestSuite) TestSyncRecordsError() {
+	source := suite.createSource()
+
+	suite.feed = feed(event("a@airbnb.com", "20300310", "20300314"))
+	assert.NoError(suite.T(), suite.syncer.SyncSource(context.Background(), &source))
+
+	// A failing provider keeps the existing blocks and reports the error
+	suite.feed = ""
+	assert.Error(suite.T(), suite.syncer.SyncSource(context.Background(), &source))
+
+	var stored models.CalendarSource
+	suite.db.First(&stored, source.ID)
+	assert.Equal(suite.T(), "error", stored.SyncStatus)
+	assert.NotEmpty(suite.T(), stored.LastError)
+
+	var count int64
+	suite.db.Model(&models.PropertyBlock{}).Where("calendar_source_id = ?", source.ID).Count(&count)
+	assert.Equal(suite.T(), int64(1), count)
+}
+
+func (suite *SyncerTestSuite) TestSyncRejectsOversizedFeed() {
+	source := suite.createSource()
+
+	suite.feed = feed(event("a@airbnb.com", "20300310", "20300314"))
+	assert.NoError(suite.T(), suite.syncer.SyncSource(context.Background(), &source))
+
+	// A feed over the size cap fails as a whole rather than being cut short
+	suite.feed = feed(event("b@airbnb.com", "20300401", "20300403"), strings.Repeat("X-PAD:padding\r\n", 400000))
+	assert.Error(suite.T(), suite.syncer.SyncSource(context.Background(), &source))
+
+	var stored models.CalendarSource
+	suite.db.First(&stored, source.ID)
+	assert.Equal(suite.T(), "feed too large", stored.LastError)
+
+	var blocks []models.PropertyBlock
+	suite.db.Where("calendar_source_id = ?", source.ID).Find(&blocks)
+	assert.Len(suite.T(), blocks, 1)
+	assert.Equal(suite.T(), "a@airbnb.com", blocks[0].ExternalUID)
+}
+
+func (suite *SyncerTestSuite) TestSyncDoesNotEchoFeedContent() {
+	source := suite.createSource()
+
+	suite.feed = "secret"
+	assert.Error(suite.T(), suite.syncer.SyncSource(context.Background(), &source))
+
+	var stored models.CalendarSource
+	suite.db.First(&stored, source.ID)
+	assert.Equal(suite.T(), "feed is not valid iCalendar", stored.LastError)
+	assert.NotContains(suite.T(), stored.LastError, "abc123")
+}
+
+func (suite *SyncerTestSuite) TestSyncRefusesPrivateAddresses() {
+	source := suite.createSource()
+	suite.feed = feed(event("a@airbnb.com", "20300310", "20300314"))
+
+	// The default client is used in production and won't connect to the loopback stand-in
+	syncer := calendarsync.NewSyncer(suite.db)
+	assert.ErrorIs(suite.T(), syncer.SyncSource(context.Background(), &source), calendarsync.ErrForbiddenAddress)
+
+	var stored models.CalendarSource
+	suite.db.First(&stored, source.ID)
+	assert.Equal(suite.T(), "error", stored.SyncStatus)
+	assert.Equal(suite.T(), calendarsync.ErrForbiddenAddress.Error(), stored.LastError)
+
+	var count int64
+	suite.db.Model(&models.PropertyBlock{}).Where("calendar_source_id = ?", source.ID).Count(&count)
+	assert.Zero(suite.T(), count)
+}
+
+func TestSyncerSuite(t *testing.T) {
+	suite.Run(t, new(SyncerTestSuite))
+}
@@ -0,0 +1,108 @@
+package channel_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bookaroo/bookaroo-platform-be/channel"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type PusherTestSuite struct {
+	suite.Suite
+	db       *gorm.DB
+	received []channel.ARI
+	server   *httptest.Server
+}
+
+func (suite *PusherTestSuite) SetupSuite() {
+	suite.db = tests.SetupTestDB(suite.T())
+
+	// Local mock partner receiving ARI pushes
+	suite.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ari channel.ARI
+		if err := json.NewDecoder(r.Body).Decode(&ari); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		suite.received = append(suite.received, ari)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+}
+
+func (suite *PusherTestSuite) TearDownSuite() {
+	suite.server.Close()
+}
+
+func (suite *PusherTestSuite) SetupTest() {
+	// Clear the database before each test
+	suite.db.Exec("DELETE FROM bookings")
+	suite.db.Exec("DELETE FROM partner_listings")
+	suite.db.Exec("DELETE FROM partners")
+	suite.db.Exec("DELETE FROM properties")
+	suite.db.Exec("DELETE FROM users")
+	suite.received = nil
+}
+
+func (suite *PusherTestSuite) TestPushAll() {
+	owner := models.User{Email: "owner@example.com", Name: "Test Owner", Role: "owner"}
+	suite.db.Create(&owner)
+
+	property := models.Property{Name: "Beach House", Location: "Bali", Price: 120.0, OwnerID: owner.ID}
+	suite.db.Create(&property)
+
+	partner := models.Partner{Name: "Mock OTA", Code: "mock_ota", APIKeyHash: "hash", WebhookURL: suite.server.URL, Active: true}
+	suite.db.Create(&partner)
+	suite.db.Create(&models.PartnerListing{PartnerID: partner.ID, PropertyID: property.ID, ExternalListingID: "OTA-1"})
+
+	tomorrow := time.Now().AddDate(0, 0, 1)
+	suite.db.Create(&models.Booking{PropertyID: property.ID, UserID: &owner.ID, StartDate: tomorrow, EndDate: tomorrow.AddDate(0, 0, 2), Status: "confirmed"})
+
+	pusher := channel.NewPusher(suite.db)
+	pusher.Horizon = 7
+	assert.NoError(suite.T(), pusher.PushAll(context.Background()))
+
+	assert.Len(suite.T(), suite.received, 1)
+	ari := suite.received[0]
+	assert.Equal(suite.T(), "OTA-1", ari.ExternalListingID)
+	assert.Len(suite.T(), ari.Days, 7)
+	assert.True(suite.T(), ari.Days[0].Available)
+	assert.False(suite.T(), ari.Days[1].Available)
+	assert.Equal(suite.T(), 120.0, ari.Days[0].Rate)
+}
+
+func (suite *PusherTestSuite) TestPushClosesUnlistedProperty() {
+	owner := models.User{Email: "owner@example.com", Name: "Test Owner", Role: "owner"}
+	suite.db.Create(&owner)
+
+	archived := time.Now()
+	property := models.Property{Name: "Beach House", Location: "Bali", Price: 120.0, OwnerID: owner.ID, ArchivedAt: &archived}
+	suite.db.Create(&property)
+
+	partner := models.Partner{Name: "Mock OTA", Code: "mock_ota", APIKeyHash: "hash", WebhookURL: suite.server.URL, Active: true}
+	suite.db.Create(&partner)
+	suite.db.Create(&models.PartnerListing{PartnerID: partner.ID, PropertyID: property.ID, ExternalListingID: "OTA-1"})
+
+	pusher := channel.NewPusher(suite.db)
+	pusher.Horizon = 7
+	assert.NoError(suite.T(), pusher.PushAll(context.Background()))
+
+	// Partners are told the archived property has nothing left to sell
+	assert.Len(suite.T(), suite.received, 1)
+	for _, day := range suite.received[0].Days {
+		assert.False(suite.T(), day.Available)
+		assert.Zero(suite.T(), day.Inventory)
+	}
+}
+
+func TestPusherSuite(t *testing.T) {
+	suite.Run(t, new(PusherTestSuite))
+}
@@ -0,0 +1,79 @@
+package duplicates_test
+
+import (
+	"testing"
+
+	"github.com/bookaroo/bookaroo-platform-be/duplicates"
+	"github.com/bookaroo/bookaroo-platform-be/geo"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWords(t *testing.T) {
+	assert.Equal(t, []string{"sunset", "villa"}, duplicates.Words("The Sunset Villa!"))
+	assert.Equal(t, duplicates.Words("villa sunset"), duplicates.Words("The Sunset Villa!"))
+	assert.Equal(t, []string{"2", "bali", "ubud", "villa"}, duplicates.Words("Villa 2, Ubud - Bali, villa"))
+	assert.Empty(t, duplicates.Words(" - "))
+}
+
+func TestOf(t *testing.T) {
+	lat, lng, hash := -8.5, 115.26, int64(-42)
+	fp := duplicates.Of(models.Property{
+		Name: "Sunset Villa", Location: "Ubud", Latitude: &lat, Longitude: &lng,
+		Images: []models.PropertyImage{{ImageURL: "https://example.com/linked.jpg"}, {PerceptualHash: &hash}},
+	})
+	assert.Equal(t, []string{"sunset", "villa"}, fp.Name)
+	assert.Equal(t, &geo.Point{Lat: lat, Lng: lng}, fp.Point)
+	assert.Equal(t, []uint64{uint64(hash)}, fp.Photos)
+}
+
+func TestCompare(t *testing.T) {
+	villa := duplicates.Fingerprint{
+		Name:     duplicates.Words("Sunset Villa"),
+		Location: duplicates.Words("Ubud, Bali"),
+		Point:    &geo.Point{Lat: -8.5, Lng: 115.26},
+		Photos:   []uint64{0xF0F0F0F0F0F0F0F0, 0x0123456789ABCDEF},
+	}
+
+	same := villa
+	same.Name = duplicates.Words("The Sunset Villa")
+	same.Photos = []uint64{0xF0F0F0F0F0F0F0F1} // The same photo, recompressed
+	match := duplicates.Compare(villa, same)
+	assert.InDelta(t, 1.0, match.Score, 0.001)
+	assert.Equal(t, []string{"same name", "same location", "0 m apart", "1 matching photo"}, match.Reasons)
+
+	// Without coordinates or photos on one side, only names and locations count
+	bare := duplicates.Fingerprint{Name: villa.Name, Location: villa.Location}
+	assert.InDelta(t, 1.0, duplicates.Compare(villa, bare).Score, 0.001)
+
+	// The same name far away, with other photos, is another place
+	elsewhere := duplicates.Fingerprint{
+		Name:     villa.Name,
+		Location: duplicates.Words("Canggu, Bali"),
+		Point:    &geo.Point{Lat: -8.65, Lng: 115.13},
+		Photos:   []uint64{0x0F0F0F0F0F0F0F0F},
+	}
+	match = duplicates.Compare(villa, elsewhere)
+	assert.Less(t, match.Score, duplicates.DefaultThreshold)
+	assert.Equal(t, []string{"same name"}, match.Reasons)
+}
+
+func TestClusters(t *testing.T) {
+	clusters := duplicates.Clusters([]models.DuplicateFlag{
+		{ID: 1, PropertyID: 2, DuplicateOfID: 1, Score: 0.8},
+		{ID: 2, PropertyID: 7, DuplicateOfID: 5, Score: 0.95},
+		{ID: 3, PropertyID: 3, DuplicateOfID: 2, Score: 0.9},
+	})
+	require.Len(t, clusters, 2)
+
+	assert.Equal(t, []uint{5, 7}, clusters[0].PropertyIDs)
+	assert.Equal(t, 0.95, clusters[0].Score)
+	assert.Len(t, clusters[0].Flags, 1)
+
+	assert.Equal(t, []uint{1, 2, 3}, clusters[1].PropertyIDs)
+	assert.Equal(t, 0.9, clusters[1].Score)
+	assert.Len(t, clusters[1].Flags, 2)
+
+	assert.Empty(t, duplicates.Clusters(nil))
+}
@@ -0,0 +1,68 @@
+package filters_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/bookaroo/bookaroo-platform-be/filters"
+	"github.com/bookaroo/bookaroo-platform-be/geo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parse(query string) (*filters.Criteria, error) {
+	params, _ := url.ParseQuery(query)
+	return filters.Parse(context.Background(), params, 0, geo.NewGazetteer(), nil)
+}
+
+func TestParse(t *testing.T) {
+	checkIn := time.Now().AddDate(0, 1, 0)
+	criteria, err := parse("near=Ubud&radius_km=10&guests=4&max_price=150&check_in=" +
+		checkIn.Format("2006-01-02") + "&check_out=" + checkIn.AddDate(0, 0, 3).Format("2006-01-02"))
+	require.NoError(t, err)
+	require.NotNil(t, criteria.Center)
+	assert.InDelta(t, -8.5, criteria.Center.Lat, 0.1)
+	assert.Equal(t, 3, criteria.Nights)
+	assert.Equal(t, "en", criteria.Lang)
+
+	criteria, err = parse("")
+	require.NoError(t, err)
+	assert.Nil(t, criteria.Center)
+	assert.Zero(t, criteria.Nights)
+
+	// Any language can be searched; those Postgres can't stem are matched word for word
+	criteria, err = parse("lang=pt-br")
+	require.NoError(t, err)
+	assert.Equal(t, "pt-BR", criteria.Lang)
+	criteria, err = parse("lang=th")
+	require.NoError(t, err)
+	assert.Equal(t, "th", criteria.Lang)
+}
+
+func TestParseRejectsInvalidParameters(t *testing.T) {
+	yesterday := time.Now().AddDate(0, 0, -3)
+	cases := map[string]string{
+		"lang=xx":                   "lang must be a language tag",
+		"place_id=ubud":             "place_id must be a place ID",
+		"guests=0":                  "guests must be a positive number",
+		"check_in=2030-01-05":       "check_in and check_out must both be dates",
+		"near=Atlantis":             `unknown place "Atlantis"`,
+		"lat=1":                     "lat and lng must both be valid coordinates",
+		"lat=1&lng=2&radius_km=900": "radius_km must be between 0 and 500",
+		"bbox=1,2":                  "bbox",
+		"check_in=" + yesterday.Format("2006-01-02") + "&check_out=" + yesterday.AddDate(0, 0, 2).Format("2006-01-02"): "check_in must not be in the past",
+	}
+	for query, message := range cases {
+		_, err := parse(query)
+		var invalid *filters.InvalidError
+		require.ErrorAs(t, err, &invalid, query)
+		assert.Contains(t, err.Error(), message, query)
+	}
+}
+
+func TestOnly(t *testing.T) {
+	params, _ := url.ParseQuery("location=Bali&max_price=150&sort=price_asc&limit=5&cursor=abc&guests=4&utm_source=mail")
+	assert.Equal(t, "guests=4&location=Bali&max_price=150", filters.Only(params).Encode())
+}
@@ -0,0 +1,143 @@
+package geo_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bookaroo/bookaroo-platform-be/geo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDistanceKm(t *testing.T) {
+	paris := geo.Point{Lat: 48.8566, Lng: 2.3522}
+	london := geo.Point{Lat: 51.5074, Lng: -0.1278}
+	assert.InDelta(t, 343.5, geo.DistanceKm(paris, london), 1)
+	assert.Zero(t, geo.DistanceKm(paris, paris))
+}
+
+func TestParseBoundingBox(t *testing.T) {
+	box, err := geo.ParseBoundingBox("114.4,-9.0,115.8,-8.0")
+	require.NoError(t, err)
+	assert.True(t, box.Contains(geo.Point{Lat: -8.5, Lng: 115.2}))
+	assert.False(t, box.Contains(geo.Point{Lat: -6.2, Lng: 106.8}))
+
+	// Viewports can wrap around the antimeridian
+	box, err = geo.ParseBoundingBox("170,-20,-170,0")
+	require.NoError(t, err)
+	assert.True(t, box.Contains(geo.Point{Lat: -10, Lng: 179}))
+	assert.True(t, box.Contains(geo.Point{Lat: -10, Lng: -175}))
+	assert.False(t, box.Contains(geo.Point{Lat: -10, Lng: 0}))
+
+	for _, invalid := range []string{"1,2,3", "a,b,c,d", "0,10,1,5", "0,-91,1,0"} {
+		_, err := geo.ParseBoundingBox(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestGazetteer(t *testing.T) {
+	g := geo.NewGazetteer()
+
+	point, err := g.Geocode(context.Background(), "Villa Sunset, Ubud, Bali")
+	require.NoError(t, err)
+	assert.InDelta(t, -8.5069, point.Lat, 0.0001)
+
+	point, err = g.Geocode(context.Background(), "  new   YORK ")
+	require.NoError(t, err)
+	assert.InDelta(t, -74.006, point.Lng, 0.001)
+
+	_, err = g.Geocode(context.Background(), "Atlantis")
+	assert.ErrorIs(t, err, geo.ErrNotFound)
+}
+
+func TestNominatim(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/search", r.URL.Path)
+		assert.NotEmpty(t, r.Header.Get("User-Agent"))
+		if r.URL.Query().Get("q") == "Ubud" {
+			w.Write([]byte(`[{"lat":"-8.5069","lon":"115.2625"}]`))
+			return
+		}
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	n := geo.NewNominatim(server.URL)
+	point, err := n.Geocode(context.Background(), "Ubud")
+	require.NoError(t, err)
+	assert.Equal(t, geo.Point{Lat: -8.5069, Lng: 115.2625}, point)
+
+	_, err = n.Geocode(context.Background(), "Atlantis")
+	assert.ErrorIs(t, err, geo.ErrNotFound)
+}
+
+func TestNominatimCachesAndThrottles(t *testing.T) {
+	var requests atomic.Int32
+	var failing atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if failing.Load() {
+			http.Error(w, "overloaded", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`[{"lat":"-8.5069","lon":"115.2625"}]`))
+	}))
+	defer server.Close()
+
+	n := geo.NewNominatim(server.URL)
+	n.Interval = 50 * time.Millisecond
+	n.MaxWait = 75 * time.Millisecond
+	ctx := context.Background()
+
+	// Repeated lookups are answered from the cache, however they are written
+	for _, query := range []string{"Ubud", "ubud", "  UBUD "} {
+		point, err := n.Geocode(ctx, query)
+		require.NoError(t, err)
+		assert.Equal(t, geo.Point{Lat: -8.5069, Lng: 115.2625}, point)
+	}
+	assert.Equal(t, int32(1), requests.Load())
+
+	// Requests are spaced out, and lookups that would wait too long for their turn fail
+	time.Sleep(100 * time.Millisecond)
+	var wg sync.WaitGroup
+	var busy atomic.Int32
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := n.Geocode(ctx, fmt.Sprintf("Village %d", i)); errors.Is(err, geo.ErrUnavailable) {
+				busy.Add(1)
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, int32(3), busy.Load())
+	assert.Equal(t, int32(3), requests.Load())
+
+	// Server failures are reported as unavailable and not cached
+	failing.Store(true)
+	time.Sleep(100 * time.Millisecond)
+	_, err := n.Geocode(ctx, "Lovina")
+	assert.ErrorIs(t, err, geo.ErrUnavailable)
+	failing.Store(false)
+	time.Sleep(100 * time.Millisecond)
+	_, err = n.Geocode(ctx, "Lovina")
+	assert.NoError(t, err)
+}
+
+func TestCountryCodes(t *testing.T) {
+	assert.Equal(t, "ID", geo.CountryCode("Indonesia"))
+	assert.Equal(t, "GB", geo.CountryCode("united kingdom"))
+	assert.Empty(t, geo.CountryCode("Atlantis"))
+
+	assert.Equal(t, "Indonesia", geo.CountryName("ID"))
+	assert.Equal(t, "Indonesia", geo.CountryName("id"))
+	assert.Empty(t, geo.CountryName("XX"))
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/bookaroo/bookaroo-platform-be/handlers"
+	"github.com/bookaroo/bookaroo-platform-be/middleware"
 	"github.com/bookaroo/bookaroo-platform-be/models"
 	"github.com/bookaroo/bookaroo-platform-be/tests"
 	"github.com/gin-gonic/gin"
@@ -30,11 +31,14 @@ func (suite *BookingHandlerTestSuite) SetupSuite() {
 	// Setup router
 	suite.router = gin.New()
 	suite.router.POST("/bookings", suite.handler.CreateBooking)
-	suite.router.GET("/bookings/guest/:guest_id", suite.handler.GetGuestBookings)
+	suite.router.GET("/bookings", middleware.AuthMiddleware(), suite.handler.GetGuestBookings)
+	suite.router.GET("/bookings/guest/:guest_id", middleware.AuthMiddleware(), suite.handler.GetGuestBookingsByID)
 }
 
 func (suite *BookingHandlerTestSuite) SetupTest() {
 	// Clear the database before each test
+	suite.db.Exec("DELETE FROM notifications")
+	suite.db.Exec("DELETE FROM installments")
 	suite.db.Exec("DELETE FROM bookings")
 	suite.db.Exec("DELETE FROM properties")
 	suite.db.Exec("DELETE FROM users")
@@ -113,7 +117,7 @@ func (suite *BookingHandlerTestSuite) TestCreateBookingConflict() {
 	
 	existingBooking := models.Booking{
 		PropertyID: property.ID,
-		UserID:     1,
+		UserID:     &owner.ID,
 		StartDate:  startDate,
 		EndDate:    endDate,
 		Status:     "confirmed",
@@ -170,8 +174,8 @@ func (suite *BookingHandlerTestSuite) TestGetGuestBookings() {
 			OwnerID:     owner.ID,
 		},
 	}
-	for _, property := range properties {
-		suite.db.Create(&property)
+	for i := range properties {
+		suite.db.Create(&properties[i])
 	}
 
 	// Create bookings for the guest
@@ -179,7 +183,7 @@ func (suite *BookingHandlerTestSuite) TestGetGuestBookings() {
 	bookings := []models.Booking{
 		{
 			PropertyID: properties[0].ID,
-			UserID:     guest.ID,
+			UserID:     &guest.ID,
 			StartDate:  now.AddDate(0, 0, -10), // Past booking
 			EndDate:    now.AddDate(0, 0, -5),
 			Status:     "completed",
@@ -187,7 +191,7 @@ func (suite *BookingHandlerTestSuite) TestGetGuestBookings() {
 		},
 		{
 			PropertyID: properties[1].ID,
-			UserID:     guest.ID,
+			UserID:     &guest.ID,
 			StartDate:  now.AddDate(0, 0, 5), // Future booking
 			EndDate:    now.AddDate(0, 0, 10),
 			Status:     "confirmed",
@@ -199,7 +203,8 @@ func (suite *BookingHandlerTestSuite) TestGetGuestBookings() {
 	}
 
 	// Make request
-	w := tests.MakeRequest(suite.router, "GET", fmt.Sprintf("/bookings/guest/%d", guest.ID), nil)
+	token := tests.GenerateTestToken(suite.T(), &guest)
+	w := tests.MakeRequestWithToken(suite.router, "GET", "/bookings", nil, token)
 
 	// Assert response
 	assert.Equal(suite.T(), http.StatusOK, w.Code)
@@ -229,11 +234,46 @@ func (suite *BookingHandlerTestSuite) TestGetGuestBookings() {
 	assert.Equal(suite.T(), float64(2), stats["total_bookings"])
 	assert.Equal(suite.T(), float64(1750.0), stats["total_spent"])
 	assert.Equal(suite.T(), float64(1), stats["upcoming_bookings"])
+
+	// Pages hold the newest bookings first while statistics still cover all of them
+	w = tests.MakeRequestWithToken(suite.router, "GET", "/bookings?limit=1", nil, token)
+	var page handlers.GuestBookingsResponse
+	tests.ParseResponse(suite.T(), w, &page)
+	assert.Len(suite.T(), page.Bookings, 1)
+	assert.Equal(suite.T(), "Mountain Cabin", page.Bookings[0].Property.Name)
+	assert.Equal(suite.T(), 2, page.Statistics.TotalBookings)
+	assert.True(suite.T(), page.Meta.HasMore)
+
+	w = tests.MakeRequestWithToken(suite.router, "GET", fmt.Sprintf("/bookings?limit=1&cursor=%s", page.Meta.NextCursor), nil, token)
+	tests.ParseResponse(suite.T(), w, &page)
+	assert.Len(suite.T(), page.Bookings, 1)
+	assert.Equal(suite.T(), "Beach House", page.Bookings[0].Property.Name)
+	assert.False(suite.T(), page.Meta.HasMore)
 }
 
 func (suite *BookingHandlerTestSuite) TestGetGuestBookingsNonExistentGuest() {
-	w := tests.MakeRequest(suite.router, "GET", "/bookings/guest/999999", nil)
+	guest := models.User{Email: "guest@example.com", Name: "Test Guest", Role: "guest"}
+	suite.db.Create(&guest)
+	token := tests.GenerateTestToken(suite.T(), &guest)
+
+	w := tests.MakeRequestWithToken(suite.router, "GET", "/bookings/guest/999999", nil, token)
 	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	// The deprecated path still lists the caller's own bookings
+	w = tests.MakeRequestWithToken(suite.router, "GET", fmt.Sprintf("/bookings/guest/%d", guest.ID), nil, token)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), "true", w.Header().Get("Deprecation"))
+
+	// But not anyone else's
+	other := models.User{Email: "other@example.com", Name: "Other Guest", Role: "guest"}
+	suite.db.Create(&other)
+	w = tests.MakeRequestWithToken(suite.router, "GET", fmt.Sprintf("/bookings/guest/%d", other.ID), nil, token)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+func (suite *BookingHandlerTestSuite) TestGetGuestBookingsUnauthenticated() {
+	w := tests.MakeRequest(suite.router, "GET", "/bookings", nil)
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
 }
 
 func (suite *BookingHandlerTestSuite) TestCreateBookingSuccess() {
@@ -258,6 +298,187 @@ func (suite *BookingHandlerTestSuite) TestCreateBookingSuccess() {
     assert.Equal(suite.T(), "Booking created successfully", response["message"])
 }
 
+func (suite *BookingHandlerTestSuite) TestMonthlyStay() {
+	owner := models.User{Email: "owner@example.com", Name: "Test Owner", Role: "owner"}
+	suite.db.Create(&owner)
+
+	guest := models.User{Email: "guest@example.com", Name: "Test Guest", Role: "guest"}
+	suite.db.Create(&guest)
+
+	property := models.Property{
+		Name:             "Canggu Studio",
+		Location:         "Bali",
+		Price:            50.0,
+		MonthlyPrice:     900.0,
+		NoticePeriodDays: 30,
+		OwnerID:          owner.ID,
+	}
+	suite.db.Create(&property)
+
+	router := gin.New()
+	router.Use(middleware.AuthMiddleware())
+	router.POST("/bookings", suite.handler.CreateBooking)
+	router.GET("/bookings/:id/installments", suite.handler.GetInstallments)
+	router.POST("/bookings/:id/terminate", suite.handler.TerminateStay)
+	token := tests.GenerateTestToken(suite.T(), &guest)
+
+	start := time.Now().AddDate(0, 0, 7).UTC().Truncate(24 * time.Hour)
+	body, _ := json.Marshal(map[string]interface{}{
+		"property_id": property.ID,
+		"start_date":  start,
+		"stay_type":   "monthly",
+		"months":      3,
+	})
+	w := tests.MakeRequestWithToken(router, "POST", "/bookings", body, token)
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	var booking models.Booking
+	suite.db.Where("property_id = ?", property.ID).First(&booking)
+	assert.Equal(suite.T(), "monthly", booking.StayType)
+	assert.Equal(suite.T(), 2700.0, booking.TotalPrice)
+	assert.Equal(suite.T(), start.AddDate(0, 3, 0), booking.EndDate.UTC())
+
+	// The first month is due now, the others are upcoming
+	w = tests.MakeRequestWithToken(router, "GET", fmt.Sprintf("/bookings/%d/installments", booking.ID), nil, token)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var installments handlers.InstallmentsResponse
+	tests.ParseResponse(suite.T(), w, &installments)
+	assert.Len(suite.T(), installments.Upcoming, 3)
+	assert.Equal(suite.T(), "due", installments.Upcoming[0].Status)
+
+	// The owner can't end the guest's stay early
+	w = tests.MakeRequestWithToken(router, "POST", fmt.Sprintf("/bookings/%d/terminate", booking.ID), nil, tests.GenerateTestToken(suite.T(), &owner))
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+	suite.db.First(&booking, booking.ID)
+	assert.Nil(suite.T(), booking.NoticeGivenAt)
+
+	// Notice given now ends the stay after 30 days, voiding the last month
+	w = tests.MakeRequestWithToken(router, "POST", fmt.Sprintf("/bookings/%d/terminate", booking.ID), nil, token)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	tests.ParseResponse(suite.T(), w, &installments)
+	assert.Equal(suite.T(), "void", installments.Upcoming[2].Status)
+	assert.Less(suite.T(), installments.TotalPrice, 2700.0)
+
+	w = tests.MakeRequestWithToken(router, "POST", fmt.Sprintf("/bookings/%d/terminate", booking.ID), nil, token)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+}
+
+func (suite *BookingHandlerTestSuite) TestTerminateBeforeStayStarts() {
+	owner := models.User{Email: "owner@example.com", Name: "Test Owner", Role: "owner"}
+	suite.db.Create(&owner)
+	guest := models.User{Email: "guest@example.com", Name: "Test Guest", Role: "guest"}
+	suite.db.Create(&guest)
+
+	property := models.Property{Name: "Canggu Studio", Location: "Bali", Price: 50.0, MonthlyPrice: 900.0, NoticePeriodDays: 30, OwnerID: owner.ID}
+	suite.db.Create(&property)
+
+	start := time.Now().AddDate(0, 2, 0).UTC().Truncate(24 * time.Hour)
+	booking := models.Booking{PropertyID: property.ID, UserID: &guest.ID, StartDate: start, EndDate: start.AddDate(0, 3, 0), StayType: "monthly", Months: 3, Status: "pending"}
+	suite.db.Create(&booking)
+
+	router := gin.New()
+	router.Use(middleware.AuthMiddleware())
+	router.POST("/bookings/:id/terminate", suite.handler.TerminateStay)
+
+	// The notice period is over before check-in, so the stay has to be cancelled instead
+	w := tests.MakeRequestWithToken(router, "POST", fmt.Sprintf("/bookings/%d/terminate", booking.ID), nil, tests.GenerateTestToken(suite.T(), &guest))
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+
+	suite.db.First(&booking, booking.ID)
+	assert.Nil(suite.T(), booking.NoticeGivenAt)
+}
+
+func (suite *BookingHandlerTestSuite) TestMonthlyStayNotOffered() {
+	owner := models.User{Email: "owner@example.com", Name: "Test Owner", Role: "owner"}
+	suite.db.Create(&owner)
+
+	property := models.Property{Name: "Nightly Only", Location: "Bali", Price: 50.0, OwnerID: owner.ID}
+	suite.db.Create(&property)
+
+	router := gin.New()
+	router.Use(middleware.AuthMiddleware())
+	router.POST("/bookings", suite.handler.CreateBooking)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"property_id": property.ID,
+		"start_date":  time.Now().AddDate(0, 0, 7),
+		"stay_type":   "monthly",
+		"months":      2,
+	})
+	w := tests.MakeRequestWithToken(router, "POST", "/bookings", body, tests.GenerateTestToken(suite.T(), &owner))
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *BookingHandlerTestSuite) TestGetBookingRevealsCheckInOnceConfirmed() {
+	owner := models.User{Email: "owner@example.com", Name: "Test Owner", Role: "owner"}
+	suite.db.Create(&owner)
+	guest := models.User{Email: "guest@example.com", Name: "Test Guest", Role: "guest"}
+	suite.db.Create(&guest)
+	stranger := models.User{Email: "stranger@example.com", Name: "Stranger", Role: "guest"}
+	suite.db.Create(&stranger)
+
+	lat, lng := -8.50712, 115.26345
+	property := models.Property{
+		Name:                "Rice Field Villa",
+		Location:            "Ubud",
+		Latitude:            &lat,
+		Longitude:           &lng,
+		Price:               120.0,
+		OwnerID:             owner.ID,
+		HouseRules:          models.HouseRules{PetsAllowed: true, QuietHoursStart: "22:00", QuietHoursEnd: "07:00"},
+		CheckInInstructions: "Key box code 4821, by the gate",
+	}
+	property.SetAddress(models.Address{Street: "Jalan Raya Sayan 17", City: "Ubud", Region: "Bali", PostalCode: "80571", CountryCode: "ID"})
+	suite.db.Create(&property)
+
+	start := time.Now().AddDate(0, 0, 10)
+	booking := models.Booking{PropertyID: property.ID, UserID: &guest.ID, StartDate: start, EndDate: start.AddDate(0, 0, 2), TotalPrice: 240, Status: "pending"}
+	suite.db.Create(&booking)
+
+	router := gin.New()
+	router.GET("/bookings/:id", middleware.AuthMiddleware(), suite.handler.GetBooking)
+	router.POST("/bookings/:id/confirm", middleware.AuthMiddleware(), suite.handler.ConfirmBooking)
+	path := fmt.Sprintf("/bookings/%d", booking.ID)
+
+	var response handlers.BookingDetailResponse
+	w := tests.MakeRequestWithToken(router, "GET", path, nil, tests.GenerateTestToken(suite.T(), &guest))
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	tests.ParseResponse(suite.T(), w, &response)
+	assert.Empty(suite.T(), response.CheckInInstructions)
+	assert.Equal(suite.T(), "22:00", response.Property.HouseRules.QuietHoursStart)
+	assert.NotContains(suite.T(), w.Body.String(), "4821")
+	assert.Nil(suite.T(), response.Address)
+	assert.NotContains(suite.T(), w.Body.String(), "Sayan")
+	assert.Equal(suite.T(), "Ubud", response.Property.City)
+	assert.Equal(suite.T(), -8.51, *response.Property.Latitude, "rounded until confirmed")
+
+	// Only the owner confirms, and only once
+	w = tests.MakeRequestWithToken(router, "POST", path+"/confirm", nil, tests.GenerateTestToken(suite.T(), &guest))
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+	w = tests.MakeRequestWithToken(router, "POST", path+"/confirm", nil, tests.GenerateTestToken(suite.T(), &owner))
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	w = tests.MakeRequestWithToken(router, "POST", path+"/confirm", nil, tests.GenerateTestToken(suite.T(), &owner))
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+
+	var notification models.Notification
+	suite.Require().NoError(suite.db.Where("user_id = ?", guest.ID).First(&notification).Error)
+	assert.Equal(suite.T(), "booking_confirmed", notification.Type)
+
+	response = handlers.BookingDetailResponse{}
+	w = tests.MakeRequestWithToken(router, "GET", path, nil, tests.GenerateTestToken(suite.T(), &guest))
+	tests.ParseResponse(suite.T(), w, &response)
+	assert.Equal(suite.T(), "Key box code 4821, by the gate", response.CheckInInstructions)
+	suite.Require().NotNil(response.Address)
+	assert.Equal(suite.T(), property.Address(), *response.Address)
+	assert.Equal(suite.T(), lat, *response.Property.Latitude)
+	assert.Equal(suite.T(), lng, *response.Property.Longitude)
+
+	w = tests.MakeRequestWithToken(router, "GET", path, nil, tests.GenerateTestToken(suite.T(), &stranger))
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
 func (suite *BookingHandlerTestSuite) LoginAndGetToken() string {
     // Prepare login request
     loginBody := map[string]interface{}{
@@ -0,0 +1,192 @@
+package handlers_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bookaroo/bookaroo-platform-be/calendarsync"
+	"github.com/bookaroo/bookaroo-platform-be/handlers"
+	"github.com/bookaroo/bookaroo-platform-be/ical"
+	"github.com/bookaroo/bookaroo-platform-be/middleware"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/tests"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type CalendarHandlerTestSuite struct {
+	suite.Suite
+	db      *gorm.DB
+	handler *handlers.CalendarHandler
+	router  *gin.Engine
+}
+
+func (suite *CalendarHandlerTestSuite) SetupSuite() {
+	suite.db = tests.SetupTestDB(suite.T())
+	suite.handler = handlers.NewCalendarHandler(suite.db)
+
+	// Setup router
+	suite.router = gin.New()
+	suite.router.GET("/properties/:id/calendar.ics", suite.handler.ExportCalendar)
+	suite.router.POST("/properties/:id/calendar/token", middleware.AuthMiddleware(), suite.handler.RotateCalendarToken)
+	suite.router.POST("/properties/:id/blocks", middleware.AuthMiddleware(), suite.handler.CreateBlock)
+}
+
+func (suite *CalendarHandlerTestSuite) SetupTest() {
+	// Clear the database before each test
+	suite.db.Exec("DELETE FROM property_blocks")
+	suite.db.Exec("DELETE FROM calendar_sources")
+	suite.db.Exec("DELETE FROM bookings")
+	suite.db.Exec("DELETE FROM properties")
+	suite.db.Exec("DELETE FROM users")
+}
+
+func (suite *CalendarHandlerTestSuite) createProperty() (models.User, models.Property) {
+	owner := models.User{
+		Email: "owner@example.com",
+		Name:  "Test Owner",
+		Role:  "owner",
+	}
+	suite.db.Create(&owner)
+
+	property := models.Property{
+		Name:          "Beach House",
+		Description:   "Beautiful beachfront property",
+		Location:      "Bali",
+		Price:         200.0,
+		OwnerID:       owner.ID,
+		CalendarToken: "feedtoken",
+	}
+	suite.db.Create(&property)
+
+	return owner, property
+}
+
+func (suite *CalendarHandlerTestSuite) TestExportCalendar() {
+	owner, property := suite.createProperty()
+
+	start := time.Date(2030, 3, 10, 0, 0, 0, 0, time.UTC)
+	bookings := []models.Booking{
+		{PropertyID: property.ID, UserID: &owner.ID, StartDate: start, EndDate: start.AddDate(0, 0, 3), Status: "confirmed"},
+		{PropertyID: property.ID, UserID: &owner.ID, StartDate: start.AddDate(0, 0, 5), EndDate: start.AddDate(0, 0, 7), Status: "pending"},
+		{PropertyID: property.ID, UserID: &owner.ID, StartDate: start.AddDate(0, 0, 10), EndDate: start.AddDate(0, 0, 12), Status: "cancelled"},
+	}
+	for i := range bookings {
+		suite.db.Create(&bookings[i])
+	}
+
+	block := models.PropertyBlock{PropertyID: property.ID, StartDate: start.AddDate(0, 1, 0), EndDate: start.AddDate(0, 1, 2)}
+	suite.db.Create(&block)
+
+	w := tests.MakeRequest(suite.router, "GET", fmt.Sprintf("/properties/%d/calendar.ics?token=feedtoken", property.ID), nil)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Header().Get("Content-Type"), "text/calendar")
+
+	body := w.Body.String()
+	assert.Equal(suite.T(), 3, strings.Count(body, "BEGIN:VEVENT"))
+	assert.Contains(suite.T(), body, fmt.Sprintf("UID:booking-%d@bookaroo.com", bookings[0].ID))
+	assert.Contains(suite.T(), body, fmt.Sprintf("UID:booking-%d@bookaroo.com", bookings[1].ID))
+	assert.Contains(suite.T(), body, fmt.Sprintf("UID:block-%d@bookaroo.com", block.ID))
+	assert.NotContains(suite.T(), body, fmt.Sprintf("UID:booking-%d@bookaroo.com", bookings[2].ID))
+
+	// Pending bookings are exported as tentative, confirmed ones as confirmed
+	events, err := ical.Parse(strings.NewReader(body))
+	suite.Require().NoError(err)
+	statuses := map[string]string{}
+	for _, event := range events {
+		statuses[event.UID] = event.Status
+	}
+	assert.Equal(suite.T(), "CONFIRMED", statuses[fmt.Sprintf("booking-%d@bookaroo.com", bookings[0].ID)])
+	assert.Equal(suite.T(), "TENTATIVE", statuses[fmt.Sprintf("booking-%d@bookaroo.com", bookings[1].ID)])
+}
+
+func (suite *CalendarHandlerTestSuite) TestExportCalendarLeavesOutImportedBlocks() {
+	_, property := suite.createProperty()
+
+	// Another channel's feed, naming its guest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/calendar")
+		w.Write([]byte("BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VEVENT\r\nUID:stay-1@airbnb.com\r\n" +
+			"DTSTART;VALUE=DATE:20300310\r\nDTEND;VALUE=DATE:20300314\r\nSUMMARY:Reserved - Jane Doe\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"))
+	}))
+	defer server.Close()
+	source := models.CalendarSource{PropertyID: property.ID, Name: "Airbnb", URL: server.URL}
+	suite.db.Create(&source)
+	syncer := calendarsync.NewSyncer(suite.db)
+	syncer.Client = server.Client()
+	suite.Require().NoError(syncer.SyncSource(context.Background(), &source))
+
+	start := time.Date(2030, 4, 1, 0, 0, 0, 0, time.UTC)
+	block := models.PropertyBlock{PropertyID: property.ID, StartDate: start, EndDate: start.AddDate(0, 0, 2), Note: "Painting"}
+	suite.db.Create(&block)
+
+	w := tests.MakeRequest(suite.router, "GET", fmt.Sprintf("/properties/%d/calendar.ics?token=feedtoken", property.ID), nil)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Equal(suite.T(), 1, strings.Count(body, "BEGIN:VEVENT"))
+	assert.Contains(suite.T(), body, fmt.Sprintf("UID:block-%d@bookaroo.com", block.ID))
+	assert.NotContains(suite.T(), body, "Jane Doe")
+	assert.NotContains(suite.T(), body, "20300310")
+}
+
+func (suite *CalendarHandlerTestSuite) TestExportCalendarInvalidToken() {
+	_, property := suite.createProperty()
+
+	w := tests.MakeRequest(suite.router, "GET", fmt.Sprintf("/properties/%d/calendar.ics?token=wrong", property.ID), nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *CalendarHandlerTestSuite) TestRotateCalendarToken() {
+	owner, property := suite.createProperty()
+	token := tests.GenerateTestToken(suite.T(), &owner)
+
+	w := tests.MakeRequestWithToken(suite.router, "POST", fmt.Sprintf("/properties/%d/calendar/token", property.ID), nil, token)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response handlers.CalendarTokenResponse
+	tests.ParseResponse(suite.T(), w, &response)
+	assert.NotEqual(suite.T(), "feedtoken", response.Token)
+
+	// The previous token no longer grants access
+	w = tests.MakeRequest(suite.router, "GET", fmt.Sprintf("/properties/%d/calendar.ics?token=feedtoken", property.ID), nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	w = tests.MakeRequest(suite.router, "GET", response.URL[len("/api"):], nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *CalendarHandlerTestSuite) TestRotateCalendarTokenNotOwner() {
+	_, property := suite.createProperty()
+
+	other := models.User{Email: "other@example.com", Name: "Other Owner", Role: "owner"}
+	suite.db.Create(&other)
+	token := tests.GenerateTestToken(suite.T(), &other)
+
+	w := tests.MakeRequestWithToken(suite.router, "POST", fmt.Sprintf("/properties/%d/calendar/token", property.ID), nil, token)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+func (suite *CalendarHandlerTestSuite) TestCreateBlock() {
+	owner, property := suite.createProperty()
+	token := tests.GenerateTestToken(suite.T(), &owner)
+
+	body := []byte(`{"start_date":"2030-05-01T00:00:00Z","end_date":"2030-05-04T00:00:00Z","note":"Renovation"}`)
+	w := tests.MakeRequestWithToken(suite.router, "POST", fmt.Sprintf("/properties/%d/blocks", property.ID), body, token)
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	var count int64
+	suite.db.Model(&models.PropertyBlock{}).Where("property_id = ?", property.ID).Count(&count)
+	assert.Equal(suite.T(), int64(1), count)
+}
+
+func TestCalendarHandlerSuite(t *testing.T) {
+	suite.Run(t, new(CalendarHandlerTestSuite))
+}
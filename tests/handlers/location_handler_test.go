@@ -0,0 +1,135 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bookaroo/bookaroo-platform-be/geo"
+	"github.com/bookaroo/bookaroo-platform-be/handlers"
+	"github.com/bookaroo/bookaroo-platform-be/locations"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/search"
+	"github.com/bookaroo/bookaroo-platform-be/tests"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type LocationHandlerTestSuite struct {
+	suite.Suite
+	db     *gorm.DB
+	router *gin.Engine
+	owner  models.User
+}
+
+func (suite *LocationHandlerTestSuite) SetupSuite() {
+	suite.db = tests.SetupTestDB(suite.T())
+	locationHandler := handlers.NewLocationHandler(suite.db)
+	propertyHandler := handlers.NewPropertyHandler(suite.db, search.NewPostgres(suite.db), geo.NewGazetteer())
+
+	suite.router = gin.New()
+	suite.router.GET("/locations/autocomplete", locationHandler.AutocompleteLocations)
+	suite.router.GET("/properties/search", propertyHandler.SearchProperties)
+}
+
+func (suite *LocationHandlerTestSuite) SetupTest() {
+	suite.db.Exec("DELETE FROM bookings")
+	suite.db.Exec("DELETE FROM property_amenities")
+	suite.db.Exec("DELETE FROM property_images")
+	suite.db.Exec("DELETE FROM properties")
+	suite.db.Exec("DELETE FROM places")
+	suite.db.Exec("DELETE FROM users")
+
+	suite.owner = models.User{Email: "owner@example.com", Name: "Owner", Role: "owner"}
+	suite.db.Create(&suite.owner)
+}
+
+// createAt creates a listed property filed under its location
+func (suite *LocationHandlerTestSuite) createAt(location string) models.Property {
+	place, err := locations.Resolve(suite.db, location)
+	suite.Require().NoError(err)
+	property := models.Property{Name: "Stay in " + location, Location: location, Price: 100, OwnerID: suite.owner.ID}
+	if place != nil {
+		property.PlaceID = &place.ID
+	}
+	suite.Require().NoError(suite.db.Create(&property).Error)
+	return property
+}
+
+func (suite *LocationHandlerTestSuite) TestAutocompleteCountsListingsWithinPlaces() {
+	suite.createAt("Ubud")
+	suite.createAt("Ubud, Bali")
+	suite.createAt("Seminyak")
+	archived := suite.createAt("Uluwatu")
+	now := time.Now()
+	suite.db.Model(&archived).Update("archived_at", &now)
+
+	w := tests.MakeRequest(suite.router, "GET", "/locations/autocomplete?q=u", nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var suggestions []locations.Suggestion
+	tests.ParseResponse(suite.T(), w, &suggestions)
+	// Uluwatu only has an archived listing
+	if assert.Len(suite.T(), suggestions, 1) {
+		assert.Equal(suite.T(), "Ubud, Bali, Indonesia", suggestions[0].Label)
+		assert.Equal(suite.T(), locations.KindCity, suggestions[0].Kind)
+		assert.Equal(suite.T(), int64(2), suggestions[0].Listings)
+	}
+
+	w = tests.MakeRequest(suite.router, "GET", "/locations/autocomplete?q=indo", nil)
+	tests.ParseResponse(suite.T(), w, &suggestions)
+	if assert.Len(suite.T(), suggestions, 1) {
+		assert.Equal(suite.T(), locations.KindCountry, suggestions[0].Kind)
+		assert.Equal(suite.T(), int64(3), suggestions[0].Listings)
+	}
+}
+
+func (suite *LocationHandlerTestSuite) TestAutocompleteMatchesLaterWordsAndAccents() {
+	suite.createAt("Nusa Dua")
+	suite.createAt("Nice")
+
+	var suggestions []locations.Suggestion
+	w := tests.MakeRequest(suite.router, "GET", "/locations/autocomplete?q=dua", nil)
+	tests.ParseResponse(suite.T(), w, &suggestions)
+	if assert.Len(suite.T(), suggestions, 1) {
+		assert.Equal(suite.T(), "Nusa Dua", suggestions[0].Name)
+	}
+
+	w = tests.MakeRequest(suite.router, "GET", "/locations/autocomplete?q=cote", nil)
+	tests.ParseResponse(suite.T(), w, &suggestions)
+	if assert.Len(suite.T(), suggestions, 1) {
+		assert.Equal(suite.T(), locations.KindRegion, suggestions[0].Kind)
+	}
+}
+
+func (suite *LocationHandlerTestSuite) TestAutocompleteRejectsBadInput() {
+	w := tests.MakeRequest(suite.router, "GET", "/locations/autocomplete?q=%20,", nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w = tests.MakeRequest(suite.router, "GET", "/locations/autocomplete?q=ub&limit=0", nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *LocationHandlerTestSuite) TestSearchByPlace() {
+	ubud := suite.createAt("Ubud")
+	suite.createAt("Paris")
+
+	var bali models.Place
+	suite.Require().NoError(suite.db.Where("kind = ? AND name = ?", locations.KindRegion, "Bali").First(&bali).Error)
+
+	w := tests.MakeRequest(suite.router, "GET", fmt.Sprintf("/properties/search?place_id=%d", bali.ID), nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response handlers.SearchResponse
+	tests.ParseResponse(suite.T(), w, &response)
+	if assert.Len(suite.T(), response.Properties, 1) {
+		assert.Equal(suite.T(), ubud.ID, response.Properties[0].ID)
+	}
+}
+
+func TestLocationHandlerSuite(t *testing.T) {
+	suite.Run(t, new(LocationHandlerTestSuite))
+}
@@ -0,0 +1,258 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bookaroo/bookaroo-platform-be/channel"
+	"github.com/bookaroo/bookaroo-platform-be/handlers"
+	"github.com/bookaroo/bookaroo-platform-be/middleware"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/tests"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+const partnerAPIKey = "test-partner-key"
+
+type PartnerHandlerTestSuite struct {
+	suite.Suite
+	db       *gorm.DB
+	handler  *handlers.PartnerHandler
+	router   *gin.Engine
+	property models.Property
+}
+
+func (suite *PartnerHandlerTestSuite) SetupSuite() {
+	suite.db = tests.SetupTestDB(suite.T())
+	suite.handler = handlers.NewPartnerHandler(suite.db)
+
+	// Setup router
+	suite.router = gin.New()
+	partner := suite.router.Group("/partner", middleware.PartnerAuth(suite.db))
+	partner.GET("/properties/:id/ari", suite.handler.GetARI)
+	partner.POST("/reservations", suite.handler.CreateReservation)
+	partner.GET("/reservations/:external_ref", suite.handler.GetReservation)
+	partner.PATCH("/reservations/:external_ref", suite.handler.ModifyReservation)
+	partner.DELETE("/reservations/:external_ref", suite.handler.CancelReservation)
+}
+
+func (suite *PartnerHandlerTestSuite) SetupTest() {
+	// Clear the database before each test
+	suite.db.Exec("DELETE FROM waitlist_entries")
+	suite.db.Exec("DELETE FROM notifications")
+	suite.db.Exec("DELETE FROM installments")
+	suite.db.Exec("DELETE FROM bookings")
+	suite.db.Exec("DELETE FROM partner_listings")
+	suite.db.Exec("DELETE FROM partners")
+	suite.db.Exec("DELETE FROM properties")
+	suite.db.Exec("DELETE FROM users")
+
+	owner := models.User{Email: "owner@example.com", Name: "Test Owner", Role: "owner"}
+	suite.db.Create(&owner)
+
+	suite.property = models.Property{Name: "Beach House", Location: "Bali", Price: 100.0, OwnerID: owner.ID}
+	suite.db.Create(&suite.property)
+
+	partner := models.Partner{Name: "Mock OTA", Code: "mock_ota", APIKeyHash: middleware.HashAPIKey(partnerAPIKey), Active: true}
+	suite.db.Create(&partner)
+	suite.db.Create(&models.PartnerListing{PartnerID: partner.ID, PropertyID: suite.property.ID, ExternalListingID: "OTA-1"})
+}
+
+// partnerRequest acts as the mock partner calling our API
+func (suite *PartnerHandlerTestSuite) partnerRequest(method, path, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", partnerAPIKey)
+
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *PartnerHandlerTestSuite) reservationBody(ref, start, end string) string {
+	return fmt.Sprintf(`{"property_id":%d,"external_ref":"%s","start_date":"%sT00:00:00Z","end_date":"%sT00:00:00Z","guest_name":"OTA Guest","guest_email":"guest@ota.example"}`,
+		suite.property.ID, ref, start, end)
+}
+
+func (suite *PartnerHandlerTestSuite) TestRequiresAPIKey() {
+	w := tests.MakeRequest(suite.router, "GET", fmt.Sprintf("/partner/properties/%d/ari?start_date=2030-01-01&end_date=2030-01-05", suite.property.ID), nil)
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+}
+
+func (suite *PartnerHandlerTestSuite) TestReservationLifecycle() {
+	w := suite.partnerRequest("POST", "/partner/reservations", suite.reservationBody("R-1", "2030-01-02", "2030-01-04"))
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	var created handlers.PartnerReservationResponse
+	tests.ParseResponse(suite.T(), w, &created)
+	assert.Equal(suite.T(), "confirmed", created.Status)
+	assert.Equal(suite.T(), "mock_ota", created.Source)
+	assert.Equal(suite.T(), 200.0, created.TotalPrice)
+
+	// The booked nights are no longer available
+	w = suite.partnerRequest("GET", fmt.Sprintf("/partner/properties/%d/ari?start_date=2030-01-01&end_date=2030-01-05", suite.property.ID), "")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var ari channel.ARI
+	json.Unmarshal(w.Body.Bytes(), &ari)
+	assert.Equal(suite.T(), "OTA-1", ari.ExternalListingID)
+	assert.Len(suite.T(), ari.Days, 4)
+	assert.True(suite.T(), ari.Days[0].Available)
+	assert.False(suite.T(), ari.Days[1].Available)
+	assert.False(suite.T(), ari.Days[2].Available)
+	assert.True(suite.T(), ari.Days[3].Available)
+
+	// An overlapping reservation is refused
+	w = suite.partnerRequest("POST", "/partner/reservations", suite.reservationBody("R-2", "2030-01-03", "2030-01-06"))
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+
+	// Moving the reservation doesn't conflict with itself
+	w = suite.partnerRequest("PATCH", "/partner/reservations/R-1", `{"start_date":"2030-01-03T00:00:00Z","end_date":"2030-01-06T00:00:00Z"}`)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = suite.partnerRequest("DELETE", "/partner/reservations/R-1", "")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var booking models.Booking
+	suite.db.Where("external_ref = ?", "R-1").First(&booking)
+	assert.Equal(suite.T(), "cancelled", booking.Status)
+	assert.Equal(suite.T(), 3, booking.EndDate.Day()-booking.StartDate.Day())
+}
+
+func (suite *PartnerHandlerTestSuite) TestReservationKeepsGuestOnBooking() {
+	// An account with the guest's email, such as an owner's, is left alone
+	account := models.User{Email: "guest@ota.example", Name: "Existing Owner", Role: "owner"}
+	suite.db.Create(&account)
+
+	w := suite.partnerRequest("POST", "/partner/reservations", suite.reservationBody("R-3", "2030-02-02", "2030-02-04"))
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	var booking models.Booking
+	suite.db.Where("external_ref = ?", "R-3").First(&booking)
+	assert.Nil(suite.T(), booking.UserID)
+	assert.Equal(suite.T(), "OTA Guest", booking.GuestName)
+	assert.Equal(suite.T(), "guest@ota.example", booking.GuestEmail)
+
+	var users int64
+	suite.db.Model(&models.User{}).Where("email = ?", "guest@ota.example").Count(&users)
+	assert.Equal(suite.T(), int64(1), users)
+
+	// A retried reservation isn't booked twice
+	w = suite.partnerRequest("POST", "/partner/reservations", suite.reservationBody("R-3", "2030-03-02", "2030-03-04"))
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+
+	var bookings int64
+	suite.db.Model(&models.Booking{}).Where("external_ref = ?", "R-3").Count(&bookings)
+	assert.Equal(suite.T(), int64(1), bookings)
+}
+
+func (suite *PartnerHandlerTestSuite) TestModifyReservationRespectsWaitlistAndListing() {
+	w := suite.partnerRequest("POST", "/partner/reservations", suite.reservationBody("R-4", "2030-04-02", "2030-04-06"))
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	// Dates held for a waitlisted guest can't be taken by moving a reservation
+	guest := models.User{Email: "waiting@example.com", Name: "Waiting Guest", Role: "guest"}
+	suite.db.Create(&guest)
+	expires := time.Now().Add(time.Hour)
+	suite.db.Create(&models.WaitlistEntry{
+		PropertyID: suite.property.ID, UserID: guest.ID, Status: "offered", OfferExpiresAt: &expires,
+		StartDate: time.Date(2030, 4, 10, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2030, 4, 12, 0, 0, 0, 0, time.UTC),
+	})
+	w = suite.partnerRequest("PATCH", "/partner/reservations/R-4", `{"start_date":"2030-04-09T00:00:00Z","end_date":"2030-04-11T00:00:00Z"}`)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+
+	// Shortening the stay offers the freed nights to the waitlist
+	waiting := models.WaitlistEntry{
+		PropertyID: suite.property.ID, UserID: guest.ID, Status: "waiting",
+		StartDate: time.Date(2030, 4, 4, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2030, 4, 6, 0, 0, 0, 0, time.UTC),
+	}
+	suite.db.Create(&waiting)
+	w = suite.partnerRequest("PATCH", "/partner/reservations/R-4", `{"start_date":"2030-04-02T00:00:00Z","end_date":"2030-04-04T00:00:00Z"}`)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	suite.db.First(&waiting, waiting.ID)
+	assert.Equal(suite.T(), "offered", waiting.Status)
+
+	// An archived listing can't take new dates
+	suite.db.Model(&suite.property).Update("archived_at", time.Now())
+	w = suite.partnerRequest("PATCH", "/partner/reservations/R-4", `{"start_date":"2030-04-20T00:00:00Z","end_date":"2030-04-22T00:00:00Z"}`)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+}
+
+func (suite *PartnerHandlerTestSuite) TestCancelReservationTwice() {
+	w := suite.partnerRequest("POST", "/partner/reservations", suite.reservationBody("R-5", "2030-05-02", "2030-05-04"))
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	var booking models.Booking
+	suite.db.Where("external_ref = ?", "R-5").First(&booking)
+	suite.db.Create(&models.Installment{BookingID: booking.ID, Sequence: 1, DueDate: booking.StartDate, Amount: 100.0, Status: "scheduled"})
+
+	w = suite.partnerRequest("DELETE", "/partner/reservations/R-5", "")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var installment models.Installment
+	suite.db.Where("booking_id = ?", booking.ID).First(&installment)
+	assert.Equal(suite.T(), "void", installment.Status)
+
+	// A retried cancellation returns the reservation and frees nothing again
+	guest := models.User{Email: "waiting@example.com", Name: "Waiting Guest", Role: "guest"}
+	suite.db.Create(&guest)
+	waiting := models.WaitlistEntry{PropertyID: suite.property.ID, UserID: guest.ID, Status: "waiting", StartDate: booking.StartDate, EndDate: booking.EndDate}
+	suite.db.Create(&waiting)
+
+	w = suite.partnerRequest("DELETE", "/partner/reservations/R-5", "")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var cancelled handlers.PartnerReservationResponse
+	tests.ParseResponse(suite.T(), w, &cancelled)
+	assert.Equal(suite.T(), "cancelled", cancelled.Status)
+
+	suite.db.First(&waiting, waiting.ID)
+	assert.Equal(suite.T(), "waiting", waiting.Status)
+}
+
+func (suite *PartnerHandlerTestSuite) TestConcurrentCancelsOfferDatesOnce() {
+	w := suite.partnerRequest("POST", "/partner/reservations", suite.reservationBody("R-6", "2030-05-02", "2030-05-04"))
+	suite.Require().Equal(http.StatusCreated, w.Code)
+	var booking models.Booking
+	suite.db.Where("external_ref = ?", "R-6").First(&booking)
+
+	guest := models.User{Email: "waiting@example.com", Name: "Waiting Guest", Role: "guest"}
+	suite.db.Create(&guest)
+	suite.db.Create(&models.WaitlistEntry{PropertyID: suite.property.ID, UserID: guest.ID, Status: "waiting", StartDate: booking.StartDate, EndDate: booking.EndDate})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := suite.partnerRequest("DELETE", "/partner/reservations/R-6", "")
+			assert.Equal(suite.T(), http.StatusOK, w.Code)
+		}()
+	}
+	wg.Wait()
+
+	var offers int64
+	suite.db.Model(&models.Notification{}).Where("user_id = ? AND type = ?", guest.ID, "waitlist_offer").Count(&offers)
+	assert.Equal(suite.T(), int64(1), offers)
+}
+
+func (suite *PartnerHandlerTestSuite) TestUnmappedProperty() {
+	other := models.Property{Name: "Cabin", Location: "Alps", Price: 80.0, OwnerID: suite.property.OwnerID}
+	suite.db.Create(&other)
+
+	w := suite.partnerRequest("GET", fmt.Sprintf("/partner/properties/%d/ari?start_date=2030-01-01&end_date=2030-01-05", other.ID), "")
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func TestPartnerHandlerSuite(t *testing.T) {
+	suite.Run(t, new(PartnerHandlerTestSuite))
+}
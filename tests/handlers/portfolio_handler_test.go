@@ -0,0 +1,223 @@
+package handlers_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bookaroo/bookaroo-platform-be/geo"
+	"github.com/bookaroo/bookaroo-platform-be/handlers"
+	"github.com/bookaroo/bookaroo-platform-be/middleware"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/portfolio"
+	"github.com/bookaroo/bookaroo-platform-be/tests"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type PortfolioHandlerTestSuite struct {
+	suite.Suite
+	db       *gorm.DB
+	router   *gin.Engine
+	importer *portfolio.Importer
+	owner    models.User
+	token    string
+}
+
+func (suite *PortfolioHandlerTestSuite) SetupSuite() {
+	suite.db = tests.SetupTestDB(suite.T())
+	handler := handlers.NewPortfolioHandler(suite.db)
+	suite.importer = portfolio.NewImporter(suite.db, geo.NewGazetteer())
+
+	suite.router = gin.New()
+	suite.router.POST("/portfolio/imports", middleware.AuthMiddleware(), handler.ImportProperties)
+	suite.router.GET("/portfolio/imports/:id", middleware.AuthMiddleware(), handler.GetImport)
+	suite.router.GET("/portfolio/export", middleware.AuthMiddleware(), handler.ExportProperties)
+}
+
+func (suite *PortfolioHandlerTestSuite) SetupTest() {
+	suite.db.Exec("DELETE FROM import_rows")
+	suite.db.Exec("DELETE FROM import_jobs")
+	suite.db.Exec("DELETE FROM property_amenities")
+	suite.db.Exec("DELETE FROM property_images")
+	suite.db.Exec("DELETE FROM properties")
+	suite.db.Exec("DELETE FROM users")
+
+	suite.owner = models.User{Email: "owner@example.com", Name: "Owner", Role: "owner"}
+	suite.db.Create(&suite.owner)
+	suite.token = tests.GenerateTestToken(suite.T(), &suite.owner)
+}
+
+func (suite *PortfolioHandlerTestSuite) upload(query, contentType, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/portfolio/imports"+query, strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+suite.token)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *PortfolioHandlerTestSuite) getImport(id uint) models.ImportJob {
+	w := tests.MakeRequestWithToken(suite.router, "GET", fmt.Sprintf("/portfolio/imports/%d", id), nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var job models.ImportJob
+	tests.ParseResponse(suite.T(), w, &job)
+	return job
+}
+
+const importCSV = `name,description,location,price,max_guests,image_urls
+Rice Field Villa,Wake up to green terraces,Ubud,120,4,https://cdn.example.com/a.jpg
+,No name,Canggu,80,2,
+Beach Hut,Steps from the sand,Canggu,80,2,
+`
+
+func (suite *PortfolioHandlerTestSuite) TestImportCreatesValidRowsAsDrafts() {
+	w := suite.upload("", "text/csv", importCSV)
+	suite.Require().Equal(http.StatusAccepted, w.Code, w.Body.String())
+	var job models.ImportJob
+	tests.ParseResponse(suite.T(), w, &job)
+	assert.Equal(suite.T(), "queued", job.Status)
+	assert.Equal(suite.T(), 3, job.TotalRows)
+
+	suite.Require().NoError(suite.importer.RunQueued(context.Background()))
+
+	job = suite.getImport(job.ID)
+	assert.Equal(suite.T(), "done", job.Status)
+	assert.Equal(suite.T(), 3, job.ProcessedRows)
+	assert.Equal(suite.T(), 2, job.CreatedRows)
+	assert.Equal(suite.T(), 1, job.FailedRows)
+	suite.Require().Len(job.Rows, 3)
+	assert.Equal(suite.T(), "created", job.Rows[0].Status)
+	assert.NotNil(suite.T(), job.Rows[0].PropertyID)
+	assert.Equal(suite.T(), "invalid", job.Rows[1].Status)
+	assert.Equal(suite.T(), 3, job.Rows[1].Line)
+	assert.Equal(suite.T(), []string{"name is required"}, job.Rows[1].Errors)
+
+	var properties []models.Property
+	suite.db.Preload("Images").Where("owner_id = ?", suite.owner.ID).Order("id").Find(&properties)
+	suite.Require().Len(properties, 2)
+	assert.Equal(suite.T(), models.ListingDraft, properties[0].Status)
+	assert.NotNil(suite.T(), properties[0].Latitude, "located by the geocoder")
+	suite.Require().Len(properties[0].Images, 1)
+	assert.True(suite.T(), properties[0].Images[0].IsCover)
+}
+
+func (suite *PortfolioHandlerTestSuite) TestDryRunOnlyValidates() {
+	w := suite.upload("?format=csv&dry_run=true", "application/octet-stream", importCSV)
+	suite.Require().Equal(http.StatusAccepted, w.Code, w.Body.String())
+	var job models.ImportJob
+	tests.ParseResponse(suite.T(), w, &job)
+
+	suite.Require().NoError(suite.importer.RunQueued(context.Background()))
+
+	job = suite.getImport(job.ID)
+	assert.Equal(suite.T(), "done", job.Status)
+	assert.Equal(suite.T(), 0, job.CreatedRows)
+	assert.Equal(suite.T(), "valid", job.Rows[0].Status)
+	assert.Equal(suite.T(), "invalid", job.Rows[1].Status)
+
+	var count int64
+	suite.db.Model(&models.Property{}).Count(&count)
+	assert.Equal(suite.T(), int64(0), count)
+}
+
+func (suite *PortfolioHandlerTestSuite) TestStalledImportIsResumed() {
+	w := suite.upload("", "text/csv", importCSV)
+	suite.Require().Equal(http.StatusAccepted, w.Code, w.Body.String())
+	var job models.ImportJob
+	tests.ParseResponse(suite.T(), w, &job)
+
+	// A worker claimed the job and created the first row's property
+	villa := models.Property{Name: "Rice Field Villa", Location: "Ubud", Price: 120, OwnerID: suite.owner.ID, Status: models.ListingDraft}
+	suite.Require().NoError(suite.db.Create(&villa).Error)
+	suite.Require().NoError(suite.db.Create(&models.ImportRow{ImportJobID: job.ID, Line: 2, Name: villa.Name, Status: "created", PropertyID: &villa.ID}).Error)
+	suite.db.Model(&job).Updates(map[string]interface{}{"status": "running", "started_at": time.Now()})
+
+	// While it makes progress, nobody else takes it
+	suite.Require().NoError(suite.importer.RunQueued(context.Background()))
+	assert.Equal(suite.T(), "running", suite.getImport(job.ID).Status)
+
+	// Once it stops, the job is resumed where it was left
+	suite.db.Model(&job).Update("started_at", time.Now().Add(-time.Hour))
+	suite.Require().NoError(suite.importer.RunQueued(context.Background()))
+
+	job = suite.getImport(job.ID)
+	assert.Equal(suite.T(), "done", job.Status)
+	assert.Equal(suite.T(), 3, job.ProcessedRows)
+	assert.Equal(suite.T(), 2, job.CreatedRows)
+	suite.Require().Len(job.Rows, 3)
+	assert.Equal(suite.T(), villa.ID, *job.Rows[0].PropertyID)
+
+	var count int64
+	suite.db.Model(&models.Property{}).Where("owner_id = ?", suite.owner.ID).Count(&count)
+	assert.Equal(suite.T(), int64(2), count)
+
+	// A row is recorded once per job
+	assert.Error(suite.T(), suite.db.Create(&models.ImportRow{ImportJobID: job.ID, Line: 2, Status: "created"}).Error)
+}
+
+func (suite *PortfolioHandlerTestSuite) TestImportRejectsUnreadableFiles() {
+	w := suite.upload("", "application/octet-stream", importCSV)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w = suite.upload("?format=csv", "text/csv", "name,bedrooms\nVilla,3\n")
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	var rows strings.Builder
+	rows.WriteString("name\n")
+	for i := 0; i <= portfolio.MaxRows; i++ {
+		rows.WriteString("Villa\n")
+	}
+	w = suite.upload("", "text/csv", rows.String())
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	var count int64
+	suite.db.Model(&models.ImportJob{}).Count(&count)
+	assert.Equal(suite.T(), int64(0), count)
+}
+
+func (suite *PortfolioHandlerTestSuite) TestGetImportOfAnotherOwner() {
+	other := models.User{Email: "other@example.com", Name: "Other", Role: "owner"}
+	suite.db.Create(&other)
+	job := models.ImportJob{OwnerID: other.ID, Format: portfolio.FormatCSV, Status: "queued"}
+	suite.db.Create(&job)
+
+	w := tests.MakeRequestWithToken(suite.router, "GET", fmt.Sprintf("/portfolio/imports/%d", job.ID), nil, suite.token)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+func (suite *PortfolioHandlerTestSuite) TestExportCanBeImported() {
+	suite.db.Create(&models.Property{
+		Name:        "Rice Field Villa",
+		Description: "Wake up to green terraces",
+		Location:    "Ubud",
+		Price:       120,
+		OwnerID:     suite.owner.ID,
+		Images:      []models.PropertyImage{{ImageURL: "https://cdn.example.com/a.jpg", IsCover: true}},
+	})
+
+	for _, format := range []string{portfolio.FormatCSV, portfolio.FormatJSONLines} {
+		w := tests.MakeRequestWithToken(suite.router, "GET", "/portfolio/export?format="+format, nil, suite.token)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(suite.T(), w.Header().Get("Content-Disposition"), "properties."+format)
+
+		rows, err := portfolio.Read(format, w.Body.Bytes())
+		suite.Require().NoError(err)
+		suite.Require().Len(rows, 1)
+		assert.Equal(suite.T(), "Rice Field Villa", rows[0].Record.Name)
+		assert.Equal(suite.T(), []string{"https://cdn.example.com/a.jpg"}, rows[0].Record.ImageURLs)
+	}
+
+	w := tests.MakeRequestWithToken(suite.router, "GET", "/portfolio/export?format=xml", nil, suite.token)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func TestPortfolioHandlerSuite(t *testing.T) {
+	suite.Run(t, new(PortfolioHandlerTestSuite))
+}
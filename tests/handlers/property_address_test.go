@@ -0,0 +1,188 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/bookaroo/bookaroo-platform-be/geo"
+	"github.com/bookaroo/bookaroo-platform-be/handlers"
+	"github.com/bookaroo/bookaroo-platform-be/middleware"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/search"
+	"github.com/bookaroo/bookaroo-platform-be/tests"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type PropertyAddressTestSuite struct {
+	suite.Suite
+	db     *gorm.DB
+	router *gin.Engine
+	owner  models.User
+	token  string
+}
+
+func (suite *PropertyAddressTestSuite) SetupSuite() {
+	suite.db = tests.SetupTestDB(suite.T())
+	handler := handlers.NewPropertyHandler(suite.db, search.NewPostgres(suite.db), geo.NewGazetteer())
+
+	suite.router = gin.New()
+	suite.router.GET("/properties", handler.ListProperties)
+	suite.router.GET("/properties/search", handler.SearchProperties)
+	suite.router.GET("/properties/:id", handler.GetProperty)
+	suite.router.POST("/properties", middleware.AuthMiddleware(), handler.CreateProperty)
+	suite.router.PATCH("/properties/:id", middleware.AuthMiddleware(), handler.UpdateProperty)
+	suite.router.GET("/properties/:id/owner-details", middleware.AuthMiddleware(), handler.GetPropertyDetailsForOwner)
+}
+
+func (suite *PropertyAddressTestSuite) SetupTest() {
+	suite.db.Exec("DELETE FROM duplicate_flags")
+	suite.db.Exec("DELETE FROM price_changes")
+	suite.db.Exec("DELETE FROM property_amenities")
+	suite.db.Exec("DELETE FROM property_images")
+	suite.db.Exec("DELETE FROM properties")
+	suite.db.Exec("DELETE FROM users")
+
+	suite.owner = models.User{Email: "owner@example.com", Name: "Owner", Role: "owner"}
+	suite.db.Create(&suite.owner)
+	suite.token = tests.GenerateTestToken(suite.T(), &suite.owner)
+}
+
+func (suite *PropertyAddressTestSuite) create(body string) models.Property {
+	w := tests.MakeRequestWithToken(suite.router, "POST", "/properties", []byte(body), suite.token)
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	var property models.Property
+	tests.ParseResponse(suite.T(), w, &property)
+	suite.db.Model(&property).Update("status", models.ListingPublished)
+	return property
+}
+
+func (suite *PropertyAddressTestSuite) search(query string) []uint {
+	w := tests.MakeRequest(suite.router, "GET", "/properties/search?"+query, nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var response struct {
+		Properties []models.Property `json:"properties"`
+	}
+	tests.ParseResponse(suite.T(), w, &response)
+	ids := []uint{}
+	for _, p := range response.Properties {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
+func (suite *PropertyAddressTestSuite) TestPreciseAddressIsPrivate() {
+	villa := suite.create(fmt.Sprintf(`{"name": "Rice Field Villa", "description": "Green terraces", "location": "Sayan, Ubud", "price": 120, "owner_id": %d,
+		"address": {"street": "Jalan Raya Sayan 17", "city": "Ubud", "region": "Bali", "postal_code": "80571", "country_code": "ID"}}`, suite.owner.ID))
+	assert.Equal(suite.T(), "Ubud", villa.City)
+	assert.Equal(suite.T(), "Bali", villa.Region)
+	assert.Equal(suite.T(), "ID", villa.CountryCode)
+
+	w := tests.MakeRequest(suite.router, "GET", fmt.Sprintf("/properties/%d", villa.ID), nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(suite.T(), w.Body.String(), `"city":"Ubud"`)
+	assert.NotContains(suite.T(), w.Body.String(), "Jalan Raya Sayan")
+	assert.NotContains(suite.T(), w.Body.String(), "80571")
+
+	w = tests.MakeRequestWithToken(suite.router, "GET", fmt.Sprintf("/properties/%d/owner-details", villa.ID), nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var details handlers.PropertyDetailsResponse
+	tests.ParseResponse(suite.T(), w, &details)
+	assert.Equal(suite.T(), models.Address{Street: "Jalan Raya Sayan 17", City: "Ubud", Region: "Bali", PostalCode: "80571", CountryCode: "ID"}, details.Address)
+
+	w = tests.MakeRequestWithToken(suite.router, "POST", "/properties", []byte(fmt.Sprintf(`{"name": "Hut", "description": "Small", "location": "Ubud", "price": 50, "owner_id": %d,
+		"address": {"city": "Ubud", "country_code": "Indonesia"}}`, suite.owner.ID)), suite.token)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *PropertyAddressTestSuite) TestExactCoordinatesArePrivate() {
+	villa := suite.create(fmt.Sprintf(`{"name": "Rice Field Villa", "description": "Green terraces", "location": "Jalan Raya Sayan 17", "price": 120,
+		"latitude": -8.50712, "longitude": 115.26345, "owner_id": %d, "address": {"street": "Jalan Raya Sayan 17", "city": "Ubud", "region": "Bali", "country_code": "ID"}}`, suite.owner.ID))
+
+	w := tests.MakeRequest(suite.router, "GET", fmt.Sprintf("/properties/%d", villa.ID), nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var public models.Property
+	tests.ParseResponse(suite.T(), w, &public)
+	assert.Equal(suite.T(), -8.51, *public.Latitude)
+	assert.Equal(suite.T(), 115.26, *public.Longitude)
+	assert.Equal(suite.T(), "Ubud, Bali", public.Location)
+	assert.NotContains(suite.T(), w.Body.String(), "Sayan")
+
+	w = tests.MakeRequest(suite.router, "GET", "/properties/search?lat=-8.5&lng=115.26&radius_km=5", nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(suite.T(), w.Body.String(), `"latitude":-8.51`)
+	assert.NotContains(suite.T(), w.Body.String(), "-8.50712")
+	assert.Empty(suite.T(), suite.search("q=sayan"), "the location isn't searched once the city is known")
+
+	// Distances are measured to the public coordinates, not the exact ones
+	w = tests.MakeRequest(suite.router, "GET", "/properties/search?lat=-8.51&lng=115.26&radius_km=5", nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var results handlers.SearchResponse
+	tests.ParseResponse(suite.T(), w, &results)
+	suite.Require().Len(results.Properties, 1)
+	assert.InDelta(suite.T(), 0, *results.Properties[0].DistanceKm, 1e-9)
+
+	// A bounding box around the exact point alone doesn't find it
+	assert.Empty(suite.T(), suite.search("bbox=115.262,-8.509,115.265,-8.505"))
+	assert.Equal(suite.T(), []uint{villa.ID}, suite.search("bbox=115.259,-8.511,115.261,-8.509"))
+
+	w = tests.MakeRequestWithToken(suite.router, "GET", fmt.Sprintf("/properties/%d/owner-details", villa.ID), nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var details handlers.PropertyDetailsResponse
+	tests.ParseResponse(suite.T(), w, &details)
+	assert.Equal(suite.T(), -8.50712, *details.Latitude)
+	assert.Equal(suite.T(), "Jalan Raya Sayan 17", details.Location)
+}
+
+func (suite *PropertyAddressTestSuite) TestAddressIsReadFromLocation() {
+	hut := suite.create(fmt.Sprintf(`{"name": "Beach Hut", "description": "Steps from the sand", "location": "Canggu", "price": 80, "owner_id": %d}`, suite.owner.ID))
+	assert.Equal(suite.T(), "Canggu", hut.City)
+	assert.Equal(suite.T(), "Bali", hut.Region)
+	assert.Equal(suite.T(), "ID", hut.CountryCode)
+
+	// Without an address of its own, a property follows its location
+	w := tests.MakeRequestWithToken(suite.router, "PATCH", fmt.Sprintf("/properties/%d", hut.ID), []byte(`{"location": "Seminyak"}`), suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var updated models.Property
+	tests.ParseResponse(suite.T(), w, &updated)
+	assert.Equal(suite.T(), "Seminyak", updated.City)
+
+	// Once it has one, location only changes what listings display
+	w = tests.MakeRequestWithToken(suite.router, "PATCH", fmt.Sprintf("/properties/%d", hut.ID),
+		[]byte(`{"address": {"street": "Jalan Pantai 3", "city": "Seminyak", "region": "Bali", "country_code": "ID"}}`), suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	w = tests.MakeRequestWithToken(suite.router, "PATCH", fmt.Sprintf("/properties/%d", hut.ID), []byte(`{"location": "Seminyak beach"}`), suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var stored models.Property
+	suite.db.First(&stored, hut.ID)
+	assert.Equal(suite.T(), "Jalan Pantai 3", stored.Street)
+	assert.Equal(suite.T(), "Seminyak", stored.City)
+}
+
+func (suite *PropertyAddressTestSuite) TestSearchUsesCityAndRegion() {
+	villa := suite.create(fmt.Sprintf(`{"name": "Rice Field Villa", "description": "Green terraces", "location": "Ubud", "price": 120, "owner_id": %d,
+		"address": {"street": "Jalan Kuta 9", "city": "Ubud", "region": "Bali", "country_code": "ID"}}`, suite.owner.ID))
+	flat := suite.create(fmt.Sprintf(`{"name": "City Flat", "description": "Central", "location": "Jakarta", "price": 60, "owner_id": %d}`, suite.owner.ID))
+
+	assert.Equal(suite.T(), []uint{villa.ID}, suite.search("location=ubud"))
+	assert.Equal(suite.T(), []uint{villa.ID}, suite.search("location=Bali"))
+	assert.Empty(suite.T(), suite.search("location=Kuta"), "streets aren't searched")
+	assert.Equal(suite.T(), []uint{villa.ID}, suite.search("city=UBUD"))
+	assert.Equal(suite.T(), []uint{villa.ID}, suite.search("region=bali"))
+	assert.Equal(suite.T(), []uint{flat.ID}, suite.search("city=Jakarta"))
+	assert.Empty(suite.T(), suite.search("city=Uban"))
+
+	w := tests.MakeRequest(suite.router, "GET", "/properties?location=bali", nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var list handlers.PropertyListResponse
+	tests.ParseResponse(suite.T(), w, &list)
+	suite.Require().Len(list.Properties, 1)
+	assert.Equal(suite.T(), villa.ID, list.Properties[0].ID)
+}
+
+func TestPropertyAddressTestSuite(t *testing.T) {
+	suite.Run(t, new(PropertyAddressTestSuite))
+}
@@ -0,0 +1,164 @@
+package handlers_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/bookaroo/bookaroo-platform-be/duplicates"
+	"github.com/bookaroo/bookaroo-platform-be/geo"
+	"github.com/bookaroo/bookaroo-platform-be/handlers"
+	"github.com/bookaroo/bookaroo-platform-be/middleware"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/search"
+	"github.com/bookaroo/bookaroo-platform-be/tests"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type DuplicateListingsTestSuite struct {
+	suite.Suite
+	db         *gorm.DB
+	router     *gin.Engine
+	owner      models.User
+	token      string
+	adminToken string
+}
+
+func (suite *DuplicateListingsTestSuite) SetupSuite() {
+	suite.db = tests.SetupTestDB(suite.T())
+	handler := handlers.NewPropertyHandler(suite.db, search.NewPostgres(suite.db), geo.NewGazetteer())
+
+	suite.router = gin.New()
+	properties := suite.router.Group("/properties", middleware.AuthMiddleware())
+	properties.POST("", handler.CreateProperty)
+	properties.PATCH("/:id", handler.UpdateProperty)
+	admin := suite.router.Group("/admin", middleware.AuthMiddleware(), middleware.RoleAuth("admin"))
+	admin.GET("/duplicates", handler.ListDuplicateClusters)
+	admin.POST("/duplicates/:id/confirm", handler.ConfirmDuplicate)
+	admin.POST("/duplicates/:id/dismiss", handler.DismissDuplicate)
+}
+
+func (suite *DuplicateListingsTestSuite) SetupTest() {
+	suite.db.Exec("DELETE FROM duplicate_flags")
+	suite.db.Exec("DELETE FROM price_changes")
+	suite.db.Exec("DELETE FROM property_amenities")
+	suite.db.Exec("DELETE FROM property_images")
+	suite.db.Exec("DELETE FROM properties")
+	suite.db.Exec("DELETE FROM users")
+
+	suite.owner = models.User{Email: "owner@example.com", Name: "Owner", Role: "owner"}
+	suite.db.Create(&suite.owner)
+	suite.token = tests.GenerateTestToken(suite.T(), &suite.owner)
+	admin := models.User{Email: "admin@example.com", Name: "Admin", Role: "admin"}
+	suite.db.Create(&admin)
+	suite.adminToken = tests.GenerateTestToken(suite.T(), &admin)
+}
+
+func (suite *DuplicateListingsTestSuite) create(name string, lat, lng float64) models.Property {
+	body := fmt.Sprintf(`{"name": %q, "description": "Green terraces", "location": "Ubud", "price": 120, "latitude": %f, "longitude": %f, "owner_id": %d}`,
+		name, lat, lng, suite.owner.ID)
+	w := tests.MakeRequestWithToken(suite.router, "POST", "/properties", []byte(body), suite.token)
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	var property models.Property
+	tests.ParseResponse(suite.T(), w, &property)
+	return property
+}
+
+func (suite *DuplicateListingsTestSuite) clusters(query string) []handlers.DuplicateCluster {
+	w := tests.MakeRequestWithToken(suite.router, "GET", "/admin/duplicates"+query, nil, suite.adminToken)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var clusters []handlers.DuplicateCluster
+	tests.ParseResponse(suite.T(), w, &clusters)
+	return clusters
+}
+
+func (suite *DuplicateListingsTestSuite) TestFlagsAndClustersDuplicates() {
+	first := suite.create("Sunset Villa", -8.5, 115.26)
+	second := suite.create("The Sunset Villa", -8.5001, 115.2601)
+	third := suite.create("Villa Sunset", -8.5, 115.2602)
+	other := suite.create("Beach Hut", -8.5, 115.26)
+
+	clusters := suite.clusters("")
+	suite.Require().Len(clusters, 1)
+	assert.Equal(suite.T(), []uint{first.ID, second.ID, third.ID}, clusters[0].PropertyIDs)
+	suite.Require().Len(clusters[0].Properties, 3)
+	assert.Equal(suite.T(), "Sunset Villa", clusters[0].Properties[0].Name)
+	assert.Len(suite.T(), clusters[0].Flags, 3)
+	assert.Contains(suite.T(), clusters[0].Flags[0].Reasons, "same name")
+	for _, flag := range clusters[0].Flags {
+		assert.NotEqual(suite.T(), other.ID, flag.PropertyID)
+		assert.GreaterOrEqual(suite.T(), flag.Score, 0.75)
+	}
+
+	// Renaming a listing clears its open flags
+	w := tests.MakeRequestWithToken(suite.router, "PATCH", fmt.Sprintf("/properties/%d", third.ID),
+		[]byte(`{"name": "Jungle Treehouse", "latitude": -8.51, "longitude": 115.27}`), suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	clusters = suite.clusters("")
+	suite.Require().Len(clusters, 1)
+	assert.Equal(suite.T(), []uint{first.ID, second.ID}, clusters[0].PropertyIDs)
+}
+
+func (suite *DuplicateListingsTestSuite) TestDismissedPairsStayDismissed() {
+	first := suite.create("Sunset Villa", -8.5, 115.26)
+	second := suite.create("Sunset Villa", -8.5, 115.26)
+	clusters := suite.clusters("")
+	suite.Require().Len(clusters, 1)
+	flag := clusters[0].Flags[0]
+
+	w := tests.MakeRequestWithToken(suite.router, "POST", fmt.Sprintf("/admin/duplicates/%d/dismiss", flag.ID), nil, suite.token)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+	w = tests.MakeRequestWithToken(suite.router, "POST", fmt.Sprintf("/admin/duplicates/%d/dismiss", flag.ID), nil, suite.adminToken)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var dismissed models.DuplicateFlag
+	tests.ParseResponse(suite.T(), w, &dismissed)
+	assert.Equal(suite.T(), models.DuplicateDismissed, dismissed.Status)
+	assert.NotNil(suite.T(), dismissed.ReviewedAt)
+
+	// Saving either listing again doesn't raise the pair again
+	w = tests.MakeRequestWithToken(suite.router, "PATCH", fmt.Sprintf("/properties/%d", second.ID), []byte(`{"price": 110}`), suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	assert.Empty(suite.T(), suite.clusters(""))
+
+	clusters = suite.clusters("?status=dismissed")
+	suite.Require().Len(clusters, 1)
+	assert.Equal(suite.T(), []uint{first.ID, second.ID}, clusters[0].PropertyIDs)
+
+	w = tests.MakeRequestWithToken(suite.router, "GET", "/admin/duplicates?status=maybe", nil, suite.adminToken)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	w = tests.MakeRequestWithToken(suite.router, "POST", "/admin/duplicates/0/confirm", nil, suite.adminToken)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *DuplicateListingsTestSuite) TestMatchesNearlyEqualPhotos() {
+	original := suite.create("Sunset Villa", -8.5, 115.26)
+	hash := int64(0x5a5a5a5a5a5a5a5a)
+	suite.db.Create(&models.PropertyImage{PropertyID: original.ID, ImageURL: "https://example.com/1.jpg", PerceptualHash: &hash})
+
+	// Another owner lists the same villa without coordinates, with a
+	// re-encoded photo a few bits off, and a different villa elsewhere
+	other := models.User{Email: "other@example.com", Name: "Other", Role: "owner"}
+	suite.db.Create(&other)
+	near, far := hash^0b1011, hash^0x3ff
+	copied := models.Property{Name: "Sunset Villa", Location: "Ubud", Price: 120, OwnerID: other.ID}
+	suite.db.Create(&copied)
+	suite.db.Create(&models.PropertyImage{PropertyID: copied.ID, ImageURL: "https://example.com/2.jpg", PerceptualHash: &near})
+	distinct := models.Property{Name: "Sunset Villa", Location: "Ubud", Price: 120, OwnerID: other.ID}
+	suite.db.Create(&distinct)
+	suite.db.Create(&models.PropertyImage{PropertyID: distinct.ID, ImageURL: "https://example.com/3.jpg", PerceptualHash: &far})
+
+	flags, err := duplicates.NewDetector(suite.db).Check(context.Background(), original.ID)
+	suite.Require().NoError(err)
+	suite.Require().Len(flags, 1)
+	assert.Equal(suite.T(), copied.ID, flags[0].PropertyID)
+	assert.Equal(suite.T(), original.ID, flags[0].DuplicateOfID)
+	assert.Contains(suite.T(), flags[0].Reasons, "1 matching photo")
+}
+
+func TestDuplicateListingsTestSuite(t *testing.T) {
+	suite.Run(t, new(DuplicateListingsTestSuite))
+}
@@ -1,18 +1,26 @@
 package handlers_test
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/bookaroo/bookaroo-platform-be/geo"
 	"github.com/bookaroo/bookaroo-platform-be/handlers"
+	"github.com/bookaroo/bookaroo-platform-be/middleware"
 	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/search"
 	"github.com/bookaroo/bookaroo-platform-be/tests"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PropertyHandlerTestSuite struct {
@@ -24,25 +32,38 @@ type PropertyHandlerTestSuite struct {
 
 func (suite *PropertyHandlerTestSuite) SetupSuite() {
 	suite.db = tests.SetupTestDB(suite.T())
-	suite.handler = handlers.NewPropertyHandler(suite.db)
+	suite.handler = handlers.NewPropertyHandler(suite.db, search.NewPostgres(suite.db), geo.NewGazetteer())
 	
 	// Setup router
 	suite.router = gin.New()
 	suite.router.GET("/properties", suite.handler.ListProperties)
 	suite.router.GET("/properties/:id", suite.handler.GetProperty)
-	suite.router.GET("/properties/search", suite.handler.SearchProperties)
-	suite.router.POST("/properties", suite.handler.CreateProperty)
-	suite.router.PUT("/properties/:id", suite.handler.UpdateProperty)
-	suite.router.GET("/properties/:id/owner-details", suite.handler.GetPropertyDetailsForOwner)
+	suite.router.GET("/properties/search", middleware.OptionalAuth(), suite.handler.SearchProperties)
+	suite.router.POST("/properties", middleware.AuthMiddleware(), suite.handler.CreateProperty)
+	suite.router.PATCH("/properties/:id", middleware.AuthMiddleware(), suite.handler.UpdateProperty)
+	suite.router.DELETE("/properties/:id", middleware.AuthMiddleware(), suite.handler.DeleteProperty)
+	suite.router.POST("/properties/:id/archive", middleware.AuthMiddleware(), suite.handler.ArchiveProperty)
+	suite.router.POST("/properties/:id/restore", middleware.AuthMiddleware(), suite.handler.RestoreProperty)
+	suite.router.GET("/properties/:id/owner-details", middleware.AuthMiddleware(), suite.handler.GetPropertyDetailsForOwner)
 }
 
 func (suite *PropertyHandlerTestSuite) SetupTest() {
 	// Clear the database before each test
+	suite.db.Exec("DELETE FROM waitlist_entries")
+	suite.db.Exec("DELETE FROM bookings")
+	suite.db.Exec("DELETE FROM property_blocks")
+	suite.db.Exec("DELETE FROM property_amenities")
 	suite.db.Exec("DELETE FROM property_images")
 	suite.db.Exec("DELETE FROM properties")
 	suite.db.Exec("DELETE FROM users")
 }
 
+// createProperty posts a new property as owner
+func (suite *PropertyHandlerTestSuite) createProperty(owner *models.User, data map[string]interface{}) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(data)
+	return tests.MakeRequestWithToken(suite.router, "POST", "/properties", body, tests.GenerateTestToken(suite.T(), owner))
+}
+
 func (suite *PropertyHandlerTestSuite) TestListProperties() {
 	// Create test data
 	owner := models.User{
@@ -67,11 +88,13 @@ func (suite *PropertyHandlerTestSuite) TestListProperties() {
 	// Assert response
 	assert.Equal(suite.T(), http.StatusOK, w.Code)
 
-	var response []models.Property
+	var response handlers.PropertyListResponse
 	tests.ParseResponse(suite.T(), w, &response)
 	
-	assert.Len(suite.T(), response, 1)
-	assert.Equal(suite.T(), property.Name, response[0].Name)
+	assert.Len(suite.T(), response.Properties, 1)
+	assert.Equal(suite.T(), property.Name, response.Properties[0].Name)
+	assert.Equal(suite.T(), int64(1), response.Meta.TotalEstimate)
+	assert.False(suite.T(), response.Meta.HasMore)
 }
 
 func (suite *PropertyHandlerTestSuite) TestGetProperty() {
@@ -136,11 +159,72 @@ func (suite *PropertyHandlerTestSuite) TestSearchProperties() {
 	w := tests.MakeRequest(suite.router, "GET", "/properties/search?location=Miami", nil)
 	assert.Equal(suite.T(), http.StatusOK, w.Code)
 
-	var response []models.Property
+	var response handlers.SearchResponse
 	tests.ParseResponse(suite.T(), w, &response)
 	
-	assert.Len(suite.T(), response, 1)
-	assert.Equal(suite.T(), "Beach House", response[0].Name)
+	assert.Len(suite.T(), response.Properties, 1)
+	assert.Equal(suite.T(), "Beach House", response.Properties[0].Name)
+}
+
+func (suite *PropertyHandlerTestSuite) TestSearchByAmenitiesWithFacets() {
+	owner := models.User{Email: "owner@example.com", Name: "Test Owner", Role: "owner"}
+	suite.db.Create(&owner)
+
+	catalog := map[string]models.Amenity{}
+	var all []models.Amenity
+	suite.db.Find(&all)
+	for _, a := range all {
+		catalog[a.Code] = a
+	}
+
+	withAmenities := func(name string, codes ...string) {
+		property := models.Property{Name: name, Location: "Bali", Price: 100.0, OwnerID: owner.ID}
+		for _, code := range codes {
+			property.AmenityList = append(property.AmenityList, catalog[code])
+		}
+		suite.db.Create(&property)
+	}
+	withAmenities("Villa", "pool", "wifi", "parking")
+	withAmenities("Bungalow", "pool")
+	withAmenities("Apartment", "wifi")
+
+	w := tests.MakeRequest(suite.router, "GET", "/properties/search?amenities=pool,Wi-Fi", nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response handlers.SearchResponse
+	tests.ParseResponse(suite.T(), w, &response)
+	assert.Len(suite.T(), response.Properties, 1)
+	assert.Equal(suite.T(), "Villa", response.Properties[0].Name)
+
+	// Facets count amenities across all results
+	w = tests.MakeRequest(suite.router, "GET", "/properties/search?location=Bali", nil)
+	tests.ParseResponse(suite.T(), w, &response)
+	assert.Len(suite.T(), response.Properties, 3)
+
+	counts := map[string]int64{}
+	for _, facet := range response.Facets.Amenities {
+		counts[facet.Code] = facet.Count
+	}
+	assert.Equal(suite.T(), map[string]int64{"pool": 2, "wifi": 2, "parking": 1}, counts)
+}
+
+func (suite *PropertyHandlerTestSuite) TestUpdatePropertyAmenities() {
+	owner := models.User{Email: "owner@example.com", Name: "Test Owner", Role: "owner"}
+	suite.db.Create(&owner)
+
+	property := models.Property{Name: "Beach House", Location: "Bali", Price: 200.0, OwnerID: owner.ID}
+	suite.db.Create(&property)
+	token := tests.GenerateTestToken(suite.T(), &owner)
+
+	w := tests.MakeRequestWithToken(suite.router, "PATCH", fmt.Sprintf("/properties/%d", property.ID), []byte(`{"amenity_codes":["pool","helipad"]}`), token)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w = tests.MakeRequestWithToken(suite.router, "PATCH", fmt.Sprintf("/properties/%d", property.ID), []byte(`{"amenity_codes":["pool","wifi"]}`), token)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response models.Property
+	tests.ParseResponse(suite.T(), w, &response)
+	assert.Len(suite.T(), response.AmenityList, 2)
 }
 
 func (suite *PropertyHandlerTestSuite) TestCreateProperty() {
@@ -171,7 +255,7 @@ func (suite *PropertyHandlerTestSuite) TestCreateProperty() {
 	}
 
 	// Make request
-	w := tests.MakeRequest(suite.router, "POST", "/properties", propertyData)
+	w := suite.createProperty(&owner, propertyData)
 
 	// Assert response
 	assert.Equal(suite.T(), http.StatusCreated, w.Code)
@@ -187,6 +271,7 @@ func (suite *PropertyHandlerTestSuite) TestCreateProperty() {
 	assert.Equal(suite.T(), propertyData["amenities"], response.Amenities)
 	assert.Equal(suite.T(), owner.ID, response.OwnerID)
 	assert.Len(suite.T(), response.Images, 2)
+	assert.Equal(suite.T(), models.ListingDraft, response.Status)
 
 	// Verify images were created
 	var images []models.PropertyImage
@@ -194,22 +279,113 @@ func (suite *PropertyHandlerTestSuite) TestCreateProperty() {
 	assert.Len(suite.T(), images, 2)
 }
 
+func (suite *PropertyHandlerTestSuite) TestFreeFormAmenitiesAreLinked() {
+	owner := models.User{Email: "owner@example.com", Name: "Test Owner", Role: "owner"}
+	suite.db.Create(&owner)
+
+	w := suite.createProperty(&owner, map[string]interface{}{
+		"name":        "Beach House",
+		"description": "Beautiful beachfront property",
+		"location":    "Bali",
+		"price":       250.0,
+		"amenities":   "Wi-Fi, swimming pool, hammock",
+	})
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	var created models.Property
+	tests.ParseResponse(suite.T(), w, &created)
+
+	codes := func() []string {
+		var property models.Property
+		suite.db.Preload("AmenityList").First(&property, created.ID)
+		var codes []string
+		for _, a := range property.AmenityList {
+			codes = append(codes, a.Code)
+		}
+		return codes
+	}
+	// Names outside the catalog stay in the text only
+	assert.ElementsMatch(suite.T(), []string{"pool", "wifi"}, codes())
+
+	// Changing the text relinks the catalog entries
+	w = tests.MakeRequestWithToken(suite.router, "PATCH", fmt.Sprintf("/properties/%d", created.ID),
+		[]byte(`{"amenities":"Wi-Fi, free parking"}`), tests.GenerateTestToken(suite.T(), &owner))
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.ElementsMatch(suite.T(), []string{"parking", "wifi"}, codes())
+}
+
+func (suite *PropertyHandlerTestSuite) TestCreatePropertyWithTypeAndHouseRules() {
+	owner := models.User{Email: "owner@example.com", Name: "Test Owner", Role: "owner"}
+	suite.db.Create(&owner)
+
+	propertyData := map[string]interface{}{
+		"name":          "Hostel Bunk",
+		"description":   "A bed in a six-bed dorm",
+		"location":      "Kuta",
+		"price":         15.0,
+		"owner_id":      owner.ID,
+		"property_type": "hostel",
+		"space_type":    "shared_room",
+		"house_rules": map[string]interface{}{
+			"smoking_allowed":   false,
+			"parties_allowed":   false,
+			"pets_allowed":      false,
+			"quiet_hours_start": "23:00",
+			"quiet_hours_end":   "07:00",
+			"other":             "No outside guests after midnight",
+		},
+		"check_in_instructions": "Reception is open 24 hours",
+	}
+	w := suite.createProperty(&owner, propertyData)
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	var response models.Property
+	tests.ParseResponse(suite.T(), w, &response)
+	assert.Equal(suite.T(), "hostel", response.PropertyType)
+	assert.Equal(suite.T(), "shared_room", response.SpaceType)
+	assert.Equal(suite.T(), "23:00", response.HouseRules.QuietHoursStart)
+	assert.Equal(suite.T(), "No outside guests after midnight", response.HouseRules.Other)
+	// Check-in instructions are kept for confirmed guests
+	assert.NotContains(suite.T(), w.Body.String(), "Reception is open")
+
+	var stored models.Property
+	suite.db.First(&stored, response.ID)
+	assert.Equal(suite.T(), "Reception is open 24 hours", stored.CheckInInstructions)
+
+	// Unknown types and half-set quiet hours are rejected
+	propertyData["property_type"] = "castle"
+	w = suite.createProperty(&owner, propertyData)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	propertyData["property_type"] = "hostel"
+	propertyData["house_rules"] = map[string]interface{}{"quiet_hours_start": "23:00"}
+	w = suite.createProperty(&owner, propertyData)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
 func (suite *PropertyHandlerTestSuite) TestCreatePropertyInvalidOwner() {
-	// Prepare request data with non-existent owner
+	owner := models.User{Email: "owner@example.com", Name: "Test Owner", Role: "owner"}
+	suite.db.Create(&owner)
+
+	// Prepare request data naming someone else as the owner
 	propertyData := map[string]interface{}{
 		"name":        "New Beach House",
 		"description": "Beautiful beachfront property",
 		"location":    "Bali",
 		"price":       250.0,
 		"amenities":   "WiFi, Pool, Beach Access",
-		"owner_id":    999, // Non-existent owner ID
+		"owner_id":    owner.ID + 1,
 	}
 
 	// Make request
-	w := tests.MakeRequest(suite.router, "POST", "/properties", propertyData)
+	w := suite.createProperty(&owner, propertyData)
 
 	// Assert response
-	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	var count int64
+	suite.db.Model(&models.Property{}).Count(&count)
+	assert.Zero(suite.T(), count)
 }
 
 func (suite *PropertyHandlerTestSuite) TestUpdateProperty() {
@@ -248,7 +424,6 @@ func (suite *PropertyHandlerTestSuite) TestUpdateProperty() {
 		"location":    "Updated Bali",
 		"price":       300.0,
 		"amenities":   "Updated WiFi, Pool, Beach Access",
-		"owner_id":    owner.ID,
 		"images": []map[string]interface{}{
 			{
 				"image_url": "https://example.com/new1.jpg",
@@ -260,7 +435,8 @@ func (suite *PropertyHandlerTestSuite) TestUpdateProperty() {
 	}
 
 	// Make request
-	w := tests.MakeRequest(suite.router, "PUT", fmt.Sprintf("/properties/%d", property.ID), updateData)
+	body, _ := json.Marshal(updateData)
+	w := tests.MakeRequestWithToken(suite.router, "PATCH", fmt.Sprintf("/properties/%d", property.ID), body, tests.GenerateTestToken(suite.T(), &owner))
 
 	// Assert response
 	assert.Equal(suite.T(), http.StatusOK, w.Code)
@@ -313,7 +489,7 @@ func (suite *PropertyHandlerTestSuite) TestUpdatePropertyUnauthorized() {
 	}
 	suite.db.Create(&property)
 
-	// Try to update property with owner2's ID
+	// Try to update property as owner2, also claiming ownership in the body
 	updateData := map[string]interface{}{
 		"name":        "Updated Beach House",
 		"description": "Updated description",
@@ -323,7 +499,8 @@ func (suite *PropertyHandlerTestSuite) TestUpdatePropertyUnauthorized() {
 	}
 
 	// Make request
-	w := tests.MakeRequest(suite.router, "PUT", fmt.Sprintf("/properties/%d", property.ID), updateData)
+	body, _ := json.Marshal(updateData)
+	w := tests.MakeRequestWithToken(suite.router, "PATCH", fmt.Sprintf("/properties/%d", property.ID), body, tests.GenerateTestToken(suite.T(), &owner2))
 
 	// Assert response
 	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
@@ -336,6 +513,9 @@ func (suite *PropertyHandlerTestSuite) TestUpdatePropertyUnauthorized() {
 }
 
 func (suite *PropertyHandlerTestSuite) TestUpdateNonExistentProperty() {
+	owner := models.User{Email: "owner@example.com", Name: "Test Owner", Role: "owner"}
+	suite.db.Create(&owner)
+
 	updateData := map[string]interface{}{
 		"name":        "Updated Beach House",
 		"description": "Updated description",
@@ -344,7 +524,8 @@ func (suite *PropertyHandlerTestSuite) TestUpdateNonExistentProperty() {
 	}
 
 	// Make request with non-existent property ID
-	w := tests.MakeRequest(suite.router, "PUT", "/properties/999999", updateData)
+	body, _ := json.Marshal(updateData)
+	w := tests.MakeRequestWithToken(suite.router, "PATCH", "/properties/999999", body, tests.GenerateTestToken(suite.T(), &owner))
 
 	// Assert response
 	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
@@ -383,7 +564,7 @@ func (suite *PropertyHandlerTestSuite) TestGetPropertyDetailsForOwner() {
 	bookings := []models.Booking{
 		{
 			PropertyID: property.ID,
-			UserID:     guest.ID,
+			UserID:     &guest.ID,
 			StartDate:  now.AddDate(0, 0, -10),  // Past booking
 			EndDate:    now.AddDate(0, 0, -5),
 			Status:     "completed",
@@ -391,7 +572,7 @@ func (suite *PropertyHandlerTestSuite) TestGetPropertyDetailsForOwner() {
 		},
 		{
 			PropertyID: property.ID,
-			UserID:     guest.ID,
+			UserID:     &guest.ID,
 			StartDate:  now.AddDate(0, 0, 5),   // Future booking
 			EndDate:    now.AddDate(0, 0, 10),
 			Status:     "confirmed",
@@ -403,7 +584,7 @@ func (suite *PropertyHandlerTestSuite) TestGetPropertyDetailsForOwner() {
 	}
 
 	// Make request
-	w := tests.MakeRequest(suite.router, "GET", fmt.Sprintf("/properties/%d/owner-details?owner_id=%d", property.ID, owner.ID), nil)
+	w := tests.MakeRequestWithToken(suite.router, "GET", fmt.Sprintf("/properties/%d/owner-details", property.ID), nil, tests.GenerateTestToken(suite.T(), &owner))
 
 	// Assert response
 	assert.Equal(suite.T(), http.StatusOK, w.Code)
@@ -460,18 +641,379 @@ func (suite *PropertyHandlerTestSuite) TestGetPropertyDetailsForOwnerUnauthorize
 	}
 	suite.db.Create(&property)
 
-	// Try to get details with owner2's ID
-	w := tests.MakeRequest(suite.router, "GET", fmt.Sprintf("/properties/%d/owner-details?owner_id=%d", property.ID, owner2.ID), nil)
+	// Try to get details as owner2
+	w := tests.MakeRequestWithToken(suite.router, "GET", fmt.Sprintf("/properties/%d/owner-details", property.ID), nil, tests.GenerateTestToken(suite.T(), &owner2))
 
 	// Assert response
 	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
 }
 
 func (suite *PropertyHandlerTestSuite) TestGetPropertyDetailsForOwnerNonExistent() {
-	w := tests.MakeRequest(suite.router, "GET", "/properties/999999/owner-details?owner_id=1", nil)
+	owner := models.User{Email: "owner@example.com", Name: "Test Owner", Role: "owner"}
+	suite.db.Create(&owner)
+
+	w := tests.MakeRequestWithToken(suite.router, "GET", "/properties/999999/owner-details", nil, tests.GenerateTestToken(suite.T(), &owner))
 	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
 }
 
+func (suite *PropertyHandlerTestSuite) TestPartialUpdateKeepsOmittedFields() {
+	owner := models.User{Email: "owner@example.com", Name: "Test Owner", Role: "owner"}
+	suite.db.Create(&owner)
+
+	property := models.Property{Name: "Beach House", Description: "Description", Location: "Bali", Price: 200.0, OwnerID: owner.ID}
+	suite.db.Create(&property)
+	suite.db.Create(&models.PropertyImage{PropertyID: property.ID, ImageURL: "https://example.com/1.jpg"})
+
+	w := tests.MakeRequestWithToken(suite.router, "PATCH", fmt.Sprintf("/properties/%d", property.ID), []byte(`{"price":250}`), tests.GenerateTestToken(suite.T(), &owner))
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response models.Property
+	tests.ParseResponse(suite.T(), w, &response)
+	assert.Equal(suite.T(), 250.0, response.Price)
+	assert.Equal(suite.T(), "Beach House", response.Name)
+	assert.Equal(suite.T(), "Bali", response.Location)
+	assert.Len(suite.T(), response.Images, 1)
+}
+
+func (suite *PropertyHandlerTestSuite) TestArchiveHidesPropertyFromGuests() {
+	owner := models.User{Email: "owner@example.com", Name: "Test Owner", Role: "owner"}
+	suite.db.Create(&owner)
+
+	property := models.Property{Name: "Beach House", Location: "Bali", Price: 200.0, OwnerID: owner.ID}
+	suite.db.Create(&property)
+	token := tests.GenerateTestToken(suite.T(), &owner)
+
+	w := tests.MakeRequestWithToken(suite.router, "POST", fmt.Sprintf("/properties/%d/archive", property.ID), nil, token)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = tests.MakeRequest(suite.router, "GET", fmt.Sprintf("/properties/%d", property.ID), nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	var listed handlers.PropertyListResponse
+	w = tests.MakeRequest(suite.router, "GET", "/properties", nil)
+	tests.ParseResponse(suite.T(), w, &listed)
+	assert.Len(suite.T(), listed.Properties, 0)
+
+	// The owner can still manage it
+	w = tests.MakeRequestWithToken(suite.router, "GET", fmt.Sprintf("/properties/%d/owner-details", property.ID), nil, token)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = tests.MakeRequestWithToken(suite.router, "POST", fmt.Sprintf("/properties/%d/restore", property.ID), nil, token)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = tests.MakeRequest(suite.router, "GET", fmt.Sprintf("/properties/%d", property.ID), nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *PropertyHandlerTestSuite) TestDeleteProperty() {
+	owner := models.User{Email: "owner@example.com", Name: "Test Owner", Role: "owner"}
+	suite.db.Create(&owner)
+	guest := models.User{Email: "guest@example.com", Name: "Test Guest", Role: "guest"}
+	suite.db.Create(&guest)
+
+	property := models.Property{Name: "Beach House", Location: "Bali", Price: 200.0, OwnerID: owner.ID}
+	suite.db.Create(&property)
+
+	now := time.Now()
+	booking := models.Booking{PropertyID: property.ID, UserID: &guest.ID, StartDate: now.AddDate(0, 0, 5), EndDate: now.AddDate(0, 0, 10), Status: "confirmed"}
+	suite.db.Create(&booking)
+
+	// Only the owner can delete it
+	w := tests.MakeRequestWithToken(suite.router, "DELETE", fmt.Sprintf("/properties/%d", property.ID), nil, tests.GenerateTestToken(suite.T(), &guest))
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	// Upcoming bookings block deletion
+	token := tests.GenerateTestToken(suite.T(), &owner)
+	w = tests.MakeRequestWithToken(suite.router, "DELETE", fmt.Sprintf("/properties/%d", property.ID), nil, token)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+
+	suite.db.Model(&booking).Update("status", "cancelled")
+	w = tests.MakeRequestWithToken(suite.router, "DELETE", fmt.Sprintf("/properties/%d", property.ID), nil, token)
+	assert.Equal(suite.T(), http.StatusNoContent, w.Code)
+
+	w = tests.MakeRequest(suite.router, "GET", fmt.Sprintf("/properties/%d", property.ID), nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	// A deleted property can only be restored
+	w = tests.MakeRequestWithToken(suite.router, "PATCH", fmt.Sprintf("/properties/%d", property.ID), []byte(`{"price":300}`), token)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+	w = tests.MakeRequestWithToken(suite.router, "POST", fmt.Sprintf("/properties/%d/archive", property.ID), nil, token)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+	w = tests.MakeRequestWithToken(suite.router, "DELETE", fmt.Sprintf("/properties/%d", property.ID), nil, token)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	// Booking history is kept
+	var count int64
+	suite.db.Model(&models.Booking{}).Where("property_id = ?", property.ID).Count(&count)
+	assert.Equal(suite.T(), int64(1), count)
+}
+
+func (suite *PropertyHandlerTestSuite) TestDeleteWaitsForBookingInProgress() {
+	owner := models.User{Email: "owner@example.com", Name: "Test Owner", Role: "owner"}
+	suite.db.Create(&owner)
+	guest := models.User{Email: "guest@example.com", Name: "Test Guest", Role: "guest"}
+	suite.db.Create(&guest)
+	property := models.Property{Name: "Beach House", Location: "Bali", Price: 200.0, OwnerID: owner.ID}
+	suite.db.Create(&property)
+
+	// A booking being made holds the property's lock until it is saved
+	tx := suite.db.Begin()
+	suite.Require().NoError(tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.Property{}, property.ID).Error)
+
+	deleted := make(chan int)
+	go func() {
+		w := tests.MakeRequestWithToken(suite.router, "DELETE", fmt.Sprintf("/properties/%d", property.ID), nil, tests.GenerateTestToken(suite.T(), &owner))
+		deleted <- w.Code
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	now := time.Now()
+	suite.Require().NoError(tx.Create(&models.Booking{PropertyID: property.ID, UserID: &guest.ID, StartDate: now.AddDate(0, 0, 5), EndDate: now.AddDate(0, 0, 10), Status: "pending"}).Error)
+	suite.Require().NoError(tx.Commit().Error)
+
+	assert.Equal(suite.T(), http.StatusConflict, <-deleted)
+	suite.db.First(&property, property.ID)
+	assert.Nil(suite.T(), property.DeletedAt)
+}
+
+func (suite *PropertyHandlerTestSuite) TestListPropertiesReturnsCoverOnly() {
+	owner := models.User{Email: "owner@example.com", Name: "Test Owner", Role: "owner"}
+	suite.db.Create(&owner)
+
+	property := models.Property{Name: "Beach House", Location: "Bali", Price: 200.0, OwnerID: owner.ID}
+	suite.db.Create(&property)
+	suite.db.Create(&models.PropertyImage{PropertyID: property.ID, ImageURL: "https://example.com/1.jpg", Position: 0})
+	suite.db.Create(&models.PropertyImage{PropertyID: property.ID, ImageURL: "https://example.com/2.jpg", Position: 1, IsCover: true})
+
+	var listed handlers.PropertyListResponse
+	w := tests.MakeRequest(suite.router, "GET", "/properties", nil)
+	tests.ParseResponse(suite.T(), w, &listed)
+	assert.Len(suite.T(), listed.Properties, 1)
+	assert.Len(suite.T(), listed.Properties[0].Images, 1)
+	assert.Equal(suite.T(), "https://example.com/2.jpg", listed.Properties[0].Images[0].ImageURL)
+
+	// The detail view has every image in display order
+	var detail models.Property
+	w = tests.MakeRequest(suite.router, "GET", fmt.Sprintf("/properties/%d", property.ID), nil)
+	tests.ParseResponse(suite.T(), w, &detail)
+	assert.Len(suite.T(), detail.Images, 2)
+	assert.Equal(suite.T(), "https://example.com/1.jpg", detail.Images[0].ImageURL)
+}
+
+func (suite *PropertyHandlerTestSuite) TestGeoSearch() {
+	owner := models.User{Email: "owner@example.com", Name: "Test Owner", Role: "owner"}
+	suite.db.Create(&owner)
+
+	at := func(name string, lat, lng float64) {
+		suite.db.Create(&models.Property{Name: name, Location: name, Price: 100.0, OwnerID: owner.ID, Latitude: &lat, Longitude: &lng})
+	}
+	at("Ubud Villa", -8.5069, 115.2625)
+	at("Canggu Loft", -8.6478, 115.1385)
+	at("Jakarta Flat", -6.2088, 106.8456)
+	suite.db.Create(&models.Property{Name: "Unmapped", Location: "Somewhere", Price: 100.0, OwnerID: owner.ID})
+
+	// Within 30 km of Ubud, nearest first
+	w := tests.MakeRequest(suite.router, "GET", "/properties/search?near=Ubud&radius_km=30&sort=distance", nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response handlers.SearchResponse
+	tests.ParseResponse(suite.T(), w, &response)
+	assert.Len(suite.T(), response.Properties, 2)
+	assert.Equal(suite.T(), "Ubud Villa", response.Properties[0].Name)
+	assert.InDelta(suite.T(), 0, *response.Properties[0].DistanceKm, 0.1)
+	assert.Equal(suite.T(), "Canggu Loft", response.Properties[1].Name)
+
+	// Map viewport around Java
+	w = tests.MakeRequest(suite.router, "GET", "/properties/search?bbox=105,-8,112,-6", nil)
+	tests.ParseResponse(suite.T(), w, &response)
+	assert.Len(suite.T(), response.Properties, 1)
+	assert.Equal(suite.T(), "Jakarta Flat", response.Properties[0].Name)
+
+	w = tests.MakeRequest(suite.router, "GET", "/properties/search?sort=distance", nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w = tests.MakeRequest(suite.router, "GET", "/properties/search?near=Atlantis", nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	// A geocoder outage isn't the client's fault
+	geocoder := suite.handler.Geocoder
+	suite.handler.Geocoder = unavailableGeocoder{}
+	defer func() { suite.handler.Geocoder = geocoder }()
+	w = tests.MakeRequest(suite.router, "GET", "/properties/search?near=Ubud", nil)
+	assert.Equal(suite.T(), http.StatusServiceUnavailable, w.Code)
+}
+
+// unavailableGeocoder fails like a geocoder that can't be reached
+type unavailableGeocoder struct{}
+
+func (unavailableGeocoder) Geocode(ctx context.Context, query string) (geo.Point, error) {
+	return geo.Point{}, geo.ErrUnavailable
+}
+
+func (suite *PropertyHandlerTestSuite) TestSearchByDates() {
+	owner := models.User{Email: "owner@example.com", Name: "Test Owner", Role: "owner"}
+	suite.db.Create(&owner)
+	guest := models.User{Email: "guest@example.com", Name: "Test Guest", Role: "guest"}
+	suite.db.Create(&guest)
+
+	booked := models.Property{Name: "Booked Villa", Location: "Bali", Price: 100.0, OwnerID: owner.ID}
+	blocked := models.Property{Name: "Blocked Villa", Location: "Bali", Price: 100.0, OwnerID: owner.ID}
+	free := models.Property{Name: "Free Villa", Location: "Bali", Price: 120.0, OwnerID: owner.ID, MaxGuests: 4}
+	small := models.Property{Name: "Studio", Location: "Bali", Price: 50.0, OwnerID: owner.ID, MaxGuests: 2}
+	for _, p := range []*models.Property{&booked, &blocked, &free, &small} {
+		suite.db.Create(p)
+	}
+
+	checkIn := time.Date(2030, 3, 10, 0, 0, 0, 0, time.UTC)
+	suite.db.Create(&models.Booking{PropertyID: booked.ID, UserID: &guest.ID, StartDate: checkIn.AddDate(0, 0, 3), EndDate: checkIn.AddDate(0, 0, 8), Status: "confirmed"})
+	suite.db.Create(&models.PropertyBlock{PropertyID: blocked.ID, StartDate: checkIn.AddDate(0, 0, -2), EndDate: checkIn.AddDate(0, 0, 1)})
+	// Cancelled bookings and stays ending on check-in day don't count
+	suite.db.Create(&models.Booking{PropertyID: free.ID, UserID: &guest.ID, StartDate: checkIn, EndDate: checkIn.AddDate(0, 0, 5), Status: "cancelled"})
+	suite.db.Create(&models.Booking{PropertyID: free.ID, UserID: &guest.ID, StartDate: checkIn.AddDate(0, 0, -3), EndDate: checkIn, Status: "confirmed"})
+
+	w := tests.MakeRequest(suite.router, "GET", "/properties/search?location=Bali&check_in=2030-03-10&check_out=2030-03-15&guests=3", nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response handlers.SearchResponse
+	tests.ParseResponse(suite.T(), w, &response)
+	assert.Len(suite.T(), response.Properties, 1)
+	assert.Equal(suite.T(), "Free Villa", response.Properties[0].Name)
+	assert.Equal(suite.T(), 5, response.Properties[0].Nights)
+	assert.Equal(suite.T(), 600.0, *response.Properties[0].TotalPrice)
+
+	w = tests.MakeRequest(suite.router, "GET", "/properties/search?check_in=2030-03-15&check_out=2030-03-10", nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *PropertyHandlerTestSuite) TestSearchByDatesSkipsWaitlistHolds() {
+	owner := models.User{Email: "owner@example.com", Name: "Test Owner", Role: "owner"}
+	suite.db.Create(&owner)
+	waitlisted := models.User{Email: "waitlisted@example.com", Name: "Waitlisted Guest", Role: "guest"}
+	suite.db.Create(&waitlisted)
+	other := models.User{Email: "other@example.com", Name: "Other Guest", Role: "guest"}
+	suite.db.Create(&other)
+
+	property := models.Property{Name: "Held Villa", Location: "Bali", Price: 100.0, OwnerID: owner.ID}
+	suite.db.Create(&property)
+
+	// Freed dates are offered to the waitlisted guest for a while
+	checkIn := time.Date(2030, 3, 10, 0, 0, 0, 0, time.UTC)
+	offered, expires := time.Now(), time.Now().Add(time.Hour)
+	suite.db.Create(&models.WaitlistEntry{PropertyID: property.ID, UserID: waitlisted.ID, StartDate: checkIn, EndDate: checkIn.AddDate(0, 0, 3),
+		Status: "offered", OfferedAt: &offered, OfferExpiresAt: &expires})
+
+	search := func(user *models.User) int {
+		path := "/properties/search?check_in=2030-03-11&check_out=2030-03-14"
+		w := tests.MakeRequest(suite.router, "GET", path, nil)
+		if user != nil {
+			w = tests.MakeRequestWithToken(suite.router, "GET", path, nil, tests.GenerateTestToken(suite.T(), user))
+		}
+		assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+		var response handlers.SearchResponse
+		tests.ParseResponse(suite.T(), w, &response)
+		return len(response.Properties)
+	}
+
+	// Only the guest holding the offer can book the dates, so only they find the property
+	assert.Equal(suite.T(), 0, search(nil))
+	assert.Equal(suite.T(), 0, search(&other))
+	assert.Equal(suite.T(), 1, search(&waitlisted))
+}
+
+func (suite *PropertyHandlerTestSuite) TestPaginatesWithCursor() {
+	owner := models.User{Email: "owner@example.com", Name: "Test Owner", Role: "owner"}
+	suite.db.Create(&owner)
+	for i, price := range []float64{300, 100, 200, 100, 250} {
+		suite.db.Create(&models.Property{Name: fmt.Sprintf("Villa %d", i), Location: "Bali", Price: price, OwnerID: owner.ID})
+	}
+
+	// Walk every page, following next_cursor
+	var prices []float64
+	path := "/properties?sort=price_asc&limit=2"
+	for pages := 0; ; pages++ {
+		require.Less(suite.T(), pages, 3)
+		w := tests.MakeRequest(suite.router, "GET", path, nil)
+		require.Equal(suite.T(), http.StatusOK, w.Code)
+
+		var response handlers.PropertyListResponse
+		tests.ParseResponse(suite.T(), w, &response)
+		assert.Equal(suite.T(), int64(5), response.Meta.TotalEstimate)
+		for _, p := range response.Properties {
+			prices = append(prices, p.Price)
+		}
+		if !response.Meta.HasMore {
+			break
+		}
+		path = "/properties?sort=price_asc&limit=2&cursor=" + response.Meta.NextCursor
+	}
+	assert.Equal(suite.T(), []float64{100, 100, 200, 250, 300}, prices)
+
+	// Search pages the same way
+	w := tests.MakeRequest(suite.router, "GET", "/properties/search?sort=price_desc&limit=3", nil)
+	var search handlers.SearchResponse
+	tests.ParseResponse(suite.T(), w, &search)
+	require.Len(suite.T(), search.Properties, 3)
+	assert.Equal(suite.T(), 300.0, search.Properties[0].Price)
+	assert.True(suite.T(), search.Meta.HasMore)
+	cursor := search.Meta.NextCursor
+
+	w = tests.MakeRequest(suite.router, "GET", "/properties/search?sort=price_desc&limit=3&cursor="+cursor, nil)
+	tests.ParseResponse(suite.T(), w, &search)
+	assert.Len(suite.T(), search.Properties, 2)
+	assert.False(suite.T(), search.Meta.HasMore)
+
+	// A cursor only continues the sort it came from
+	w = tests.MakeRequest(suite.router, "GET", "/properties?sort=newest&cursor="+cursor, nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	w = tests.MakeRequest(suite.router, "GET", "/properties?limit=0", nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *PropertyHandlerTestSuite) TestFullTextSearch() {
+	owner := models.User{Email: "owner@example.com", Name: "Test Owner", Role: "owner"}
+	suite.db.Create(&owner)
+
+	villa := models.Property{Name: "Beach Villa", Location: "Seminyak, Bali", Price: 300.0, OwnerID: owner.ID,
+		Description: "Steps from the sand, with a private pool and <b>sunset</b> views."}
+	cottage := models.Property{Name: "Garden Cottage", Location: "Ubud, Bali", Price: 90.0, OwnerID: owner.ID,
+		Description: "Quiet rice field views, a short drive to the beach villas of Canggu. Shared pool."}
+	chalet := models.Property{Name: "Chalet des Alpes", Location: "Chamonix", Price: 250.0, OwnerID: owner.ID, Language: "fr",
+		Description: "Chalet au pied des pistes avec piscines chauffées."}
+	for _, p := range []*models.Property{&villa, &cottage, &chalet} {
+		suite.db.Create(p)
+	}
+
+	w := tests.MakeRequest(suite.router, "GET", "/properties/search?q=beach+villa+with+pool", nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response handlers.SearchResponse
+	tests.ParseResponse(suite.T(), w, &response)
+	require.Len(suite.T(), response.Properties, 2)
+	// A match in the name outranks one in the description
+	assert.Equal(suite.T(), "Beach Villa", response.Properties[0].Name)
+	assert.Greater(suite.T(), *response.Properties[0].Relevance, *response.Properties[1].Relevance)
+	assert.Contains(suite.T(), response.Properties[0].Snippet, "<mark>pool</mark>")
+	assert.Contains(suite.T(), response.Properties[0].Snippet, "&lt;b&gt;")
+
+	// Listings are stemmed in their own language
+	w = tests.MakeRequest(suite.router, "GET", "/properties/search?q=piscine&lang=fr", nil)
+	tests.ParseResponse(suite.T(), w, &response)
+	require.Len(suite.T(), response.Properties, 1)
+	assert.Equal(suite.T(), "Chalet des Alpes", response.Properties[0].Name)
+
+	// Edits are indexed straight away
+	suite.db.Model(&cottage).Update("name", "Jungle Treehouse")
+	w = tests.MakeRequest(suite.router, "GET", "/properties/search?q=treehouse", nil)
+	tests.ParseResponse(suite.T(), w, &response)
+	assert.Len(suite.T(), response.Properties, 1)
+
+	w = tests.MakeRequest(suite.router, "GET", "/properties/search?q=pool&lang=xx", nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	w = tests.MakeRequest(suite.router, "GET", "/properties/search?sort=relevance", nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
 func TestPropertyHandlerSuite(t *testing.T) {
 	suite.Run(t, new(PropertyHandlerTestSuite))
 }
@@ -0,0 +1,208 @@
+package handlers_test
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bookaroo/bookaroo-platform-be/geo"
+	"github.com/bookaroo/bookaroo-platform-be/handlers"
+	"github.com/bookaroo/bookaroo-platform-be/middleware"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/search"
+	"github.com/bookaroo/bookaroo-platform-be/storage"
+	"github.com/bookaroo/bookaroo-platform-be/tests"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type PropertyImageHandlerTestSuite struct {
+	suite.Suite
+	db       *gorm.DB
+	handler  *handlers.PropertyHandler
+	router   *gin.Engine
+	dir      string
+	owner    models.User
+	property models.Property
+}
+
+// stubWebP stands in for cwebp, which may not be installed where tests run
+type stubWebP struct{}
+
+func (stubWebP) EncodeWebP(image.Image) ([]byte, error) {
+	return []byte("RIFF"), nil
+}
+
+func (suite *PropertyImageHandlerTestSuite) SetupSuite() {
+	suite.db = tests.SetupTestDB(suite.T())
+	suite.handler = handlers.NewPropertyHandler(suite.db, search.NewPostgres(suite.db), geo.NewGazetteer())
+	suite.handler.WebP = stubWebP{}
+
+	// Setup router
+	suite.router = gin.New()
+	suite.router.Use(middleware.AuthMiddleware())
+	suite.router.POST("/properties/:id/images", suite.handler.UploadPropertyImages)
+	suite.router.DELETE("/properties/:id/images/:image_id", suite.handler.DeletePropertyImage)
+	suite.router.PATCH("/properties/:id/images/:image_id", suite.handler.UpdatePropertyImage)
+	suite.router.PUT("/properties/:id/images/order", suite.handler.ReorderPropertyImages)
+}
+
+func (suite *PropertyImageHandlerTestSuite) SetupTest() {
+	// Clear the database before each test
+	suite.db.Exec("DELETE FROM property_images")
+	suite.db.Exec("DELETE FROM properties")
+	suite.db.Exec("DELETE FROM users")
+
+	suite.dir = suite.T().TempDir()
+	suite.handler.Storage = storage.NewLocal(suite.dir, "/uploads")
+
+	suite.owner = models.User{Email: "owner@example.com", Name: "Test Owner", Role: "owner"}
+	suite.db.Create(&suite.owner)
+
+	suite.property = models.Property{Name: "Beach House", Location: "Bali", Price: 100.0, OwnerID: suite.owner.ID}
+	suite.db.Create(&suite.property)
+}
+
+func (suite *PropertyImageHandlerTestSuite) upload(user *models.User, files map[string][]byte) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for name, data := range files {
+		part, _ := form.CreateFormFile("images", name)
+		part.Write(data)
+	}
+	form.Close()
+
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/properties/%d/images", suite.property.ID), &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+tests.GenerateTestToken(suite.T(), user))
+
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func samplePNG() []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, 640, 480))
+	for x := 0; x < 640; x++ {
+		img.Set(x, x%480, color.NRGBA{R: 200, A: 255})
+	}
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+func (suite *PropertyImageHandlerTestSuite) TestUploadAndDelete() {
+	w := suite.upload(&suite.owner, map[string][]byte{"photo.png": samplePNG()})
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	var images []models.PropertyImage
+	tests.ParseResponse(suite.T(), w, &images)
+	assert.Len(suite.T(), images, 1)
+	assert.Equal(suite.T(), 640, images[0].Width)
+	assert.NotEmpty(suite.T(), images[0].ThumbnailURL)
+	assert.NotEmpty(suite.T(), images[0].MediumURL)
+	assert.NotEmpty(suite.T(), images[0].ThumbnailWebPURL)
+	assert.NotEmpty(suite.T(), images[0].MediumWebPURL)
+
+	var stored models.PropertyImage
+	suite.db.First(&stored, images[0].ID)
+	thumb := filepath.Join(suite.dir, stored.StorageKey, "thumb.jpg")
+	_, err := os.Stat(thumb)
+	assert.NoError(suite.T(), err)
+	thumbWebP := filepath.Join(suite.dir, stored.StorageKey, "thumb.webp")
+	_, err = os.Stat(thumbWebP)
+	assert.NoError(suite.T(), err)
+
+	// New photos of a published listing wait for review
+	var property models.Property
+	suite.db.First(&property, suite.property.ID)
+	assert.Equal(suite.T(), models.ListingPendingReview, property.Status)
+
+	w = tests.MakeRequestWithToken(suite.router, "DELETE", fmt.Sprintf("/properties/%d/images/%d", suite.property.ID, stored.ID), nil, tests.GenerateTestToken(suite.T(), &suite.owner))
+	assert.Equal(suite.T(), http.StatusNoContent, w.Code)
+
+	_, err = os.Stat(thumb)
+	assert.True(suite.T(), os.IsNotExist(err))
+	_, err = os.Stat(thumbWebP)
+	assert.True(suite.T(), os.IsNotExist(err))
+}
+
+func (suite *PropertyImageHandlerTestSuite) TestRejectsNonImages() {
+	w := suite.upload(&suite.owner, map[string][]byte{"notes.png": []byte("plain text")})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	var count int64
+	suite.db.Model(&models.PropertyImage{}).Count(&count)
+	assert.Equal(suite.T(), int64(0), count)
+}
+
+func (suite *PropertyImageHandlerTestSuite) TestOnlyOwnerCanUpload() {
+	guest := models.User{Email: "guest@example.com", Name: "Test Guest", Role: "guest"}
+	suite.db.Create(&guest)
+
+	w := suite.upload(&guest, map[string][]byte{"photo.png": samplePNG()})
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+func (suite *PropertyImageHandlerTestSuite) TestCoverAndOrder() {
+	var images []models.PropertyImage
+	for i := 0; i < 3; i++ {
+		img := models.PropertyImage{PropertyID: suite.property.ID, ImageURL: fmt.Sprintf("https://example.com/%d.jpg", i), Position: i, IsCover: i == 0}
+		suite.db.Create(&img)
+		images = append(images, img)
+	}
+	token := tests.GenerateTestToken(suite.T(), &suite.owner)
+
+	// Make the last image the cover and give it a caption
+	body := []byte(`{"is_cover":true,"caption":"Sea view","alt_text":"Balcony facing the sea"}`)
+	w := tests.MakeRequestWithToken(suite.router, "PATCH", fmt.Sprintf("/properties/%d/images/%d", suite.property.ID, images[2].ID), body, token)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var covers []models.PropertyImage
+	suite.db.Where("property_id = ? AND is_cover", suite.property.ID).Find(&covers)
+	assert.Len(suite.T(), covers, 1)
+	assert.Equal(suite.T(), images[2].ID, covers[0].ID)
+	assert.Equal(suite.T(), "Sea view", covers[0].Caption)
+
+	// Neither sends the listing back to review
+	var property models.Property
+	suite.db.First(&property, suite.property.ID)
+	assert.Equal(suite.T(), models.ListingPublished, property.Status)
+
+	// Unsetting the cover directly is refused
+	w = tests.MakeRequestWithToken(suite.router, "PATCH", fmt.Sprintf("/properties/%d/images/%d", suite.property.ID, images[2].ID), []byte(`{"is_cover":false}`), token)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	// Reordering must list every image
+	w = tests.MakeRequestWithToken(suite.router, "PUT", fmt.Sprintf("/properties/%d/images/order", suite.property.ID), []byte(fmt.Sprintf(`{"image_ids":[%d,%d]}`, images[2].ID, images[0].ID)), token)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w = tests.MakeRequestWithToken(suite.router, "PUT", fmt.Sprintf("/properties/%d/images/order", suite.property.ID), []byte(fmt.Sprintf(`{"image_ids":[%d,%d,%d]}`, images[2].ID, images[0].ID, images[1].ID)), token)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var ordered []models.PropertyImage
+	tests.ParseResponse(suite.T(), w, &ordered)
+	assert.Equal(suite.T(), []uint{images[2].ID, images[0].ID, images[1].ID}, []uint{ordered[0].ID, ordered[1].ID, ordered[2].ID})
+
+	// Deleting the cover hands it to the next image in order
+	w = tests.MakeRequestWithToken(suite.router, "DELETE", fmt.Sprintf("/properties/%d/images/%d", suite.property.ID, images[2].ID), nil, token)
+	assert.Equal(suite.T(), http.StatusNoContent, w.Code)
+
+	var cover models.PropertyImage
+	suite.db.Where("property_id = ? AND is_cover", suite.property.ID).First(&cover)
+	assert.Equal(suite.T(), images[0].ID, cover.ID)
+}
+
+func TestPropertyImageHandlerSuite(t *testing.T) {
+	suite.Run(t, new(PropertyImageHandlerTestSuite))
+}
@@ -0,0 +1,219 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/bookaroo/bookaroo-platform-be/geo"
+	"github.com/bookaroo/bookaroo-platform-be/handlers"
+	"github.com/bookaroo/bookaroo-platform-be/middleware"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/search"
+	"github.com/bookaroo/bookaroo-platform-be/tests"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type PropertyModerationTestSuite struct {
+	suite.Suite
+	db         *gorm.DB
+	router     *gin.Engine
+	owner      models.User
+	admin      models.User
+	ownerToken string
+	adminToken string
+}
+
+func (suite *PropertyModerationTestSuite) SetupSuite() {
+	suite.db = tests.SetupTestDB(suite.T())
+	handler := handlers.NewPropertyHandler(suite.db, search.NewPostgres(suite.db), geo.NewGazetteer())
+
+	suite.router = gin.New()
+	suite.router.GET("/properties", handler.ListProperties)
+	suite.router.GET("/properties/:id", handler.GetProperty)
+	suite.router.POST("/properties", handler.CreateProperty)
+	suite.router.POST("/properties/:id/submit", middleware.AuthMiddleware(), handler.SubmitProperty)
+	suite.router.PATCH("/properties/:id", middleware.AuthMiddleware(), handler.UpdateProperty)
+	suite.router.PUT("/properties/:id/translations/:locale", middleware.AuthMiddleware(), handler.PutPropertyTranslation)
+	admin := suite.router.Group("/admin", middleware.AuthMiddleware(), middleware.RoleAuth("admin"))
+	admin.GET("/properties", handler.ListPropertiesForReview)
+	admin.POST("/properties/:id/approve", handler.ApproveProperty)
+	admin.POST("/properties/:id/reject", handler.RejectProperty)
+	admin.POST("/properties/:id/suspend", handler.SuspendProperty)
+}
+
+func (suite *PropertyModerationTestSuite) SetupTest() {
+	suite.db.Exec("DELETE FROM notifications")
+	suite.db.Exec("DELETE FROM bookings")
+	suite.db.Exec("DELETE FROM property_amenities")
+	suite.db.Exec("DELETE FROM property_images")
+	suite.db.Exec("DELETE FROM property_translations")
+	suite.db.Exec("DELETE FROM properties")
+	suite.db.Exec("DELETE FROM users")
+
+	suite.owner = models.User{Email: "owner@example.com", Name: "Owner", Role: "owner"}
+	suite.db.Create(&suite.owner)
+	suite.admin = models.User{Email: "admin@example.com", Name: "Admin", Role: "admin"}
+	suite.db.Create(&suite.admin)
+	suite.ownerToken = tests.GenerateTestToken(suite.T(), &suite.owner)
+	suite.adminToken = tests.GenerateTestToken(suite.T(), &suite.admin)
+}
+
+// createDraft creates a property through the API, which starts it as a draft
+func (suite *PropertyModerationTestSuite) createDraft() models.Property {
+	w := tests.MakeRequest(suite.router, "POST", "/properties", map[string]interface{}{
+		"name":        "Cliffside Villa",
+		"description": "Ocean views from every room",
+		"location":    "Uluwatu",
+		"price":       300.0,
+		"owner_id":    suite.owner.ID,
+	})
+	suite.Require().Equal(http.StatusCreated, w.Code)
+
+	var property models.Property
+	tests.ParseResponse(suite.T(), w, &property)
+	return property
+}
+
+func (suite *PropertyModerationTestSuite) post(path, token, body string) *models.Property {
+	w := tests.MakeRequestWithToken(suite.router, "POST", path, []byte(body), token)
+	if !assert.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String()) {
+		return nil
+	}
+	var property models.Property
+	tests.ParseResponse(suite.T(), w, &property)
+	return &property
+}
+
+func (suite *PropertyModerationTestSuite) listedIDs() []uint {
+	var response handlers.PropertyListResponse
+	tests.ParseResponse(suite.T(), tests.MakeRequest(suite.router, "GET", "/properties", nil), &response)
+	var ids []uint
+	for _, p := range response.Properties {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
+func (suite *PropertyModerationTestSuite) TestDraftIsPublishedOnceApproved() {
+	draft := suite.createDraft()
+	assert.Equal(suite.T(), models.ListingDraft, draft.Status)
+	assert.Empty(suite.T(), suite.listedIDs())
+	w := tests.MakeRequest(suite.router, "GET", fmt.Sprintf("/properties/%d", draft.ID), nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	submitted := suite.post(fmt.Sprintf("/properties/%d/submit", draft.ID), suite.ownerToken, "")
+	suite.Require().NotNil(submitted)
+	assert.Equal(suite.T(), models.ListingPendingReview, submitted.Status)
+	assert.NotNil(suite.T(), submitted.SubmittedAt)
+	assert.Empty(suite.T(), suite.listedIDs())
+
+	// The review queue shows pending listings by default
+	var queue handlers.PropertyListResponse
+	tests.ParseResponse(suite.T(), tests.MakeRequestWithToken(suite.router, "GET", "/admin/properties", nil, suite.adminToken), &queue)
+	if assert.Len(suite.T(), queue.Properties, 1) {
+		assert.Equal(suite.T(), draft.ID, queue.Properties[0].ID)
+	}
+
+	approved := suite.post(fmt.Sprintf("/admin/properties/%d/approve", draft.ID), suite.adminToken, "")
+	suite.Require().NotNil(approved)
+	assert.Equal(suite.T(), models.ListingPublished, approved.Status)
+	assert.Equal(suite.T(), suite.admin.ID, *approved.ReviewedByID)
+	assert.Equal(suite.T(), []uint{draft.ID}, suite.listedIDs())
+
+	var notification models.Notification
+	suite.Require().NoError(suite.db.Where("user_id = ?", suite.owner.ID).First(&notification).Error)
+	assert.Equal(suite.T(), "listing_approved", notification.Type)
+}
+
+func (suite *PropertyModerationTestSuite) TestRejectionNeedsReasonAndReturnsDraft() {
+	draft := suite.createDraft()
+	suite.post(fmt.Sprintf("/properties/%d/submit", draft.ID), suite.ownerToken, "")
+
+	w := tests.MakeRequestWithToken(suite.router, "POST", fmt.Sprintf("/admin/properties/%d/reject", draft.ID), []byte(`{"reason": "  "}`), suite.adminToken)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	rejected := suite.post(fmt.Sprintf("/admin/properties/%d/reject", draft.ID), suite.adminToken, `{"reason": "Photos are missing"}`)
+	suite.Require().NotNil(rejected)
+	assert.Equal(suite.T(), models.ListingDraft, rejected.Status)
+	assert.Equal(suite.T(), "Photos are missing", rejected.ReviewNote)
+
+	// Only listings waiting for review can be approved
+	w = tests.MakeRequestWithToken(suite.router, "POST", fmt.Sprintf("/admin/properties/%d/approve", draft.ID), nil, suite.adminToken)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+
+	var notification models.Notification
+	suite.Require().NoError(suite.db.Where("user_id = ?", suite.owner.ID).First(&notification).Error)
+	assert.Equal(suite.T(), "listing_rejected", notification.Type)
+	assert.Contains(suite.T(), notification.Message, "Photos are missing")
+}
+
+func (suite *PropertyModerationTestSuite) TestSuspendedListingCanBeResubmitted() {
+	property := models.Property{Name: "Old Listing", Description: "Listed before moderation", Location: "Ubud", Price: 80, OwnerID: suite.owner.ID}
+	suite.db.Create(&property)
+	assert.Equal(suite.T(), models.ListingPublished, property.Status)
+	assert.Equal(suite.T(), []uint{property.ID}, suite.listedIDs())
+
+	suspended := suite.post(fmt.Sprintf("/admin/properties/%d/suspend", property.ID), suite.adminToken, `{"reason": "Reported as a scam"}`)
+	suite.Require().NotNil(suspended)
+	assert.Equal(suite.T(), models.ListingSuspended, suspended.Status)
+	assert.Empty(suite.T(), suite.listedIDs())
+
+	resubmitted := suite.post(fmt.Sprintf("/properties/%d/submit", property.ID), suite.ownerToken, "")
+	suite.Require().NotNil(resubmitted)
+	assert.Equal(suite.T(), models.ListingPendingReview, resubmitted.Status)
+}
+
+func (suite *PropertyModerationTestSuite) TestRiskyEditsAreReviewedAgain() {
+	property := models.Property{Name: "Old Listing", Description: "Listed before moderation", Location: "Ubud", Price: 80, OwnerID: suite.owner.ID}
+	suite.db.Create(&property)
+	path := fmt.Sprintf("/properties/%d", property.ID)
+
+	// Price changes go live right away
+	w := tests.MakeRequestWithToken(suite.router, "PATCH", path, []byte(`{"price": 90}`), suite.ownerToken)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(suite.T(), []uint{property.ID}, suite.listedIDs())
+
+	// New text is hidden until approved
+	w = tests.MakeRequestWithToken(suite.router, "PATCH", path, []byte(`{"description": "Now with a rooftop bar"}`), suite.ownerToken)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var edited models.Property
+	tests.ParseResponse(suite.T(), w, &edited)
+	assert.Equal(suite.T(), models.ListingPendingReview, edited.Status)
+	assert.Empty(suite.T(), suite.listedIDs())
+
+	approved := suite.post(fmt.Sprintf("/admin/properties/%d/approve", property.ID), suite.adminToken, "")
+	suite.Require().NotNil(approved)
+	assert.Equal(suite.T(), []uint{property.ID}, suite.listedIDs())
+
+	// Rules, amenities and translations go live right away
+	w = tests.MakeRequestWithToken(suite.router, "PATCH", path, []byte(`{"house_rules": {"other": "No shoes inside"}, "amenities": "Wi-Fi"}`), suite.ownerToken)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	w = tests.MakeRequestWithToken(suite.router, "PUT", path+"/translations/id", []byte(`{"name": "Vila Lama", "description": "Dekat sawah"}`), suite.ownerToken)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(suite.T(), []uint{property.ID}, suite.listedIDs())
+
+	// New photos are reviewed
+	w = tests.MakeRequestWithToken(suite.router, "PATCH", path, []byte(`{"images": [{"image_url": "https://example.com/new.jpg"}]}`), suite.ownerToken)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var stored models.Property
+	suite.db.First(&stored, property.ID)
+	assert.Equal(suite.T(), models.ListingPendingReview, stored.Status)
+}
+
+func (suite *PropertyModerationTestSuite) TestOnlyAdminsReviewAndOwnersSubmit() {
+	draft := suite.createDraft()
+
+	w := tests.MakeRequestWithToken(suite.router, "POST", fmt.Sprintf("/admin/properties/%d/approve", draft.ID), nil, suite.ownerToken)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	w = tests.MakeRequestWithToken(suite.router, "POST", fmt.Sprintf("/properties/%d/submit", draft.ID), nil, suite.adminToken)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+func TestPropertyModerationSuite(t *testing.T) {
+	suite.Run(t, new(PropertyModerationTestSuite))
+}
@@ -0,0 +1,136 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/bookaroo/bookaroo-platform-be/geo"
+	"github.com/bookaroo/bookaroo-platform-be/handlers"
+	"github.com/bookaroo/bookaroo-platform-be/middleware"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/pricing"
+	"github.com/bookaroo/bookaroo-platform-be/search"
+	"github.com/bookaroo/bookaroo-platform-be/tests"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type PriceHistoryTestSuite struct {
+	suite.Suite
+	db         *gorm.DB
+	router     *gin.Engine
+	owner      models.User
+	token      string
+	otherToken string
+}
+
+func (suite *PriceHistoryTestSuite) SetupSuite() {
+	suite.db = tests.SetupTestDB(suite.T())
+	handler := handlers.NewPropertyHandler(suite.db, search.NewPostgres(suite.db), geo.NewGazetteer())
+
+	suite.router = gin.New()
+	properties := suite.router.Group("/properties", middleware.AuthMiddleware())
+	properties.POST("", handler.CreateProperty)
+	properties.PATCH("/:id", handler.UpdateProperty)
+	properties.GET("/:id/price-history", handler.GetPriceHistory)
+}
+
+func (suite *PriceHistoryTestSuite) SetupTest() {
+	suite.db.Exec("DELETE FROM price_changes")
+	suite.db.Exec("DELETE FROM property_amenities")
+	suite.db.Exec("DELETE FROM property_images")
+	suite.db.Exec("DELETE FROM properties")
+	suite.db.Exec("DELETE FROM users")
+
+	suite.owner = models.User{Email: "owner@example.com", Name: "Owner", Role: "owner"}
+	suite.db.Create(&suite.owner)
+	suite.token = tests.GenerateTestToken(suite.T(), &suite.owner)
+	other := models.User{Email: "other@example.com", Name: "Other", Role: "owner"}
+	suite.db.Create(&other)
+	suite.otherToken = tests.GenerateTestToken(suite.T(), &other)
+}
+
+func (suite *PriceHistoryTestSuite) history(id uint, query string) handlers.PriceHistoryResponse {
+	w := tests.MakeRequestWithToken(suite.router, "GET", fmt.Sprintf("/properties/%d/price-history%s", id, query), nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var response handlers.PriceHistoryResponse
+	tests.ParseResponse(suite.T(), w, &response)
+	return response
+}
+
+func (suite *PriceHistoryTestSuite) TestRecordsPriceChanges() {
+	w := tests.MakeRequestWithToken(suite.router, "POST", "/properties",
+		[]byte(fmt.Sprintf(`{"name": "Rice Field Villa", "description": "Green terraces", "location": "Ubud", "price": 120, "owner_id": %d}`, suite.owner.ID)), suite.token)
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	var property models.Property
+	tests.ParseResponse(suite.T(), w, &property)
+
+	path := fmt.Sprintf("/properties/%d", property.ID)
+	w = tests.MakeRequestWithToken(suite.router, "PATCH", path, []byte(`{"price": 100, "monthly_price": 2500}`), suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	// Changes to anything else aren't price changes
+	w = tests.MakeRequestWithToken(suite.router, "PATCH", path, []byte(`{"name": "Terrace Villa", "price": 100}`), suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	history := suite.history(property.ID, "")
+	suite.Require().Len(history.Changes, 3)
+	assert.Equal(suite.T(), int64(3), history.Meta.TotalEstimate)
+
+	monthly, drop, initial := history.Changes[0], history.Changes[1], history.Changes[2]
+	assert.Equal(suite.T(), pricing.FieldMonthlyPrice, monthly.Field)
+	assert.Equal(suite.T(), 2500.0, monthly.NewValue)
+	assert.Equal(suite.T(), pricing.FieldPrice, drop.Field)
+	suite.Require().NotNil(drop.OldValue)
+	assert.Equal(suite.T(), 120.0, *drop.OldValue)
+	assert.Equal(suite.T(), 100.0, drop.NewValue)
+	assert.Nil(suite.T(), initial.OldValue)
+	assert.Equal(suite.T(), 120.0, initial.NewValue)
+	for _, change := range history.Changes {
+		suite.Require().NotNil(change.ChangedByID)
+		assert.Equal(suite.T(), suite.owner.ID, *change.ChangedByID)
+		assert.Equal(suite.T(), pricing.SourceOwner, change.Source)
+	}
+
+	assert.Len(suite.T(), suite.history(property.ID, "?field=price").Changes, 2)
+	page := suite.history(property.ID, "?limit=2")
+	assert.Len(suite.T(), page.Changes, 2)
+	assert.True(suite.T(), page.Meta.HasMore)
+	rest := suite.history(property.ID, "?limit=2&cursor="+page.Meta.NextCursor)
+	suite.Require().Len(rest.Changes, 1)
+	assert.Equal(suite.T(), initial.ID, rest.Changes[0].ID)
+}
+
+func (suite *PriceHistoryTestSuite) TestOwnerOnly() {
+	property := models.Property{Name: "Beach Hut", Location: "Canggu", Price: 80, OwnerID: suite.owner.ID}
+	suite.db.Create(&property)
+
+	path := fmt.Sprintf("/properties/%d/price-history", property.ID)
+	w := tests.MakeRequestWithToken(suite.router, "GET", path, nil, suite.otherToken)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	w = tests.MakeRequestWithToken(suite.router, "GET", path+"?field=deposit", nil, suite.token)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *PriceHistoryTestSuite) TestBackfillStartsHistoryFromCurrentPrices() {
+	property := models.Property{Name: "Beach Hut", Location: "Canggu", Price: 80, MonthlyPrice: 1800, OwnerID: suite.owner.ID}
+	suite.db.Create(&property)
+
+	suite.Require().NoError(pricing.Backfill(suite.db))
+	suite.Require().NoError(pricing.Backfill(suite.db))
+
+	history := suite.history(property.ID, "")
+	suite.Require().Len(history.Changes, 2)
+	for _, change := range history.Changes {
+		assert.Equal(suite.T(), pricing.SourceBackfill, change.Source)
+		assert.Nil(suite.T(), change.ChangedByID)
+		assert.Nil(suite.T(), change.OldValue)
+	}
+}
+
+func TestPriceHistoryTestSuite(t *testing.T) {
+	suite.Run(t, new(PriceHistoryTestSuite))
+}
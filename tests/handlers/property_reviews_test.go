@@ -0,0 +1,145 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bookaroo/bookaroo-platform-be/geo"
+	"github.com/bookaroo/bookaroo-platform-be/handlers"
+	"github.com/bookaroo/bookaroo-platform-be/middleware"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/search"
+	"github.com/bookaroo/bookaroo-platform-be/tests"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type PropertyReviewsTestSuite struct {
+	suite.Suite
+	db       *gorm.DB
+	router   *gin.Engine
+	owner    models.User
+	guest    models.User
+	property models.Property
+}
+
+func (suite *PropertyReviewsTestSuite) SetupSuite() {
+	suite.db = tests.SetupTestDB(suite.T())
+	bookingHandler := handlers.NewBookingHandler(suite.db)
+	propertyHandler := handlers.NewPropertyHandler(suite.db, search.NewPostgres(suite.db), geo.NewGazetteer())
+
+	suite.router = gin.New()
+	suite.router.POST("/bookings/:id/review", middleware.AuthMiddleware(), bookingHandler.CreateReview)
+	suite.router.GET("/properties", propertyHandler.ListProperties)
+	suite.router.GET("/properties/search", propertyHandler.SearchProperties)
+	suite.router.GET("/properties/:id/reviews", propertyHandler.ListReviews)
+}
+
+func (suite *PropertyReviewsTestSuite) SetupTest() {
+	suite.db.Exec("DELETE FROM reviews")
+	suite.db.Exec("DELETE FROM bookings")
+	suite.db.Exec("DELETE FROM properties")
+	suite.db.Exec("DELETE FROM users")
+
+	suite.owner = models.User{Email: "owner@example.com", Name: "Test Owner", Role: "owner"}
+	suite.db.Create(&suite.owner)
+	suite.guest = models.User{Email: "guest@example.com", Name: "Test Guest", Role: "guest"}
+	suite.db.Create(&suite.guest)
+
+	suite.property = models.Property{Name: "Beach House", Location: "Bali", Price: 100.0, OwnerID: suite.owner.ID}
+	suite.db.Create(&suite.property)
+}
+
+// stay creates a booking of the guest that ended daysAgo days ago
+func (suite *PropertyReviewsTestSuite) stay(propertyID uint, status string, daysAgo int) models.Booking {
+	end := time.Now().AddDate(0, 0, -daysAgo)
+	booking := models.Booking{PropertyID: propertyID, UserID: &suite.guest.ID, StartDate: end.AddDate(0, 0, -3), EndDate: end, Status: status}
+	suite.db.Create(&booking)
+	return booking
+}
+
+func (suite *PropertyReviewsTestSuite) review(booking models.Booking, user models.User, rating int) int {
+	body := []byte(fmt.Sprintf(`{"rating":%d,"comment":"Lovely stay"}`, rating))
+	w := tests.MakeRequestWithToken(suite.router, "POST", fmt.Sprintf("/bookings/%d/review", booking.ID), body, tests.GenerateTestToken(suite.T(), &user))
+	return w.Code
+}
+
+func (suite *PropertyReviewsTestSuite) TestReviewUpdatesRating() {
+	first := suite.stay(suite.property.ID, "confirmed", 10)
+	second := suite.stay(suite.property.ID, "completed", 2)
+
+	assert.Equal(suite.T(), http.StatusCreated, suite.review(first, suite.guest, 5))
+	assert.Equal(suite.T(), http.StatusCreated, suite.review(second, suite.guest, 4))
+
+	var property models.Property
+	suite.db.First(&property, suite.property.ID)
+	assert.InDelta(suite.T(), 4.5, property.RatingAverage, 0.001)
+	assert.Equal(suite.T(), 2, property.RatingCount)
+
+	w := tests.MakeRequest(suite.router, "GET", fmt.Sprintf("/properties/%d/reviews", suite.property.ID), nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	var response handlers.ReviewListResponse
+	tests.ParseResponse(suite.T(), w, &response)
+	assert.Len(suite.T(), response.Reviews, 2)
+	assert.Equal(suite.T(), 4, response.Reviews[0].Rating)
+}
+
+func (suite *PropertyReviewsTestSuite) TestReviewRules() {
+	ended := suite.stay(suite.property.ID, "confirmed", 1)
+
+	// Only the guest can review, with a rating from 1 to 5
+	assert.Equal(suite.T(), http.StatusForbidden, suite.review(ended, suite.owner, 5))
+	assert.Equal(suite.T(), http.StatusBadRequest, suite.review(ended, suite.guest, 6))
+
+	// Each stay is reviewed once
+	assert.Equal(suite.T(), http.StatusCreated, suite.review(ended, suite.guest, 3))
+	assert.Equal(suite.T(), http.StatusConflict, suite.review(ended, suite.guest, 5))
+
+	// Stays still going on and cancelled stays can't be reviewed
+	upcoming := suite.stay(suite.property.ID, "confirmed", -5)
+	assert.Equal(suite.T(), http.StatusConflict, suite.review(upcoming, suite.guest, 5))
+	cancelled := suite.stay(suite.property.ID, "cancelled", 1)
+	assert.Equal(suite.T(), http.StatusConflict, suite.review(cancelled, suite.guest, 5))
+}
+
+func (suite *PropertyReviewsTestSuite) TestSortByRating() {
+	unrated := models.Property{Name: "Unrated", Location: "Bali", Price: 80.0, OwnerID: suite.owner.ID}
+	suite.db.Create(&unrated)
+	better := models.Property{Name: "Better", Location: "Bali", Price: 120.0, OwnerID: suite.owner.ID}
+	suite.db.Create(&better)
+
+	assert.Equal(suite.T(), http.StatusCreated, suite.review(suite.stay(suite.property.ID, "confirmed", 3), suite.guest, 3))
+	assert.Equal(suite.T(), http.StatusCreated, suite.review(suite.stay(better.ID, "confirmed", 3), suite.guest, 5))
+
+	w := tests.MakeRequest(suite.router, "GET", "/properties?sort=rating&limit=2", nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	var response handlers.PropertyListResponse
+	tests.ParseResponse(suite.T(), w, &response)
+	assert.Len(suite.T(), response.Properties, 2)
+	assert.Equal(suite.T(), better.ID, response.Properties[0].ID)
+	assert.Equal(suite.T(), suite.property.ID, response.Properties[1].ID)
+
+	// Unrated properties come last
+	w = tests.MakeRequest(suite.router, "GET", "/properties?sort=rating&limit=2&cursor="+response.Meta.NextCursor, nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	tests.ParseResponse(suite.T(), w, &response)
+	assert.Len(suite.T(), response.Properties, 1)
+	assert.Equal(suite.T(), unrated.ID, response.Properties[0].ID)
+
+	// Search sorts the same way
+	w = tests.MakeRequest(suite.router, "GET", "/properties/search?sort=rating", nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var found handlers.SearchResponse
+	tests.ParseResponse(suite.T(), w, &found)
+	suite.Require().Len(found.Properties, 3)
+	assert.Equal(suite.T(), []uint{better.ID, suite.property.ID, unrated.ID},
+		[]uint{found.Properties[0].ID, found.Properties[1].ID, found.Properties[2].ID})
+}
+
+func TestPropertyReviewsSuite(t *testing.T) {
+	suite.Run(t, new(PropertyReviewsTestSuite))
+}
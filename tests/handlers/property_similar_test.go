@@ -0,0 +1,122 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/bookaroo/bookaroo-platform-be/geo"
+	"github.com/bookaroo/bookaroo-platform-be/handlers"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/recommend"
+	"github.com/bookaroo/bookaroo-platform-be/search"
+	"github.com/bookaroo/bookaroo-platform-be/tests"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type SimilarPropertiesTestSuite struct {
+	suite.Suite
+	db      *gorm.DB
+	router  *gin.Engine
+	handler *handlers.PropertyHandler
+	owner   models.User
+}
+
+func (suite *SimilarPropertiesTestSuite) SetupSuite() {
+	suite.db = tests.SetupTestDB(suite.T())
+	suite.handler = handlers.NewPropertyHandler(suite.db, search.NewPostgres(suite.db), geo.NewGazetteer())
+
+	suite.router = gin.New()
+	suite.router.GET("/properties/:id/similar", suite.handler.SimilarProperties)
+}
+
+func (suite *SimilarPropertiesTestSuite) SetupTest() {
+	suite.handler.Recommender = recommend.Default()
+	suite.db.Exec("DELETE FROM wishlist_items")
+	suite.db.Exec("DELETE FROM property_translations")
+	suite.db.Exec("DELETE FROM property_amenities")
+	suite.db.Exec("DELETE FROM property_images")
+	suite.db.Exec("DELETE FROM properties")
+	suite.db.Exec("DELETE FROM users")
+
+	suite.owner = models.User{Email: "owner@example.com", Name: "Owner", Role: "owner"}
+	suite.db.Create(&suite.owner)
+}
+
+func (suite *SimilarPropertiesTestSuite) property(name string, price float64, lat, lng float64) models.Property {
+	p := models.Property{Name: name, Description: "Nice", Location: "Bali", Price: price, MaxGuests: 4, Latitude: &lat, Longitude: &lng, OwnerID: suite.owner.ID}
+	suite.Require().NoError(suite.db.Create(&p).Error)
+	return p
+}
+
+func (suite *SimilarPropertiesTestSuite) similar(id uint, query string) []handlers.SimilarProperty {
+	w := tests.MakeRequest(suite.router, "GET", fmt.Sprintf("/properties/%d/similar%s", id, query), nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var similar []handlers.SimilarProperty
+	tests.ParseResponse(suite.T(), w, &similar)
+	return similar
+}
+
+func (suite *SimilarPropertiesTestSuite) TestRanksPublishedPropertiesBySimilarity() {
+	viewed := suite.property("Rice Field Villa", 120, -8.5, 115.26)
+	twin := suite.property("Twin Villa", 120, -8.5, 115.26)
+	pricier := suite.property("Pricier Villa", 240, -8.5, 115.26)
+	far := suite.property("Far Villa", 120, -8.8, 115.16)
+	draft := suite.property("Draft Villa", 120, -8.5, 115.26)
+	suite.db.Model(&draft).Update("status", models.ListingDraft)
+
+	similar := suite.similar(viewed.ID, "")
+	suite.Require().Len(similar, 3)
+	assert.Equal(suite.T(), twin.ID, similar[0].ID)
+	assert.Equal(suite.T(), pricier.ID, similar[1].ID)
+	assert.Equal(suite.T(), far.ID, similar[2].ID)
+	assert.Greater(suite.T(), similar[0].Score, similar[1].Score)
+
+	assert.Len(suite.T(), suite.similar(viewed.ID, "?limit=1"), 1)
+}
+
+func (suite *SimilarPropertiesTestSuite) TestScorerIsPluggable() {
+	viewed := suite.property("Rice Field Villa", 120, -8.5, 115.26)
+	suite.property("Twin Villa", 120, -8.5, 115.26)
+	cheap := suite.property("Cheap Hut", 20, -8.5, 115.26)
+
+	// Favour the cheapest properties instead
+	suite.handler.Recommender = recommend.ScorerFunc(func(_, candidate models.Property) float64 {
+		return 1 / candidate.Price
+	})
+
+	similar := suite.similar(viewed.ID, "")
+	suite.Require().Len(similar, 2)
+	assert.Equal(suite.T(), cheap.ID, similar[0].ID)
+}
+
+func (suite *SimilarPropertiesTestSuite) TestOwnerContactDetailsAreHidden() {
+	suite.db.Model(&suite.owner).Updates(models.User{Password: "$2a$10$secrethash", Phone: "+62 812 555", Address: "Jalan Raya 1"})
+	viewed := suite.property("Rice Field Villa", 120, -8.5, 115.26)
+	suite.property("Twin Villa", 120, -8.5, 115.26)
+
+	w := tests.MakeRequest(suite.router, "GET", fmt.Sprintf("/properties/%d/similar", viewed.ID), nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(suite.T(), w.Body.String(), `"name":"Owner"`)
+	for _, private := range []string{"secrethash", "owner@example.com", "+62 812 555", "Jalan Raya 1"} {
+		assert.NotContains(suite.T(), w.Body.String(), private)
+	}
+}
+
+func (suite *SimilarPropertiesTestSuite) TestUnlistedPropertyIsNotFound() {
+	viewed := suite.property("Rice Field Villa", 120, -8.5, 115.26)
+	suite.db.Model(&viewed).Update("status", models.ListingSuspended)
+
+	w := tests.MakeRequest(suite.router, "GET", fmt.Sprintf("/properties/%d/similar", viewed.ID), nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	w = tests.MakeRequest(suite.router, "GET", fmt.Sprintf("/properties/%d/similar?limit=0", viewed.ID), nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func TestSimilarPropertiesSuite(t *testing.T) {
+	suite.Run(t, new(SimilarPropertiesTestSuite))
+}
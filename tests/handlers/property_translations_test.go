@@ -0,0 +1,145 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bookaroo/bookaroo-platform-be/geo"
+	"github.com/bookaroo/bookaroo-platform-be/handlers"
+	"github.com/bookaroo/bookaroo-platform-be/middleware"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/search"
+	"github.com/bookaroo/bookaroo-platform-be/tests"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type PropertyTranslationsTestSuite struct {
+	suite.Suite
+	db       *gorm.DB
+	router   *gin.Engine
+	owner    models.User
+	token    string
+	property models.Property
+}
+
+func (suite *PropertyTranslationsTestSuite) SetupSuite() {
+	suite.db = tests.SetupTestDB(suite.T())
+	handler := handlers.NewPropertyHandler(suite.db, search.NewPostgres(suite.db), geo.NewGazetteer())
+
+	suite.router = gin.New()
+	suite.router.GET("/properties", handler.ListProperties)
+	suite.router.GET("/properties/:id", handler.GetProperty)
+	suite.router.GET("/properties/:id/translations", middleware.AuthMiddleware(), handler.ListPropertyTranslations)
+	suite.router.PUT("/properties/:id/translations/:locale", middleware.AuthMiddleware(), handler.PutPropertyTranslation)
+	suite.router.DELETE("/properties/:id/translations/:locale", middleware.AuthMiddleware(), handler.DeletePropertyTranslation)
+}
+
+func (suite *PropertyTranslationsTestSuite) SetupTest() {
+	suite.db.Exec("DELETE FROM property_translations")
+	suite.db.Exec("DELETE FROM bookings")
+	suite.db.Exec("DELETE FROM property_amenities")
+	suite.db.Exec("DELETE FROM property_images")
+	suite.db.Exec("DELETE FROM properties")
+	suite.db.Exec("DELETE FROM users")
+
+	suite.owner = models.User{Email: "owner@example.com", Name: "Owner", Role: "owner"}
+	suite.db.Create(&suite.owner)
+	suite.token = tests.GenerateTestToken(suite.T(), &suite.owner)
+
+	suite.property = models.Property{
+		Name:        "Rice Field Villa",
+		Description: "Wake up to green terraces",
+		Location:    "Ubud",
+		Price:       120,
+		OwnerID:     suite.owner.ID,
+		HouseRules:  models.HouseRules{Other: "Shoes off indoors"},
+	}
+	suite.db.Create(&suite.property)
+}
+
+func (suite *PropertyTranslationsTestSuite) translate(locale, body string) *httptest.ResponseRecorder {
+	return tests.MakeRequestWithToken(suite.router, "PUT", fmt.Sprintf("/properties/%d/translations/%s", suite.property.ID, locale), []byte(body), suite.token)
+}
+
+func (suite *PropertyTranslationsTestSuite) get(path, acceptLanguage string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", path, nil)
+	if acceptLanguage != "" {
+		req.Header.Set("Accept-Language", acceptLanguage)
+	}
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *PropertyTranslationsTestSuite) TestPropertyIsShownInBestLocale() {
+	w := suite.translate("id", `{"name": "Vila Sawah", "description": "Bangun dengan pemandangan sawah", "house_rules": "Lepas sepatu di dalam"}`)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	w = suite.translate("TH", `{"name": "วิลล่านาข้าว", "description": "ตื่นมาพบนาขั้นบันได"}`)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	path := fmt.Sprintf("/properties/%d", suite.property.ID)
+	var property models.Property
+
+	w = suite.get(path, "id-ID,id;q=0.9,en;q=0.8")
+	tests.ParseResponse(suite.T(), w, &property)
+	assert.Equal(suite.T(), "Vila Sawah", property.Name)
+	assert.Equal(suite.T(), "Lepas sepatu di dalam", property.HouseRules.Other)
+	assert.Equal(suite.T(), "id", property.Locale)
+	assert.Equal(suite.T(), "id", w.Header().Get("Content-Language"))
+
+	// lang overrides the header
+	tests.ParseResponse(suite.T(), suite.get(path+"?lang=th", "id"), &property)
+	assert.Equal(suite.T(), "วิลล่านาข้าว", property.Name)
+
+	// Unavailable locales fall back to the default
+	tests.ParseResponse(suite.T(), suite.get(path, "ja"), &property)
+	assert.Equal(suite.T(), "Rice Field Villa", property.Name)
+	assert.Equal(suite.T(), "en", property.Locale)
+
+	var list handlers.PropertyListResponse
+	tests.ParseResponse(suite.T(), suite.get("/properties", "th"), &list)
+	if assert.Len(suite.T(), list.Properties, 1) {
+		assert.Equal(suite.T(), "วิลล่านาข้าว", list.Properties[0].Name)
+		// Untranslated house rules are shown as written
+		assert.Equal(suite.T(), "Shoes off indoors", list.Properties[0].HouseRules.Other)
+	}
+}
+
+func (suite *PropertyTranslationsTestSuite) TestManageTranslations() {
+	suite.translate("id", `{"name": "Vila Sawah", "description": "Pemandangan sawah"}`)
+	w := suite.translate("id", `{"name": "Vila Sawah Ubud", "description": "Pemandangan sawah"}`)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var translations []models.PropertyTranslation
+	w = tests.MakeRequestWithToken(suite.router, "GET", fmt.Sprintf("/properties/%d/translations", suite.property.ID), nil, suite.token)
+	tests.ParseResponse(suite.T(), w, &translations)
+	if assert.Len(suite.T(), translations, 1) {
+		assert.Equal(suite.T(), "Vila Sawah Ubud", translations[0].Name)
+	}
+
+	// The property's own language is edited directly
+	w = suite.translate("en", `{"name": "Villa", "description": "Villa"}`)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	w = suite.translate("1", `{"name": "Villa", "description": "Villa"}`)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	path := fmt.Sprintf("/properties/%d/translations/id", suite.property.ID)
+	w = tests.MakeRequestWithToken(suite.router, "DELETE", path, nil, suite.token)
+	assert.Equal(suite.T(), http.StatusNoContent, w.Code)
+	w = tests.MakeRequestWithToken(suite.router, "DELETE", path, nil, suite.token)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	other := models.User{Email: "other@example.com", Name: "Other", Role: "owner"}
+	suite.db.Create(&other)
+	w = tests.MakeRequestWithToken(suite.router, "PUT", path, []byte(`{"name": "x", "description": "x"}`), tests.GenerateTestToken(suite.T(), &other))
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+func TestPropertyTranslationsSuite(t *testing.T) {
+	suite.Run(t, new(PropertyTranslationsTestSuite))
+}
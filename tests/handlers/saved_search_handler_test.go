@@ -0,0 +1,140 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/bookaroo/bookaroo-platform-be/geo"
+	"github.com/bookaroo/bookaroo-platform-be/handlers"
+	"github.com/bookaroo/bookaroo-platform-be/middleware"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/search"
+	"github.com/bookaroo/bookaroo-platform-be/tests"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type SavedSearchHandlerTestSuite struct {
+	suite.Suite
+	db         *gorm.DB
+	router     *gin.Engine
+	guest      models.User
+	token      string
+	otherToken string
+}
+
+func (suite *SavedSearchHandlerTestSuite) SetupSuite() {
+	suite.db = tests.SetupTestDB(suite.T())
+	handler := handlers.NewSavedSearchHandler(suite.db, search.NewPostgres(suite.db), geo.NewGazetteer())
+
+	suite.router = gin.New()
+	savedSearches := suite.router.Group("/saved-searches", middleware.AuthMiddleware())
+	savedSearches.GET("", handler.ListSavedSearches)
+	savedSearches.POST("", handler.CreateSavedSearch)
+	savedSearches.GET("/:id", handler.GetSavedSearch)
+	savedSearches.PATCH("/:id", handler.UpdateSavedSearch)
+	savedSearches.DELETE("/:id", handler.DeleteSavedSearch)
+}
+
+func (suite *SavedSearchHandlerTestSuite) SetupTest() {
+	suite.db.Exec("DELETE FROM saved_search_matches")
+	suite.db.Exec("DELETE FROM saved_searches")
+	suite.db.Exec("DELETE FROM property_amenities")
+	suite.db.Exec("DELETE FROM property_images")
+	suite.db.Exec("DELETE FROM properties")
+	suite.db.Exec("DELETE FROM users")
+
+	owner := models.User{Email: "owner@example.com", Name: "Owner", Role: "owner"}
+	suite.db.Create(&owner)
+	suite.guest = models.User{Email: "guest@example.com", Name: "Guest", Role: "guest"}
+	suite.db.Create(&suite.guest)
+	suite.token = tests.GenerateTestToken(suite.T(), &suite.guest)
+	other := models.User{Email: "other@example.com", Name: "Other", Role: "guest"}
+	suite.db.Create(&other)
+	suite.otherToken = tests.GenerateTestToken(suite.T(), &other)
+
+	suite.db.Create(&models.Property{Name: "Rice Field Villa", Description: "Green terraces", Location: "Ubud, Bali", Price: 120, MaxGuests: 4, OwnerID: owner.ID})
+}
+
+func (suite *SavedSearchHandlerTestSuite) create(body string) models.SavedSearch {
+	w := tests.MakeRequestWithToken(suite.router, "POST", "/saved-searches", []byte(body), suite.token)
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	var saved models.SavedSearch
+	tests.ParseResponse(suite.T(), w, &saved)
+	return saved
+}
+
+func (suite *SavedSearchHandlerTestSuite) TestCreateSavedSearch() {
+	saved := suite.create(`{"name": "Bali for four", "query": "?location=Bali&max_price=150&guests=4&sort=price_asc&limit=5"}`)
+	assert.Equal(suite.T(), "guests=4&location=Bali&max_price=150", saved.Query, "sorting and paging are dropped")
+	assert.True(suite.T(), saved.AlertsEnabled)
+	assert.NotNil(suite.T(), saved.LastCheckedAt, "current matches are recorded right away")
+
+	var matches int64
+	suite.db.Model(&models.SavedSearchMatch{}).Where("saved_search_id = ?", saved.ID).Count(&matches)
+	assert.Equal(suite.T(), int64(1), matches)
+
+	w := tests.MakeRequestWithToken(suite.router, "GET", "/saved-searches", nil, suite.token)
+	var searches []models.SavedSearch
+	tests.ParseResponse(suite.T(), w, &searches)
+	suite.Require().Len(searches, 1)
+	assert.Equal(suite.T(), "Bali for four", searches[0].Name)
+}
+
+func (suite *SavedSearchHandlerTestSuite) TestCreateRejectsUnusableSearches() {
+	for _, body := range []string{
+		`{"name": "Everything", "query": "sort=newest"}`,
+		`{"name": "Crowd", "query": "guests=0"}`,
+		`{"name": "Nowhere", "query": "near=Atlantis"}`,
+		`{"name": "No query"}`,
+	} {
+		w := tests.MakeRequestWithToken(suite.router, "POST", "/saved-searches", []byte(body), suite.token)
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code, body)
+	}
+}
+
+func (suite *SavedSearchHandlerTestSuite) TestUpdateSavedSearch() {
+	saved := suite.create(`{"name": "Bali", "query": "location=Bali"}`)
+	path := fmt.Sprintf("/saved-searches/%d", saved.ID)
+
+	w := tests.MakeRequestWithToken(suite.router, "PATCH", path, []byte(`{"alerts_enabled": false}`), suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	tests.ParseResponse(suite.T(), w, &saved)
+	assert.False(suite.T(), saved.AlertsEnabled)
+	assert.Equal(suite.T(), "Bali", saved.Name)
+
+	w = tests.MakeRequestWithToken(suite.router, "PATCH", path, []byte(`{"name": "Canggu", "query": "location=Canggu"}`), suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	tests.ParseResponse(suite.T(), w, &saved)
+	assert.Equal(suite.T(), "location=Canggu", saved.Query)
+
+	var matches int64
+	suite.db.Model(&models.SavedSearchMatch{}).Where("saved_search_id = ?", saved.ID).Count(&matches)
+	assert.Equal(suite.T(), int64(0), matches, "matches of the old query are forgotten")
+
+	w = tests.MakeRequestWithToken(suite.router, "PATCH", path, []byte(`{"query": "guests=0"}`), suite.token)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *SavedSearchHandlerTestSuite) TestOtherUsersSavedSearchesAreHidden() {
+	saved := suite.create(`{"name": "Bali", "query": "location=Bali"}`)
+	path := fmt.Sprintf("/saved-searches/%d", saved.ID)
+
+	w := tests.MakeRequestWithToken(suite.router, "GET", path, nil, suite.otherToken)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+	w = tests.MakeRequestWithToken(suite.router, "DELETE", path, nil, suite.otherToken)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	w = tests.MakeRequestWithToken(suite.router, "DELETE", path, nil, suite.token)
+	assert.Equal(suite.T(), http.StatusNoContent, w.Code)
+	var count int64
+	suite.db.Model(&models.SavedSearch{}).Count(&count)
+	assert.Equal(suite.T(), int64(0), count)
+}
+
+func TestSavedSearchHandlerSuite(t *testing.T) {
+	suite.Run(t, new(SavedSearchHandlerTestSuite))
+}
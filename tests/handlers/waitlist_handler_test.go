@@ -0,0 +1,154 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bookaroo/bookaroo-platform-be/handlers"
+	"github.com/bookaroo/bookaroo-platform-be/middleware"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/tests"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type WaitlistHandlerTestSuite struct {
+	suite.Suite
+	db             *gorm.DB
+	handler        *handlers.WaitlistHandler
+	bookingHandler *handlers.BookingHandler
+	router         *gin.Engine
+	property       models.Property
+	booking        models.Booking
+	guests         []models.User
+}
+
+func (suite *WaitlistHandlerTestSuite) SetupSuite() {
+	suite.db = tests.SetupTestDB(suite.T())
+	suite.handler = handlers.NewWaitlistHandler(suite.db)
+	suite.bookingHandler = handlers.NewBookingHandler(suite.db)
+
+	// Setup router
+	suite.router = gin.New()
+	suite.router.Use(middleware.AuthMiddleware())
+	suite.router.POST("/waitlist", suite.handler.JoinWaitlist)
+	suite.router.GET("/waitlist", suite.handler.ListWaitlist)
+	suite.router.DELETE("/waitlist/:id", suite.handler.LeaveWaitlist)
+	suite.router.POST("/bookings", suite.bookingHandler.CreateBooking)
+	suite.router.POST("/bookings/:id/cancel", suite.bookingHandler.CancelBooking)
+}
+
+func (suite *WaitlistHandlerTestSuite) SetupTest() {
+	// Clear the database before each test
+	suite.db.Exec("DELETE FROM notifications")
+	suite.db.Exec("DELETE FROM waitlist_entries")
+	suite.db.Exec("DELETE FROM bookings")
+	suite.db.Exec("DELETE FROM properties")
+	suite.db.Exec("DELETE FROM users")
+
+	owner := models.User{Email: "owner@example.com", Name: "Test Owner", Role: "owner"}
+	suite.db.Create(&owner)
+
+	suite.property = models.Property{Name: "Beach House", Location: "Bali", Price: 100.0, OwnerID: owner.ID}
+	suite.db.Create(&suite.property)
+
+	suite.guests = nil
+	for i := 0; i < 3; i++ {
+		guest := models.User{Email: fmt.Sprintf("guest%d@example.com", i), Name: fmt.Sprintf("Guest %d", i), Role: "guest"}
+		suite.db.Create(&guest)
+		suite.guests = append(suite.guests, guest)
+	}
+
+	start := time.Date(2030, 6, 10, 0, 0, 0, 0, time.UTC)
+	suite.booking = models.Booking{PropertyID: suite.property.ID, UserID: &suite.guests[0].ID, StartDate: start, EndDate: start.AddDate(0, 0, 5), Status: "confirmed"}
+	suite.db.Create(&suite.booking)
+}
+
+func (suite *WaitlistHandlerTestSuite) join(guest models.User, start, end string) int {
+	body, _ := json.Marshal(map[string]interface{}{
+		"property_id": suite.property.ID,
+		"start_date":  start,
+		"end_date":    end,
+	})
+	w := tests.MakeRequestWithToken(suite.router, "POST", "/waitlist", body, tests.GenerateTestToken(suite.T(), &guest))
+	return w.Code
+}
+
+func (suite *WaitlistHandlerTestSuite) TestJoinRequiresBookedDates() {
+	code := suite.join(suite.guests[1], "2030-07-01T00:00:00Z", "2030-07-03T00:00:00Z")
+	assert.Equal(suite.T(), http.StatusBadRequest, code)
+
+	code = suite.join(suite.guests[1], "2030-06-11T00:00:00Z", "2030-06-13T00:00:00Z")
+	assert.Equal(suite.T(), http.StatusCreated, code)
+}
+
+func (suite *WaitlistHandlerTestSuite) TestCancellationOffersDatesInOrder() {
+	assert.Equal(suite.T(), http.StatusCreated, suite.join(suite.guests[1], "2030-06-11T00:00:00Z", "2030-06-13T00:00:00Z"))
+	assert.Equal(suite.T(), http.StatusCreated, suite.join(suite.guests[2], "2030-06-11T00:00:00Z", "2030-06-14T00:00:00Z"))
+
+	w := tests.MakeRequestWithToken(suite.router, "POST", fmt.Sprintf("/bookings/%d/cancel", suite.booking.ID), nil, tests.GenerateTestToken(suite.T(), &suite.guests[0]))
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	// Only the first guest in line gets the overlapping dates
+	var entries []models.WaitlistEntry
+	suite.db.Order("created_at, id").Find(&entries)
+	assert.Equal(suite.T(), "offered", entries[0].Status)
+	assert.NotNil(suite.T(), entries[0].OfferExpiresAt)
+	assert.Equal(suite.T(), "waiting", entries[1].Status)
+
+	var notifications []models.Notification
+	suite.db.Where("user_id = ?", suite.guests[1].ID).Find(&notifications)
+	assert.Len(suite.T(), notifications, 1)
+	assert.Equal(suite.T(), "waitlist_offer", notifications[0].Type)
+
+	// The dates are held from other guests during the priority window
+	body := []byte(`{"property_id":` + fmt.Sprint(suite.property.ID) + `,"start_date":"2030-06-12T00:00:00Z","end_date":"2030-06-13T00:00:00Z"}`)
+	w = tests.MakeRequestWithToken(suite.router, "POST", "/bookings", body, tests.GenerateTestToken(suite.T(), &suite.guests[2]))
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+
+	// When the window runs out, the next guest in line is offered the dates
+	suite.db.Model(&entries[0]).Update("offer_expires_at", time.Now().Add(-time.Minute))
+	assert.NoError(suite.T(), suite.bookingHandler.Waitlist.ExpireOffers(context.Background()))
+
+	suite.db.Order("created_at, id").Find(&entries)
+	assert.Equal(suite.T(), "expired", entries[0].Status)
+	assert.Equal(suite.T(), "offered", entries[1].Status)
+
+	// The offered guest can book and the offer is closed
+	body = []byte(`{"property_id":` + fmt.Sprint(suite.property.ID) + `,"start_date":"2030-06-11T00:00:00Z","end_date":"2030-06-14T00:00:00Z"}`)
+	w = tests.MakeRequestWithToken(suite.router, "POST", "/bookings", body, tests.GenerateTestToken(suite.T(), &suite.guests[2]))
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	suite.db.First(&entries[1], entries[1].ID)
+	assert.Equal(suite.T(), "booked", entries[1].Status)
+}
+
+func (suite *WaitlistHandlerTestSuite) TestLeavingPassesOfferOn() {
+	assert.Equal(suite.T(), http.StatusCreated, suite.join(suite.guests[1], "2030-06-11T00:00:00Z", "2030-06-13T00:00:00Z"))
+	assert.Equal(suite.T(), http.StatusCreated, suite.join(suite.guests[2], "2030-06-11T00:00:00Z", "2030-06-14T00:00:00Z"))
+
+	w := tests.MakeRequestWithToken(suite.router, "POST", fmt.Sprintf("/bookings/%d/cancel", suite.booking.ID), nil, tests.GenerateTestToken(suite.T(), &suite.guests[0]))
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var entries []models.WaitlistEntry
+	suite.db.Order("created_at, id").Find(&entries)
+	assert.Equal(suite.T(), "offered", entries[0].Status)
+
+	// The offered guest leaves, so the next guest in line gets the dates right away
+	w = tests.MakeRequestWithToken(suite.router, "DELETE", fmt.Sprintf("/waitlist/%d", entries[0].ID), nil, tests.GenerateTestToken(suite.T(), &suite.guests[1]))
+	assert.Equal(suite.T(), http.StatusNoContent, w.Code)
+
+	suite.db.Order("created_at, id").Find(&entries)
+	assert.Equal(suite.T(), "cancelled", entries[0].Status)
+	assert.Equal(suite.T(), "offered", entries[1].Status)
+}
+
+func TestWaitlistHandlerSuite(t *testing.T) {
+	suite.Run(t, new(WaitlistHandlerTestSuite))
+}
@@ -0,0 +1,226 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bookaroo/bookaroo-platform-be/geo"
+	"github.com/bookaroo/bookaroo-platform-be/handlers"
+	"github.com/bookaroo/bookaroo-platform-be/middleware"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/search"
+	"github.com/bookaroo/bookaroo-platform-be/tests"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type WishlistHandlerTestSuite struct {
+	suite.Suite
+	db         *gorm.DB
+	router     *gin.Engine
+	guest      models.User
+	token      string
+	otherToken string
+	villa      models.Property
+	hut        models.Property
+}
+
+func (suite *WishlistHandlerTestSuite) SetupSuite() {
+	suite.db = tests.SetupTestDB(suite.T())
+	handler := handlers.NewWishlistHandler(suite.db)
+	propertyHandler := handlers.NewPropertyHandler(suite.db, search.NewPostgres(suite.db), geo.NewGazetteer())
+
+	suite.router = gin.New()
+	suite.router.GET("/properties", middleware.OptionalAuth(), propertyHandler.ListProperties)
+	suite.router.GET("/properties/:id", middleware.OptionalAuth(), propertyHandler.GetProperty)
+	suite.router.GET("/wishlists/shared/:token", middleware.OptionalAuth(), handler.GetSharedWishlist)
+	wishlists := suite.router.Group("/wishlists", middleware.AuthMiddleware())
+	wishlists.GET("", handler.ListWishlists)
+	wishlists.POST("", handler.CreateWishlist)
+	wishlists.GET("/:id", handler.GetWishlist)
+	wishlists.PATCH("/:id", handler.RenameWishlist)
+	wishlists.DELETE("/:id", handler.DeleteWishlist)
+	wishlists.POST("/:id/items", handler.AddWishlistItem)
+	wishlists.DELETE("/:id/items/:property_id", handler.RemoveWishlistItem)
+	wishlists.POST("/:id/share", handler.ShareWishlist)
+	wishlists.DELETE("/:id/share", handler.UnshareWishlist)
+}
+
+func (suite *WishlistHandlerTestSuite) SetupTest() {
+	suite.db.Exec("DELETE FROM wishlist_items")
+	suite.db.Exec("DELETE FROM wishlists")
+	suite.db.Exec("DELETE FROM property_translations")
+	suite.db.Exec("DELETE FROM property_amenities")
+	suite.db.Exec("DELETE FROM property_images")
+	suite.db.Exec("DELETE FROM properties")
+	suite.db.Exec("DELETE FROM users")
+
+	owner := models.User{Email: "owner@example.com", Name: "Owner", Role: "owner"}
+	suite.db.Create(&owner)
+	suite.guest = models.User{Email: "guest@example.com", Name: "Guest", Role: "guest"}
+	suite.db.Create(&suite.guest)
+	suite.token = tests.GenerateTestToken(suite.T(), &suite.guest)
+	other := models.User{Email: "other@example.com", Name: "Other", Role: "guest"}
+	suite.db.Create(&other)
+	suite.otherToken = tests.GenerateTestToken(suite.T(), &other)
+
+	suite.villa = models.Property{Name: "Rice Field Villa", Description: "Green terraces", Location: "Ubud", Price: 120, OwnerID: owner.ID}
+	suite.db.Create(&suite.villa)
+	suite.hut = models.Property{Name: "Beach Hut", Description: "Steps from the sand", Location: "Canggu", Price: 80, OwnerID: owner.ID}
+	suite.db.Create(&suite.hut)
+}
+
+func (suite *WishlistHandlerTestSuite) createWishlist(name string) models.Wishlist {
+	w := tests.MakeRequestWithToken(suite.router, "POST", "/wishlists", []byte(fmt.Sprintf(`{"name": %q}`, name)), suite.token)
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	var wishlist models.Wishlist
+	tests.ParseResponse(suite.T(), w, &wishlist)
+	return wishlist
+}
+
+func (suite *WishlistHandlerTestSuite) save(wishlistID, propertyID uint, note string) *httptest.ResponseRecorder {
+	body := fmt.Sprintf(`{"property_id": %d, "note": %q}`, propertyID, note)
+	return tests.MakeRequestWithToken(suite.router, "POST", fmt.Sprintf("/wishlists/%d/items", wishlistID), []byte(body), suite.token)
+}
+
+func (suite *WishlistHandlerTestSuite) TestSaveAndRemoveProperties() {
+	wishlist := suite.createWishlist("Bali trip")
+	assert.Equal(suite.T(), "Bali trip", wishlist.Name)
+	assert.Empty(suite.T(), wishlist.Items)
+
+	w := suite.save(wishlist.ID, suite.villa.ID, "Pool")
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	w = suite.save(wishlist.ID, suite.hut.ID, "")
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	// Saving again replaces the note instead of duplicating the property
+	w = suite.save(wishlist.ID, suite.villa.ID, "Pool and view")
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	tests.ParseResponse(suite.T(), w, &wishlist)
+	suite.Require().Len(wishlist.Items, 2)
+	assert.Equal(suite.T(), suite.villa.ID, wishlist.Items[0].PropertyID)
+	assert.Equal(suite.T(), "Pool and view", wishlist.Items[0].Note)
+	suite.Require().NotNil(wishlist.Items[0].Property)
+	assert.Equal(suite.T(), "Rice Field Villa", wishlist.Items[0].Property.Name)
+	assert.True(suite.T(), wishlist.Items[0].Property.IsFavorited)
+
+	w = tests.MakeRequestWithToken(suite.router, "DELETE", fmt.Sprintf("/wishlists/%d/items/%d", wishlist.ID, suite.villa.ID), nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	tests.ParseResponse(suite.T(), w, &wishlist)
+	suite.Require().Len(wishlist.Items, 1)
+	assert.Equal(suite.T(), suite.hut.ID, wishlist.Items[0].PropertyID)
+
+	w = tests.MakeRequestWithToken(suite.router, "DELETE", fmt.Sprintf("/wishlists/%d/items/%d", wishlist.ID, suite.villa.ID), nil, suite.token)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *WishlistHandlerTestSuite) TestUnlistedPropertiesCantBeSaved() {
+	wishlist := suite.createWishlist("Bali trip")
+	suite.db.Model(&suite.villa).Update("status", models.ListingDraft)
+
+	w := suite.save(wishlist.ID, suite.villa.ID, "")
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *WishlistHandlerTestSuite) TestRenameListAndDelete() {
+	first := suite.createWishlist("Bali trip")
+	second := suite.createWishlist("Someday")
+
+	w := tests.MakeRequestWithToken(suite.router, "PATCH", fmt.Sprintf("/wishlists/%d", first.ID), []byte(`{"name": "Bali, June"}`), suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	w = tests.MakeRequestWithToken(suite.router, "GET", "/wishlists", nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	var wishlists []models.Wishlist
+	tests.ParseResponse(suite.T(), w, &wishlists)
+	suite.Require().Len(wishlists, 2)
+	assert.Equal(suite.T(), second.ID, wishlists[0].ID, "newest first")
+	assert.Equal(suite.T(), "Bali, June", wishlists[1].Name)
+
+	suite.save(second.ID, suite.villa.ID, "")
+	w = tests.MakeRequestWithToken(suite.router, "DELETE", fmt.Sprintf("/wishlists/%d", second.ID), nil, suite.token)
+	suite.Require().Equal(http.StatusNoContent, w.Code)
+
+	var items int64
+	suite.db.Model(&models.WishlistItem{}).Count(&items)
+	assert.Equal(suite.T(), int64(0), items)
+}
+
+func (suite *WishlistHandlerTestSuite) TestOtherUsersWishlistsAreHidden() {
+	wishlist := suite.createWishlist("Bali trip")
+
+	path := fmt.Sprintf("/wishlists/%d", wishlist.ID)
+	w := tests.MakeRequestWithToken(suite.router, "GET", path, nil, suite.otherToken)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+	w = tests.MakeRequestWithToken(suite.router, "DELETE", path, nil, suite.otherToken)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	w = tests.MakeRequestWithToken(suite.router, "GET", "/wishlists", nil, suite.otherToken)
+	assert.JSONEq(suite.T(), `[]`, w.Body.String())
+}
+
+func (suite *WishlistHandlerTestSuite) TestPropertiesAreFlaggedAsFavorited() {
+	wishlist := suite.createWishlist("Bali trip")
+	suite.save(wishlist.ID, suite.villa.ID, "")
+
+	var response handlers.PropertyListResponse
+	w := tests.MakeRequestWithToken(suite.router, "GET", "/properties", nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	tests.ParseResponse(suite.T(), w, &response)
+	suite.Require().Len(response.Properties, 2)
+	for _, p := range response.Properties {
+		assert.Equal(suite.T(), p.ID == suite.villa.ID, p.IsFavorited, p.Name)
+	}
+
+	var property models.Property
+	w = tests.MakeRequestWithToken(suite.router, "GET", fmt.Sprintf("/properties/%d", suite.villa.ID), nil, suite.token)
+	tests.ParseResponse(suite.T(), w, &property)
+	assert.True(suite.T(), property.IsFavorited)
+
+	// Other guests and anonymous visitors don't see the guest's favorites
+	w = tests.MakeRequestWithToken(suite.router, "GET", fmt.Sprintf("/properties/%d", suite.villa.ID), nil, suite.otherToken)
+	tests.ParseResponse(suite.T(), w, &property)
+	assert.False(suite.T(), property.IsFavorited)
+
+	w = tests.MakeRequest(suite.router, "GET", fmt.Sprintf("/properties/%d", suite.villa.ID), nil)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	tests.ParseResponse(suite.T(), w, &property)
+	assert.False(suite.T(), property.IsFavorited)
+}
+
+func (suite *WishlistHandlerTestSuite) TestShareWishlist() {
+	wishlist := suite.createWishlist("Bali trip")
+	suite.save(wishlist.ID, suite.villa.ID, "Pool")
+
+	w := tests.MakeRequestWithToken(suite.router, "POST", fmt.Sprintf("/wishlists/%d/share", wishlist.ID), nil, suite.token)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var share handlers.WishlistShareResponse
+	tests.ParseResponse(suite.T(), w, &share)
+	assert.Equal(suite.T(), "/api/wishlists/shared/"+share.Token, share.URL)
+
+	w = tests.MakeRequest(suite.router, "GET", "/wishlists/shared/"+share.Token, nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var shared handlers.SharedWishlistResponse
+	tests.ParseResponse(suite.T(), w, &shared)
+	assert.Equal(suite.T(), "Bali trip", shared.Name)
+	suite.Require().Len(shared.Items, 1)
+	assert.Equal(suite.T(), "Pool", shared.Items[0].Note)
+	assert.False(suite.T(), shared.Items[0].Property.IsFavorited, "not a favorite of the anonymous visitor")
+	assert.NotContains(suite.T(), w.Body.String(), "user_id")
+
+	w = tests.MakeRequest(suite.router, "GET", "/wishlists/shared/not-a-token", nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	w = tests.MakeRequestWithToken(suite.router, "DELETE", fmt.Sprintf("/wishlists/%d/share", wishlist.ID), nil, suite.token)
+	suite.Require().Equal(http.StatusNoContent, w.Code)
+	w = tests.MakeRequest(suite.router, "GET", "/wishlists/shared/"+share.Token, nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func TestWishlistHandlerSuite(t *testing.T) {
+	suite.Run(t, new(WishlistHandlerTestSuite))
+}
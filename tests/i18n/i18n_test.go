@@ -0,0 +1,45 @@
+package i18n_test
+
+import (
+	"testing"
+
+	"github.com/bookaroo/bookaroo-platform-be/i18n"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLocale(t *testing.T) {
+	for in, want := range map[string]string{"id": "id", " TH ": "th", "pt-br": "pt-BR", "en_US": "en-US"} {
+		got, err := i18n.ParseLocale(in)
+		if assert.NoError(t, err, in) {
+			assert.Equal(t, want, got, in)
+		}
+	}
+
+	for _, in := range []string{"", "und", "not a locale", "1"} {
+		_, err := i18n.ParseLocale(in)
+		assert.ErrorIs(t, err, i18n.ErrInvalidLocale, in)
+	}
+}
+
+func TestBest(t *testing.T) {
+	available := []string{"id", "en", "th"}
+	cases := []struct {
+		lang, acceptLanguage, want string
+	}{
+		{"", "th-TH,th;q=0.9,en;q=0.8", "th"},
+		{"", "fr-FR, id;q=0.5", "id"},
+		{"id", "th", "id"},
+		{"", "en-GB", "en"},
+		// Nothing close enough: the default locale
+		{"", "ja", "en"},
+		{"", "", "en"},
+		{"bogus!", "", "en"},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.want, i18n.Best(i18n.Requested(c.lang, c.acceptLanguage), available), c.lang+"|"+c.acceptLanguage)
+	}
+
+	// Without the default locale, the first available one
+	assert.Equal(t, "id", i18n.Best(i18n.Requested("ja", ""), []string{"id", "th"}))
+	assert.Empty(t, i18n.Best(i18n.Requested("th", ""), nil))
+}
@@ -0,0 +1,64 @@
+package ical_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bookaroo/bookaroo-platform-be/ical"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteCalendar(t *testing.T) {
+	stamp := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	cal := ical.Calendar{
+		ProdID: "-//Bookaroo//Test//EN",
+		Name:   "Beach House",
+		Events: []ical.Event{
+			{
+				UID:     ical.UID("booking", 7, "bookaroo.com"),
+				Start:   time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC),
+				End:     time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC),
+				Summary: "Reserved",
+				Status:  "CONFIRMED",
+				Stamp:   stamp,
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, cal.Write(&buf))
+	out := buf.String()
+
+	assert.True(t, strings.HasPrefix(out, "BEGIN:VCALENDAR\r\n"))
+	assert.True(t, strings.HasSuffix(out, "END:VCALENDAR\r\n"))
+	assert.Contains(t, out, "UID:booking-7@bookaroo.com\r\n")
+	assert.Contains(t, out, "DTSTART;VALUE=DATE:20260310\r\n")
+	assert.Contains(t, out, "DTEND;VALUE=DATE:20260315\r\n")
+	assert.Contains(t, out, "DTSTAMP:20260102T030405Z\r\n")
+	assert.Contains(t, out, "X-WR-CALNAME:Beach House\r\n")
+}
+
+func TestWriteCalendarEscapesAndFolds(t *testing.T) {
+	cal := ical.Calendar{
+		ProdID: "-//Bookaroo//Test//EN",
+		Events: []ical.Event{
+			{
+				UID:         "block-1@bookaroo.com",
+				Start:       time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC),
+				End:         time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC),
+				Summary:     "Maintenance; pool, garden",
+				Description: strings.Repeat("Ruang tamu dibersihkan — ", 10),
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, cal.Write(&buf))
+
+	assert.Contains(t, buf.String(), `SUMMARY:Maintenance\; pool\, garden`)
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), 75)
+	}
+}
@@ -0,0 +1,68 @@
+package ical_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bookaroo/bookaroo-platform-be/ical"
+	"github.com/stretchr/testify/assert"
+)
+
+const airbnbFeed = "BEGIN:VCALENDAR\r\n" +
+	"PRODID:-//Airbnb Inc//Hosting Calendar 1.0//EN\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"DTSTART;VALUE=DATE:20260310\r\n" +
+	"DTEND;VALUE=DATE:20260314\r\n" +
+	"UID:abc123@airbnb.com\r\n" +
+	"SUMMARY:Reserved\r\n" +
+	"DESCRIPTION:Reservation URL: https://www.airbnb.com/hosting/reservations/\r\n" +
+	" details/HM123\\nPhone: 1234\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"DTSTART;TZID=Asia/Jakarta:20260401T140000\r\n" +
+	"DTEND;TZID=Asia/Jakarta:20260403T120000\r\n" +
+	"UID:def456@example.com\r\n" +
+	"SUMMARY:Blocked\\, maintenance\r\n" +
+	"STATUS:CANCELLED\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"DTSTART:20260501\r\n" +
+	"UID:ghi789@example.com\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParse(t *testing.T) {
+	events, err := ical.Parse(strings.NewReader(airbnbFeed))
+	assert.NoError(t, err)
+	assert.Len(t, events, 3)
+
+	assert.Equal(t, "abc123@airbnb.com", events[0].UID)
+	assert.Equal(t, time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC), events[0].Start)
+	assert.Equal(t, time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC), events[0].End)
+	assert.Contains(t, events[0].Description, "reservations/details/HM123\nPhone")
+
+	assert.Equal(t, "Blocked, maintenance", events[1].Summary)
+	assert.Equal(t, "CANCELLED", events[1].Status)
+	assert.Equal(t, time.Date(2026, 4, 1, 7, 0, 0, 0, time.UTC), events[1].Start)
+
+	// A missing DTEND defaults to a single night
+	assert.Equal(t, time.Date(2026, 5, 2, 0, 0, 0, 0, time.UTC), events[2].End)
+}
+
+func TestParseRejectsNonCalendar(t *testing.T) {
+	_, err := ical.Parse(strings.NewReader("<html><body>Not found</body></html>"))
+	assert.Error(t, err)
+}
+
+func TestParseRejectsTruncatedFeed(t *testing.T) {
+	// Cut inside the last VEVENT
+	cut := strings.Index(airbnbFeed, "UID:ghi789")
+	_, err := ical.Parse(strings.NewReader(airbnbFeed[:cut]))
+	assert.Error(t, err)
+
+	// Every event closed but the calendar never ends
+	_, err = ical.Parse(strings.NewReader(strings.TrimSuffix(airbnbFeed, "END:VCALENDAR\r\n")))
+	assert.Error(t, err)
+}
@@ -6,9 +6,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/bookaroo/bookaroo-platform-be/geo"
 	"github.com/bookaroo/bookaroo-platform-be/handlers"
 	"github.com/bookaroo/bookaroo-platform-be/models"
 	"github.com/bookaroo/bookaroo-platform-be/routes"
+	"github.com/bookaroo/bookaroo-platform-be/search"
 	"github.com/bookaroo/bookaroo-platform-be/tests"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -27,7 +29,7 @@ func (suite *APIIntegrationTestSuite) SetupSuite() {
 
 	// Setup router with all routes
 	suite.router = gin.New()
-	routes.SetupRoutes(suite.router, suite.db)
+	routes.SetupRoutes(suite.router, suite.db, search.NewPostgres(suite.db), geo.NewGazetteer())
 }
 
 func (suite *APIIntegrationTestSuite) SetupTest() {
@@ -60,10 +62,10 @@ func (suite *APIIntegrationTestSuite) TestFullBookingFlow() {
 	w := tests.MakeRequest(suite.router, "GET", "/api/properties/search?location=Bali", nil)
 	assert.Equal(suite.T(), http.StatusOK, w.Code)
 
-	var searchResponse []models.Property
+	var searchResponse handlers.SearchResponse
 	tests.ParseResponse(suite.T(), w, &searchResponse)
-	assert.Len(suite.T(), searchResponse, 1)
-	assert.Equal(suite.T(), "Luxury Villa", searchResponse[0].Name)
+	assert.Len(suite.T(), searchResponse.Properties, 1)
+	assert.Equal(suite.T(), "Luxury Villa", searchResponse.Properties[0].Name)
 
 	// 3. Get property details
 	w = tests.MakeRequest(suite.router, "GET", "/api/properties/"+fmt.Sprint(property.ID), nil)
@@ -92,7 +94,7 @@ func (suite *APIIntegrationTestSuite) TestFullBookingFlow() {
 	assert.Equal(suite.T(), "pending", bookingResponse.Status)
 
 	// 5. Check owner's dashboard
-	w = tests.MakeRequest(suite.router, "GET", "/api/dashboard", nil)
+	w = tests.MakeRequestWithToken(suite.router, "GET", "/api/dashboard", nil, tests.GenerateTestToken(suite.T(), &owner))
 	assert.Equal(suite.T(), http.StatusOK, w.Code)
 
 	var dashboardResponse map[string]interface{}
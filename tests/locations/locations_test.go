@@ -0,0 +1,49 @@
+package locations_test
+
+import (
+	"testing"
+
+	"github.com/bookaroo/bookaroo-platform-be/locations"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	cases := map[string]locations.Parts{
+		"Ubud":                      {City: "Ubud", Region: "Bali", Country: "Indonesia"},
+		"Villa Sunset, Seminyak":    {City: "Seminyak", Region: "Bali", Country: "Indonesia"},
+		"Jimbaran, Bali":            {City: "Jimbaran", Region: "Bali", Country: "Indonesia"},
+		"Paris":                     {City: "Paris", Country: "France"},
+		"Hallstatt, Upper Austria":  {City: "Hallstatt", Region: "Upper Austria"},
+		"Springfield, Oregon, USA":  {City: "Springfield", Region: "Oregon", Country: "USA"},
+		"Old Town, Porto, Portugal": {City: "Old Town", Region: "Porto", Country: "Portugal"},
+		"Test Location":             {City: "Test Location"},
+		" , ":                       {},
+	}
+	for in, want := range cases {
+		assert.Equal(t, want, locations.Parse(in), in)
+	}
+}
+
+func TestLabel(t *testing.T) {
+	country := models.Place{Name: "Indonesia"}
+	region := models.Place{Name: "Bali", Parent: &country}
+	city := models.Place{Name: "Ubud", Parent: &region}
+
+	assert.Equal(t, "Ubud, Bali, Indonesia", locations.Label(city))
+	assert.Equal(t, "Indonesia", locations.Label(country))
+}
+
+func TestAddress(t *testing.T) {
+	assert.Equal(t, models.Address{City: "Ubud", Region: "Bali", CountryCode: "ID"}, locations.Address("Villa Sunset, Ubud"))
+	assert.Equal(t, models.Address{City: "Hallstatt", Region: "Upper Austria"}, locations.Address("Hallstatt, Upper Austria"))
+	assert.Equal(t, models.Address{}, locations.Address(""))
+}
+
+func TestAddressText(t *testing.T) {
+	assert.Equal(t, "Ubud, Bali, Indonesia", locations.AddressText(models.Address{Street: "Jalan Raya Sayan 17", City: "Ubud", Region: "Bali", CountryCode: "ID"}))
+	assert.Equal(t, "Hallstatt", locations.AddressText(models.Address{City: "Hallstatt", CountryCode: "AT"}))
+
+	// The text files the address as its location would be
+	assert.Equal(t, locations.Parse("Ubud, Bali, Indonesia"), locations.Parse("Villa Sunset, Ubud"))
+}
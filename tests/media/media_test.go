@@ -0,0 +1,184 @@
+package media_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"math/bits"
+	"os/exec"
+	"testing"
+
+	"github.com/bookaroo/bookaroo-platform-be/media"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/webp"
+)
+
+func gradient(w, h int, alpha bool) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			a := uint8(255)
+			if alpha {
+				a = uint8(x * 255 / w)
+			}
+			img.Set(x, y, color.NRGBA{R: uint8(x * 7), G: uint8(y * 3), B: uint8(x ^ y), A: a})
+		}
+	}
+	return img
+}
+
+// fakeWebP records the sizes it was asked to encode
+type fakeWebP struct {
+	widths []int
+}
+
+func (f *fakeWebP) EncodeWebP(img image.Image) ([]byte, error) {
+	f.widths = append(f.widths, img.Bounds().Dx())
+	return []byte("RIFF"), nil
+}
+
+func TestProcessAddsWebPCopies(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, gradient(2000, 1000, false)))
+
+	encoder := &fakeWebP{}
+	result, err := media.Process(buf.Bytes(), encoder)
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(result.Variants))
+	for _, v := range result.Variants {
+		names = append(names, v.Name)
+	}
+	assert.Equal(t, []string{"large.jpg", "medium.jpg", "medium.webp", "thumb.jpg", "thumb.webp"}, names)
+	assert.Equal(t, media.VariantNames(), names)
+	assert.Equal(t, []int{800, 320}, encoder.widths)
+}
+
+func TestCWebPEncodes(t *testing.T) {
+	path, err := exec.LookPath("cwebp")
+	if err != nil {
+		t.Skip("cwebp is not installed")
+	}
+
+	data, err := media.NewCWebP(path).EncodeWebP(gradient(97, 61, true))
+	require.NoError(t, err)
+
+	decoded, err := webp.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 97, 61), decoded.Bounds())
+}
+
+// withOrientation inserts an EXIF APP1 segment carrying the orientation tag after the SOI marker
+func withOrientation(jpg []byte, orientation uint16) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	tiff = append(tiff, 0, 1) // One IFD entry
+	entry := make([]byte, 12)
+	binary.BigEndian.PutUint16(entry[0:], 0x0112)
+	binary.BigEndian.PutUint16(entry[2:], 3) // SHORT
+	binary.BigEndian.PutUint32(entry[4:], 1)
+	binary.BigEndian.PutUint16(entry[8:], orientation)
+	tiff = append(tiff, entry...)
+	tiff = append(tiff, 0, 0, 0, 0)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xff, 0xe1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	out := append([]byte{}, jpg[:2]...)
+	out = append(out, segment...)
+	return append(out, jpg[2:]...)
+}
+
+func TestProcessAppliesOrientationAndStripsExif(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, gradient(200, 100, false), nil))
+	upload := withOrientation(buf.Bytes(), 6)
+	require.True(t, bytes.Contains(upload, []byte("Exif")))
+
+	result, err := media.Process(upload, nil)
+	require.NoError(t, err)
+
+	// Rotated 90 degrees, so the photo is now portrait
+	assert.Equal(t, 100, result.Width)
+	assert.Equal(t, 200, result.Height)
+
+	names := make([]string, 0, len(result.Variants))
+	for _, v := range result.Variants {
+		names = append(names, v.Name)
+		assert.False(t, bytes.Contains(v.Data, []byte("Exif")), v.Name)
+	}
+	assert.Equal(t, []string{"large.jpg", "medium.jpg", "thumb.jpg"}, names)
+	assert.Subset(t, media.VariantNames(), names)
+}
+
+func TestProcessResizesLargeImages(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, gradient(2000, 1000, true)))
+
+	result, err := media.Process(buf.Bytes(), nil)
+	require.NoError(t, err)
+
+	for _, v := range result.Variants {
+		if v.Name != "thumb.jpg" {
+			continue
+		}
+		cfg, err := jpeg.DecodeConfig(bytes.NewReader(v.Data))
+		require.NoError(t, err)
+		assert.Equal(t, 320, cfg.Width)
+		assert.Equal(t, 160, cfg.Height)
+	}
+}
+
+func TestProcessRejectsInvalidUploads(t *testing.T) {
+	_, err := media.Process([]byte("<html>not an image</html>"), nil)
+	assert.ErrorIs(t, err, media.ErrUnsupportedType)
+
+	_, err = media.Process(make([]byte, media.MaxUploadSize+1), nil)
+	assert.ErrorIs(t, err, media.ErrTooLarge)
+}
+
+// scene draws the same picture at any size
+func scene(w, h int, mirrored bool) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			fx, fy := float64(x)/float64(w), float64(y)/float64(h)
+			if mirrored {
+				fx = 1 - fx
+			}
+			v := uint8(255 * (0.5 + 0.5*math.Sin(fx*9+fy*4)))
+			img.Set(x, y, color.NRGBA{R: v, G: v / 2, B: 255 - v, A: 255})
+		}
+	}
+	return img
+}
+
+func TestDifferenceHash(t *testing.T) {
+	original := media.DifferenceHash(scene(800, 600, false))
+	assert.NotZero(t, original)
+
+	// A smaller, recompressed copy hashes to nearly the same value
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, scene(320, 240, false), &jpeg.Options{Quality: 60}))
+	copied, err := jpeg.Decode(&buf)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, bits.OnesCount64(original^media.DifferenceHash(copied)), 6)
+
+	// A different picture doesn't
+	assert.Greater(t, bits.OnesCount64(original^media.DifferenceHash(scene(800, 600, true))), 20)
+}
+
+func TestProcessHashesImage(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, scene(400, 300, false)))
+
+	result, err := media.Process(buf.Bytes(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, media.DifferenceHash(scene(400, 300, false)), result.PerceptualHash)
+}
@@ -0,0 +1,17 @@
+package moderation_test
+
+import (
+	"testing"
+
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/moderation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMissing(t *testing.T) {
+	complete := models.Property{Name: "Beach Villa", Description: "Steps from the sand", Location: "Seminyak", Price: 150}
+	assert.Empty(t, moderation.Missing(complete))
+
+	assert.Equal(t, []string{"description", "price"}, moderation.Missing(models.Property{Name: "Beach Villa", Description: " ", Location: "Seminyak"}))
+	assert.Equal(t, []string{"name", "description", "location", "price"}, moderation.Missing(models.Property{}))
+}
@@ -0,0 +1,59 @@
+package pagination_test
+
+import (
+	"testing"
+
+	"github.com/bookaroo/bookaroo-platform-be/pagination"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	page, err := pagination.Parse("", "", "newest")
+	require.NoError(t, err)
+	assert.Equal(t, pagination.DefaultLimit, page.Limit)
+	assert.Nil(t, page.After)
+
+	page, err = pagination.Parse("5000", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, pagination.MaxLimit, page.Limit)
+
+	for _, limit := range []string{"0", "-3", "ten"} {
+		_, err = pagination.Parse(limit, "", "")
+		assert.ErrorIs(t, err, pagination.ErrInvalidLimit, limit)
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursor := pagination.Cursor{Sort: "price_asc", Key: 149.99, ID: 42}
+
+	page, err := pagination.Parse("10", cursor.Encode(), "price_asc")
+	require.NoError(t, err)
+	assert.Equal(t, &cursor, page.After)
+
+	// Cursors only continue the sort they were issued for
+	_, err = pagination.Parse("10", cursor.Encode(), "price_desc")
+	assert.ErrorIs(t, err, pagination.ErrInvalidCursor)
+
+	_, err = pagination.Parse("10", "not-a-cursor", "price_asc")
+	assert.ErrorIs(t, err, pagination.ErrInvalidCursor)
+}
+
+func TestTrim(t *testing.T) {
+	page := pagination.Page{Sort: "newest", Limit: 2}
+	cursor := func(id uint) pagination.Cursor { return pagination.Cursor{ID: id} }
+
+	rows, meta := pagination.Trim([]uint{9, 8, 7}, page, 3, cursor)
+	assert.Equal(t, []uint{9, 8}, rows)
+	assert.True(t, meta.HasMore)
+	assert.Equal(t, int64(3), meta.TotalEstimate)
+
+	next, err := pagination.Decode(meta.NextCursor)
+	require.NoError(t, err)
+	assert.Equal(t, pagination.Cursor{Sort: "newest", ID: 8}, *next)
+
+	rows, meta = pagination.Trim([]uint{7}, page, 3, cursor)
+	assert.Equal(t, []uint{7}, rows)
+	assert.False(t, meta.HasMore)
+	assert.Empty(t, meta.NextCursor)
+}
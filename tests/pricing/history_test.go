@@ -0,0 +1,47 @@
+package pricing_test
+
+import (
+	"testing"
+
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/pricing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangesOfNewProperty(t *testing.T) {
+	changes := pricing.Changes(nil, models.Property{ID: 7, Price: 120})
+	require.Len(t, changes, 1)
+	assert.Equal(t, uint(7), changes[0].PropertyID)
+	assert.Equal(t, pricing.FieldPrice, changes[0].Field)
+	assert.Nil(t, changes[0].OldValue)
+	assert.Equal(t, 120.0, changes[0].NewValue)
+
+	changes = pricing.Changes(nil, models.Property{ID: 7, Price: 120, MonthlyPrice: 2500})
+	require.Len(t, changes, 2)
+	assert.Equal(t, pricing.FieldMonthlyPrice, changes[1].Field)
+	assert.Equal(t, 2500.0, changes[1].NewValue)
+}
+
+func TestChangesOfUpdatedProperty(t *testing.T) {
+	before := models.Property{ID: 7, Price: 120, MonthlyPrice: 2500}
+
+	assert.Empty(t, pricing.Changes(&before, before))
+
+	after := before
+	after.Price = 100
+	changes := pricing.Changes(&before, after)
+	require.Len(t, changes, 1)
+	assert.Equal(t, pricing.FieldPrice, changes[0].Field)
+	require.NotNil(t, changes[0].OldValue)
+	assert.Equal(t, 120.0, *changes[0].OldValue)
+	assert.Equal(t, 100.0, changes[0].NewValue)
+
+	// Dropping monthly stays is a change to 0
+	after = before
+	after.MonthlyPrice = 0
+	changes = pricing.Changes(&before, after)
+	require.Len(t, changes, 1)
+	assert.Equal(t, pricing.FieldMonthlyPrice, changes[0].Field)
+	assert.Equal(t, 0.0, changes[0].NewValue)
+}
@@ -0,0 +1,113 @@
+package recommend_test
+
+import (
+	"testing"
+
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/recommend"
+	"github.com/stretchr/testify/assert"
+)
+
+func coords(lat, lng float64) (*float64, *float64) {
+	return &lat, &lng
+}
+
+func amenities(ids ...uint) []models.Amenity {
+	list := make([]models.Amenity, len(ids))
+	for i, id := range ids {
+		list[i] = models.Amenity{ID: id}
+	}
+	return list
+}
+
+func TestProximity(t *testing.T) {
+	scorer := recommend.Proximity{ScaleKm: 10}
+	viewed := models.Property{}
+	viewed.Latitude, viewed.Longitude = coords(0, 0)
+
+	same := models.Property{}
+	same.Latitude, same.Longitude = coords(0, 0)
+	assert.Equal(t, 1.0, scorer.Score(viewed, same))
+
+	// 0.09 degrees of latitude is about 10 km
+	near := models.Property{}
+	near.Latitude, near.Longitude = coords(0.09, 0)
+	assert.InDelta(t, 0.5, scorer.Score(viewed, near), 0.01)
+
+	far := models.Property{}
+	far.Latitude, far.Longitude = coords(1, 0)
+	assert.Less(t, scorer.Score(viewed, far), 0.001)
+
+	// Without coordinates only the place counts
+	place, other := uint(1), uint(2)
+	assert.Equal(t, 1.0, scorer.Score(models.Property{PlaceID: &place}, models.Property{PlaceID: &place}))
+	assert.Equal(t, 0.0, scorer.Score(models.Property{PlaceID: &place}, models.Property{PlaceID: &other}))
+	assert.Equal(t, 0.0, scorer.Score(viewed, models.Property{}))
+}
+
+func TestPriceBand(t *testing.T) {
+	assert.Equal(t, 1.0, recommend.PriceBand(models.Property{Price: 100}, models.Property{Price: 100}))
+	assert.Equal(t, 0.5, recommend.PriceBand(models.Property{Price: 100}, models.Property{Price: 200}))
+	assert.Equal(t, 0.5, recommend.PriceBand(models.Property{Price: 100}, models.Property{Price: 50}))
+	assert.Equal(t, 0.0, recommend.PriceBand(models.Property{Price: 100}, models.Property{}))
+}
+
+func TestAmenityOverlap(t *testing.T) {
+	viewed := models.Property{AmenityList: amenities(1, 2, 3)}
+	assert.Equal(t, 1.0, recommend.AmenityOverlap(viewed, models.Property{AmenityList: amenities(3, 2, 1)}))
+	assert.Equal(t, 0.5, recommend.AmenityOverlap(viewed, models.Property{AmenityList: amenities(2, 3, 4)}))
+	assert.Equal(t, 0.0, recommend.AmenityOverlap(viewed, models.Property{AmenityList: amenities(4)}))
+	assert.Equal(t, 0.0, recommend.AmenityOverlap(models.Property{}, models.Property{}))
+}
+
+func TestCapacity(t *testing.T) {
+	assert.Equal(t, 1.0, recommend.Capacity(models.Property{MaxGuests: 4}, models.Property{MaxGuests: 4}))
+	assert.Equal(t, 0.5, recommend.Capacity(models.Property{MaxGuests: 4}, models.Property{MaxGuests: 2}))
+	assert.Equal(t, 0.5, recommend.Capacity(models.Property{MaxGuests: 4}, models.Property{}))
+}
+
+func TestWeighted(t *testing.T) {
+	one := recommend.ScorerFunc(func(_, _ models.Property) float64 { return 1 })
+	zero := recommend.ScorerFunc(func(_, _ models.Property) float64 { return 0 })
+
+	scorer := recommend.Weighted{{Scorer: one, Weight: 3}, {Scorer: zero, Weight: 1}}
+	assert.Equal(t, 0.75, scorer.Score(models.Property{}, models.Property{}))
+	assert.Equal(t, 0.0, recommend.Weighted{}.Score(models.Property{}, models.Property{}))
+}
+
+func TestRankWithDefaultScorer(t *testing.T) {
+	viewed := models.Property{ID: 1, Price: 120, MaxGuests: 4, AmenityList: amenities(1, 2)}
+	viewed.Latitude, viewed.Longitude = coords(-8.5, 115.26)
+
+	twin := models.Property{ID: 2, Price: 120, MaxGuests: 4, AmenityList: amenities(1, 2)}
+	twin.Latitude, twin.Longitude = coords(-8.5, 115.26)
+	pricier := models.Property{ID: 3, Price: 240, MaxGuests: 4, AmenityList: amenities(1, 2)}
+	pricier.Latitude, pricier.Longitude = coords(-8.5, 115.26)
+	farAway := models.Property{ID: 4, Price: 120, MaxGuests: 4, AmenityList: amenities(1, 2)}
+	farAway.Latitude, farAway.Longitude = coords(-8.8, 115.16)
+	bare := models.Property{ID: 5, Price: 120, MaxGuests: 4}
+	bare.Latitude, bare.Longitude = coords(-8.5, 115.26)
+
+	candidates := []models.Property{bare, farAway, viewed, pricier, twin}
+	ranked := recommend.Rank(viewed, candidates, recommend.Default(), 10)
+
+	ids := make([]uint, len(ranked))
+	for i, r := range ranked {
+		ids[i] = r.Property.ID
+	}
+	assert.Equal(t, []uint{2, 3, 5, 4}, ids, "the viewed property is left out")
+	assert.InDelta(t, 1.0, ranked[0].Score, 1e-9)
+	assert.InDelta(t, 0.875, ranked[1].Score, 1e-9)
+	assert.InDelta(t, 0.8, ranked[2].Score, 1e-9)
+
+	assert.Len(t, recommend.Rank(viewed, candidates, recommend.Default(), 2), 2)
+}
+
+func TestRankBreaksTiesByID(t *testing.T) {
+	same := recommend.ScorerFunc(func(_, _ models.Property) float64 { return 0.5 })
+	ranked := recommend.Rank(models.Property{ID: 1}, []models.Property{{ID: 9}, {ID: 3}, {ID: 5}}, same, 10)
+
+	assert.Equal(t, uint(3), ranked[0].Property.ID)
+	assert.Equal(t, uint(5), ranked[1].Property.ID)
+	assert.Equal(t, uint(9), ranked[2].Property.ID)
+}
@@ -0,0 +1,185 @@
+package search_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/search"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var listings = []models.Property{
+	{ID: 1, Status: models.ListingPublished, Name: "Beach Villa", Location: "Seminyak, Bali", Description: "Steps from the sand, with a private pool and <b>sunset</b> views."},
+	{ID: 2, Status: models.ListingPublished, Name: "Garden Cottage", Location: "Ubud, Bali", Description: "Quiet rice field views, a short drive to the beach villas of Canggu. Shared pool."},
+	{ID: 3, Status: models.ListingPublished, Name: "Château Lumière", Location: "Bordeaux", Description: "Vineyard estate with a heated pool."},
+}
+
+func openIndex(t *testing.T) (*search.Local, string) {
+	path := filepath.Join(t.TempDir(), "search.idx")
+	index, err := search.OpenLocal(nil, path)
+	require.NoError(t, err)
+	require.NoError(t, index.Index(context.Background(), listings...))
+	return index, path
+}
+
+// matchedIDs returns the properties a match restricts the search to, best first
+func matchedIDs(t *testing.T, index *search.Local, text string) []uint {
+	match, err := index.Match(context.Background(), text, "en")
+	require.NoError(t, err)
+
+	var scores map[string]float64
+	require.NoError(t, json.Unmarshal([]byte(match.RankArgs[0].(string)), &scores))
+	ids := []uint{}
+	for id := range scores {
+		n, err := strconv.ParseUint(id, 10, 64)
+		require.NoError(t, err)
+		ids = append(ids, uint(n))
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := scores[fmt.Sprint(ids[i])], scores[fmt.Sprint(ids[j])]
+		if a != b {
+			return a > b
+		}
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+func TestLocalMatchRanksNameAboveDescription(t *testing.T) {
+	index, _ := openIndex(t)
+
+	assert.Equal(t, []uint{1, 2}, matchedIDs(t, index, "beach villa with pool"))
+	assert.Equal(t, []uint{3}, matchedIDs(t, index, "chateau"))
+	assert.Empty(t, matchedIDs(t, index, "ski chalet"))
+}
+
+func TestLocalMatchToleratesTypos(t *testing.T) {
+	index, _ := openIndex(t)
+
+	assert.Equal(t, []uint{1, 2}, matchedIDs(t, index, "beech villa"))
+	assert.Equal(t, []uint{3}, matchedIDs(t, index, "vineyrad"))
+	// Words under four letters must be spelled exactly
+	assert.Empty(t, matchedIDs(t, index, "bal"))
+}
+
+func TestLocalSuggestCompletesLastWord(t *testing.T) {
+	index, _ := openIndex(t)
+	ctx := context.Background()
+
+	ids, err := index.Suggest(ctx, "gard", 5)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{2}, ids)
+
+	ids, err = index.Suggest(ctx, "bali be", 5)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{1, 2}, ids)
+}
+
+func TestLocalSnippetsHighlightMatches(t *testing.T) {
+	index, _ := openIndex(t)
+
+	snippets, err := index.Snippets(context.Background(), []uint{1}, "private pool", "en")
+	require.NoError(t, err)
+	assert.Equal(t, "Steps from the sand, with a <mark>private</mark> <mark>pool</mark> and &lt;b&gt;sunset&lt;/b&gt; views.", snippets[1])
+}
+
+func TestLocalIndexFollowsChangesAndPersists(t *testing.T) {
+	index, path := openIndex(t)
+	ctx := context.Background()
+
+	renamed := listings[1]
+	renamed.Name = "Jungle Treehouse"
+	require.NoError(t, index.Index(ctx, renamed))
+	assert.Empty(t, matchedIDs(t, index, "cottage"))
+	assert.Equal(t, []uint{2}, matchedIDs(t, index, "treehouse"))
+
+	// Archived and deleted properties leave the index
+	archived := listings[0]
+	now := time.Now()
+	archived.ArchivedAt = &now
+	require.NoError(t, index.Index(ctx, archived))
+	require.NoError(t, index.Remove(ctx, 3))
+	assert.Equal(t, 1, index.Len())
+
+	reopened, err := search.OpenLocal(nil, path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, reopened.Len())
+	assert.Equal(t, []uint{2}, matchedIDs(t, reopened, "treehouse"))
+}
+
+func TestLocalMatchKeepsEveryMatch(t *testing.T) {
+	index, _ := openIndex(t)
+
+	// The SQL filters and the total need every match, not only the best ones
+	many := make([]models.Property, 1500)
+	for i := range many {
+		many[i] = models.Property{ID: uint(100 + i), Status: models.ListingPublished, Name: fmt.Sprintf("Surf Shack %d", i)}
+	}
+	require.NoError(t, index.Index(context.Background(), many...))
+	assert.Len(t, matchedIDs(t, index, "surf shack"), 1500)
+}
+
+func TestLocalIndexesCityAndRegionOverLocation(t *testing.T) {
+	index, _ := openIndex(t)
+
+	villa := models.Property{ID: 4, Status: models.ListingPublished, Name: "Rice Field Villa", Location: "Jalan Raya Sayan 17",
+		City: "Ubud", Region: "Bali"}
+	require.NoError(t, index.Index(context.Background(), villa))
+	assert.Contains(t, matchedIDs(t, index, "ubud"), uint(4))
+	assert.Empty(t, matchedIDs(t, index, "sayan"), "the street isn't searched")
+}
+
+func TestLocalReloadsIndexSavedElsewhere(t *testing.T) {
+	index, path := openIndex(t)
+	ctx := context.Background()
+
+	// Another process, such as cmd/reindex, replaces the file
+	other, err := search.OpenLocal(nil, path)
+	require.NoError(t, err)
+	renamed := listings[2]
+	renamed.Name = "Maison Soleil"
+	require.NoError(t, other.Index(ctx, renamed))
+
+	assert.Equal(t, []uint{3}, matchedIDs(t, index, "soleil"))
+
+	// Later changes build on the reloaded index instead of overwriting it
+	require.NoError(t, index.Remove(ctx, 1))
+	reopened, err := search.OpenLocal(nil, path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, reopened.Len())
+	assert.Equal(t, []uint{3}, matchedIDs(t, reopened, "soleil"))
+}
+
+func TestLocalCopiesDontOverwriteEachOther(t *testing.T) {
+	_, path := openIndex(t)
+	ctx := context.Background()
+
+	// Two processes sharing the file index different listings at once
+	var wg sync.WaitGroup
+	for copy := 0; copy < 2; copy++ {
+		index, err := search.OpenLocal(nil, path)
+		require.NoError(t, err)
+		wg.Add(1)
+		go func(copy int) {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				p := models.Property{ID: uint(100 + 20*copy + i), Status: models.ListingPublished, Name: fmt.Sprintf("Listing %d", i)}
+				assert.NoError(t, index.Index(ctx, p))
+			}
+		}(copy)
+	}
+	wg.Wait()
+
+	reopened, err := search.OpenLocal(nil, path)
+	require.NoError(t, err)
+	assert.Equal(t, len(listings)+40, reopened.Len())
+}
@@ -0,0 +1,45 @@
+package storage_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bookaroo/bookaroo-platform-be/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalPutAndDelete(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewLocal(dir, "/uploads/")
+	ctx := context.Background()
+
+	require.NoError(t, store.Put(ctx, "properties/1/abc/thumb.jpg", strings.NewReader("data"), "image/jpeg"))
+	assert.Equal(t, "/uploads/properties/1/abc/thumb.jpg", store.URL("properties/1/abc/thumb.jpg"))
+
+	content, err := os.ReadFile(filepath.Join(dir, "properties/1/abc/thumb.jpg"))
+	require.NoError(t, err)
+	assert.Equal(t, "data", string(content))
+
+	// Deleting removes the file and the directories it leaves empty
+	require.NoError(t, store.Delete(ctx, "properties/1/abc/thumb.jpg"))
+	_, err = os.Stat(filepath.Join(dir, "properties"))
+	assert.True(t, os.IsNotExist(err))
+
+	// Deleting twice is not an error
+	assert.NoError(t, store.Delete(ctx, "properties/1/abc/thumb.jpg"))
+}
+
+func TestLocalKeysStayInsideDir(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewLocal(filepath.Join(dir, "uploads"), "/uploads")
+
+	require.NoError(t, store.Put(context.Background(), "../../escape.txt", strings.NewReader("x"), "text/plain"))
+	_, err := os.Stat(filepath.Join(dir, "uploads", "escape.txt"))
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, "escape.txt"))
+	assert.True(t, os.IsNotExist(err))
+}
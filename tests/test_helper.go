@@ -7,6 +7,9 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/bookaroo/bookaroo-platform-be/config"
+	"github.com/bookaroo/bookaroo-platform-be/middleware"
+	"github.com/bookaroo/bookaroo-platform-be/models"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/driver/postgres"
@@ -20,6 +23,9 @@ func SetupTestDB(t *testing.T) *gorm.DB {
 	if err != nil {
 		t.Fatalf("Failed to connect to test database: %v", err)
 	}
+	if err := config.Migrate(db); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
 	return db
 }
 
@@ -63,6 +69,15 @@ func MakeRequestWithToken(router *gin.Engine, method, path string, body []byte,
 	return w
 }
 
+// GenerateTestToken issues a JWT for the given user to authenticate test requests
+func GenerateTestToken(t *testing.T, user *models.User) string {
+	token, err := middleware.GenerateToken(user)
+	if err != nil {
+		t.Fatalf("Failed to generate test token: %v", err)
+	}
+	return token
+}
+
 // ClearTestDB clears the test database
 func ClearTestDB(t *testing.T, db *gorm.DB) {
 	err := db.Exec("DELETE FROM users").Error
@@ -0,0 +1,126 @@
+package waitlist
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bookaroo/bookaroo-platform-be/availability"
+	"github.com/bookaroo/bookaroo-platform-be/config"
+	"github.com/bookaroo/bookaroo-platform-be/models"
+	"github.com/bookaroo/bookaroo-platform-be/notifications"
+	"gorm.io/gorm"
+)
+
+// Manager offers freed dates to waitlisted guests in the order they joined
+type Manager struct {
+	DB       *gorm.DB
+	Notifier notifications.Notifier
+	Window   time.Duration // How long an offered guest has priority to book
+}
+
+func NewManager(db *gorm.DB, notifier notifications.Notifier) *Manager {
+	return &Manager{
+		DB:       db,
+		Notifier: notifier,
+		Window:   config.GetDuration("WAITLIST_PRIORITY_WINDOW", 24*time.Hour),
+	}
+}
+
+// IsHeld reports whether the dates overlap a priority window offered to another guest
+func IsHeld(db *gorm.DB, propertyID uint, start, end time.Time, userID uint) (bool, error) {
+	var held int64
+	err := db.Model(&models.WaitlistEntry{}).
+		Where("property_id = ? AND status = 'offered' AND offer_expires_at > ? AND start_date < ? AND end_date > ? AND user_id != ?",
+			propertyID, time.Now(), end, start, userID).
+		Count(&held).Error
+	return held > 0, err
+}
+
+// MarkBooked closes the guest's offer once they have booked the offered dates
+func MarkBooked(db *gorm.DB, propertyID, userID uint, start, end time.Time) error {
+	return db.Model(&models.WaitlistEntry{}).
+		Where("property_id = ? AND user_id = ? AND status IN ('waiting', 'offered') AND start_date >= ? AND end_date <= ?",
+			propertyID, userID, start, end).
+		Update("status", "booked").Error
+}
+
+// OfferFreedDates is called when a booking is cancelled. Waiting entries that
+// overlap the freed range are considered oldest first; each one whose full range
+// is now bookable gets a priority window and a notification.
+func (m *Manager) OfferFreedDates(ctx context.Context, propertyID uint, start, end time.Time) error {
+	var entries []models.WaitlistEntry
+	if err := m.DB.WithContext(ctx).
+		Where("property_id = ? AND status = 'waiting' AND start_date < ? AND end_date > ? AND end_date > ?",
+			propertyID, end, start, time.Now()).
+		Order("created_at, id").
+		Find(&entries).Error; err != nil {
+		return err
+	}
+
+	for i := range entries {
+		if err := m.offer(ctx, &entries[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// offer gives the entry a priority window if its dates are free and not held for someone earlier in line
+func (m *Manager) offer(ctx context.Context, entry *models.WaitlistEntry) error {
+	db := m.DB.WithContext(ctx)
+
+	conflict, err := availability.HasConflict(db, entry.PropertyID, entry.StartDate, entry.EndDate, 0)
+	if err != nil || conflict {
+		return err
+	}
+
+	held, err := IsHeld(db, entry.PropertyID, entry.StartDate, entry.EndDate, entry.UserID)
+	if err != nil || held {
+		return err
+	}
+
+	now := time.Now()
+	expires := now.Add(m.Window)
+	if err := db.Model(entry).Updates(map[string]interface{}{
+		"status":           "offered",
+		"offered_at":       now,
+		"offer_expires_at": expires,
+	}).Error; err != nil {
+		return err
+	}
+
+	var property models.Property
+	db.First(&property, entry.PropertyID)
+
+	return m.Notifier.Notify(ctx, &models.Notification{
+		UserID: entry.UserID,
+		Type:   "waitlist_offer",
+		Title:  "Your dates are available",
+		Message: fmt.Sprintf("%s is available from %s to %s. Book before %s to keep your priority.",
+			property.Name, entry.StartDate.Format("2 Jan 2006"), entry.EndDate.Format("2 Jan 2006"), expires.Format(time.RFC1123)),
+		Link: fmt.Sprintf("/api/properties/%d", entry.PropertyID),
+	})
+}
+
+// ExpireOffers ends priority windows that ran out and passes the dates on to the next guests in line
+func (m *Manager) ExpireOffers(ctx context.Context) error {
+	var expired []models.WaitlistEntry
+	if err := m.DB.WithContext(ctx).
+		Where("status = 'offered' AND offer_expires_at <= ?", time.Now()).
+		Find(&expired).Error; err != nil {
+		return err
+	}
+
+	for _, entry := range expired {
+		if err := m.DB.WithContext(ctx).Model(&entry).Update("status", "expired").Error; err != nil {
+			return err
+		}
+		if err := m.OfferFreedDates(ctx, entry.PropertyID, entry.StartDate, entry.EndDate); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}